@@ -0,0 +1,286 @@
+package iterator
+
+import (
+	"context"
+
+	"github.com/cayleygraph/cayley/graph/refs"
+	"github.com/cayleygraph/quad"
+)
+
+// QuadIndex is a subset of the quad store interface that is used to follow edges.
+type QuadIndex interface {
+	// QuadIterator returns an iterator for all quads that have a given node in the specified direction.
+	QuadIterator(d quad.Direction, v refs.Ref) Shape
+	// QuadDirection returns a node of the quad in a given direction.
+	QuadDirection(id refs.Ref, d quad.Direction) (refs.Ref, error)
+}
+
+// Closure iterator returns all nodes reachable from the subiterator by following a single predicate.
+// By default, the predicate is followed from subjects to objects.
+//
+// Unlike Recursive, it computes a transitive closure in a single breadth-first pass and materializes
+// all visited nodes, thus each node is expanded only once, even if the graph contains cycles.
+// Each node is returned once, at the minimal depth it can be reached at. Tags of the subiterator are not preserved.
+type Closure struct {
+	qs        QuadIndex
+	start     Shape
+	via       refs.Ref
+	maxDepth  int
+	depthTags []string
+	rev       bool
+}
+
+// NewClosure creates a new iterator that follows a via predicate from start nodes up to maxDepth steps.
+// If maxDepth is zero, DefaultMaxRecursiveSteps is used. Negative value means no limit.
+func NewClosure(qs QuadIndex, start Shape, via refs.Ref, maxDepth int) *Closure {
+	if maxDepth == 0 {
+		maxDepth = DefaultMaxRecursiveSteps
+	}
+	return &Closure{
+		qs:       qs,
+		start:    start,
+		via:      via,
+		maxDepth: maxDepth,
+	}
+}
+
+// SetReverse changes the iterator to follow the predicate in the reverse direction, from objects to subjects.
+func (it *Closure) SetReverse(rev bool) {
+	it.rev = rev
+}
+
+// AddDepthTag adds a tag that will be set to the depth at which each node was reached.
+func (it *Closure) AddDepthTag(s string) {
+	it.depthTags = append(it.depthTags, s)
+}
+
+func (it *Closure) Iterate() Scanner {
+	return newClosureNext(it)
+}
+
+func (it *Closure) Lookup() Index {
+	return newClosureContains(it)
+}
+
+// SubIterators returns a slice of the sub iterators.
+func (it *Closure) SubIterators() []Shape {
+	return []Shape{it.start}
+}
+
+func (it *Closure) Optimize(ctx context.Context) (Shape, bool) {
+	newIt, optimized := it.start.Optimize(ctx)
+	if optimized {
+		it.start = newIt
+	}
+	return it, false
+}
+
+func (it *Closure) Stats(ctx context.Context) (Costs, error) {
+	subStats, err := it.start.Stats(ctx)
+	return Costs{
+		NextCost: subStats.NextCost,
+		// all nodes are loaded on the first call, lookups are cheap after that
+		ContainsCost: 1,
+		Size: refs.Size{
+			Value: subStats.Size.Value * int64(DefaultMaxRecursiveSteps),
+			Exact: false,
+		},
+	}, err
+}
+
+func (it *Closure) String() string {
+	return "Closure"
+}
+
+// closure is a set of reachable nodes with their depths.
+type closure struct {
+	nodes  []refs.Ref
+	depths []int
+	index  map[interface{}]int
+}
+
+func (c *closure) add(r refs.Ref, depth int) bool {
+	k := refs.ToKey(r)
+	if _, ok := c.index[k]; ok {
+		return false
+	}
+	c.index[k] = len(c.nodes)
+	c.nodes = append(c.nodes, r)
+	c.depths = append(c.depths, depth)
+	return true
+}
+
+// loadClosure runs a breadth-first search from start nodes until no new nodes can be reached.
+func loadClosure(ctx context.Context, it *Closure) (*closure, error) {
+	c := &closure{index: make(map[interface{}]int)}
+	var frontier []refs.Ref
+	sc := it.start.Iterate()
+	for sc.Next(ctx) {
+		frontier = append(frontier, sc.Result())
+	}
+	err := sc.Err()
+	sc.Close()
+	if err != nil {
+		return nil, err
+	}
+	from, to := quad.Subject, quad.Object
+	if it.rev {
+		from, to = to, from
+	}
+	for depth := 1; len(frontier) != 0; depth++ {
+		if it.maxDepth > 0 && depth > it.maxDepth {
+			break
+		}
+		var next []refs.Ref
+		for _, node := range frontier {
+			// let the intersection decide which of the quad indexes to scan
+			links, _ := NewAnd(
+				it.qs.QuadIterator(from, node),
+				it.qs.QuadIterator(quad.Predicate, it.via),
+			).Optimize(ctx)
+			qi := links.Iterate()
+			for qi.Next(ctx) {
+				o, err := it.qs.QuadDirection(qi.Result(), to)
+				if err != nil {
+					qi.Close()
+					return nil, err
+				}
+				if c.add(o, depth) {
+					next = append(next, o)
+				}
+			}
+			err := qi.Err()
+			qi.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+		frontier = next
+	}
+	return c, nil
+}
+
+type closureNext struct {
+	it    *Closure
+	c     *closure
+	index int
+	err   error
+}
+
+func newClosureNext(it *Closure) *closureNext {
+	return &closureNext{it: it}
+}
+
+func (it *closureNext) TagResults(dst map[string]refs.Ref) {
+	if it.index == 0 || it.c == nil {
+		return
+	}
+	// index is already advanced by Next
+	depth := refs.PreFetched(quad.Int(it.c.depths[it.index-1]))
+	for _, tag := range it.it.depthTags {
+		dst[tag] = depth
+	}
+}
+
+func (it *closureNext) Err() error {
+	return it.err
+}
+
+func (it *closureNext) Result() refs.Ref {
+	if it.index == 0 || it.c == nil {
+		return nil
+	}
+	return it.c.nodes[it.index-1]
+}
+
+func (it *closureNext) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	if it.c == nil {
+		it.c, it.err = loadClosure(ctx, it.it)
+		if it.err != nil {
+			return false
+		}
+	}
+	if it.index >= len(it.c.nodes) {
+		return false
+	}
+	it.index++
+	return true
+}
+
+func (it *closureNext) NextPath(ctx context.Context) bool {
+	return false
+}
+
+func (it *closureNext) Close() error {
+	it.c = nil
+	return nil
+}
+
+func (it *closureNext) String() string {
+	return "ClosureNext"
+}
+
+type closureContains struct {
+	it     *Closure
+	c      *closure
+	cur    int
+	result refs.Ref
+	err    error
+}
+
+func newClosureContains(it *Closure) *closureContains {
+	return &closureContains{it: it}
+}
+
+func (it *closureContains) TagResults(dst map[string]refs.Ref) {
+	if it.result == nil {
+		return
+	}
+	depth := refs.PreFetched(quad.Int(it.c.depths[it.cur]))
+	for _, tag := range it.it.depthTags {
+		dst[tag] = depth
+	}
+}
+
+func (it *closureContains) Err() error {
+	return it.err
+}
+
+func (it *closureContains) Result() refs.Ref {
+	return it.result
+}
+
+func (it *closureContains) Contains(ctx context.Context, val refs.Ref) bool {
+	it.result = nil
+	if it.err != nil {
+		return false
+	}
+	if it.c == nil {
+		it.c, it.err = loadClosure(ctx, it.it)
+		if it.err != nil {
+			return false
+		}
+	}
+	i, ok := it.c.index[refs.ToKey(val)]
+	if !ok {
+		return false
+	}
+	it.cur, it.result = i, val
+	return true
+}
+
+func (it *closureContains) NextPath(ctx context.Context) bool {
+	return false
+}
+
+func (it *closureContains) Close() error {
+	it.c = nil
+	return nil
+}
+
+func (it *closureContains) String() string {
+	return "ClosureContains"
+}
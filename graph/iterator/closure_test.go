@@ -0,0 +1,94 @@
+package iterator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cayleygraph/cayley/graph/graphmock"
+	. "github.com/cayleygraph/cayley/graph/iterator"
+	"github.com/cayleygraph/cayley/graph/refs"
+	"github.com/cayleygraph/quad"
+)
+
+var closureTestQs = &graphmock.Store{
+	Data: []quad.Quad{
+		quad.MakeIRI("a", "follows", "b", ""),
+		quad.MakeIRI("b", "follows", "c", ""),
+		quad.MakeIRI("c", "follows", "a", ""),
+		quad.MakeIRI("c", "follows", "d", ""),
+		quad.MakeIRI("c", "likes", "e", ""),
+	},
+}
+
+func closureDepths(t testing.TB, it Shape) map[quad.Value]quad.Value {
+	ctx := context.TODO()
+	got := make(map[quad.Value]quad.Value)
+	sc := it.Iterate()
+	for sc.Next(ctx) {
+		tags := make(map[string]refs.Ref)
+		sc.TagResults(tags)
+		v := sc.Result().(refs.PreFetchedValue).NameOf()
+		_, dup := got[v]
+		require.False(t, dup, "node returned twice: %v", v)
+		got[v] = tags["depth"].(refs.PreFetchedValue).NameOf()
+	}
+	require.NoError(t, sc.Err())
+	require.NoError(t, sc.Close())
+	return got
+}
+
+func TestClosure(t *testing.T) {
+	ctx := context.TODO()
+	start := NewFixed(refs.PreFetched(quad.IRI("a")))
+	via := refs.PreFetched(quad.IRI("follows"))
+
+	it := NewClosure(closureTestQs, start, via, 0)
+	it.AddDepthTag("depth")
+	require.Equal(t, map[quad.Value]quad.Value{
+		quad.IRI("b"): quad.Int(1),
+		quad.IRI("c"): quad.Int(2),
+		quad.IRI("a"): quad.Int(3),
+		quad.IRI("d"): quad.Int(3),
+	}, closureDepths(t, it))
+
+	ix := it.Lookup()
+	require.True(t, ix.Contains(ctx, refs.PreFetched(quad.IRI("d"))))
+	tags := make(map[string]refs.Ref)
+	ix.TagResults(tags)
+	require.Equal(t, quad.Int(3), tags["depth"].(refs.PreFetchedValue).NameOf())
+	require.False(t, ix.Contains(ctx, refs.PreFetched(quad.IRI("e"))))
+	require.NoError(t, ix.Close())
+}
+
+func TestClosureMaxDepth(t *testing.T) {
+	start := NewFixed(refs.PreFetched(quad.IRI("a")))
+	via := refs.PreFetched(quad.IRI("follows"))
+
+	it := NewClosure(closureTestQs, start, via, 2)
+	it.AddDepthTag("depth")
+	require.Equal(t, map[quad.Value]quad.Value{
+		quad.IRI("b"): quad.Int(1),
+		quad.IRI("c"): quad.Int(2),
+	}, closureDepths(t, it))
+
+	// cycles are still visited only once without a limit
+	it = NewClosure(closureTestQs, start, via, -1)
+	it.AddDepthTag("depth")
+	require.Len(t, closureDepths(t, it), 4)
+}
+
+func TestClosureReverse(t *testing.T) {
+	start := NewFixed(refs.PreFetched(quad.IRI("d")))
+	via := refs.PreFetched(quad.IRI("follows"))
+
+	it := NewClosure(closureTestQs, start, via, 0)
+	it.SetReverse(true)
+	it.AddDepthTag("depth")
+	require.Equal(t, map[quad.Value]quad.Value{
+		quad.IRI("c"): quad.Int(1),
+		quad.IRI("b"): quad.Int(2),
+		quad.IRI("a"): quad.Int(3),
+	}, closureDepths(t, it))
+}
@@ -0,0 +1,280 @@
+package iterator
+
+import (
+	"context"
+
+	"github.com/cayleygraph/cayley/graph/refs"
+	"github.com/cayleygraph/quad"
+)
+
+// Components iterator labels nodes with ids of connected components they belong to.
+//
+// Edges are read from the subiterator: each result is a target of an edge, while the source
+// of the edge is expected to be saved into a srcTag. Edge directions are ignored.
+// Component ids are sequential quad.Int values assigned in the order nodes appear in the subiterator.
+type Components struct {
+	edges  Shape
+	srcTag string
+	tags   []string
+}
+
+// NewComponents creates a new iterator that returns all nodes connected by edges from a subiterator.
+// Component ids are saved into provided tags.
+func NewComponents(edges Shape, srcTag string, tags ...string) *Components {
+	return &Components{
+		edges:  edges,
+		srcTag: srcTag,
+		tags:   tags,
+	}
+}
+
+func (it *Components) Iterate() Scanner {
+	return newComponentsNext(it.edges, it.srcTag, it.tags)
+}
+
+func (it *Components) Lookup() Index {
+	return newComponentsContains(it.edges, it.srcTag, it.tags)
+}
+
+// SubIterators returns a slice of the sub iterators.
+func (it *Components) SubIterators() []Shape {
+	return []Shape{it.edges}
+}
+
+func (it *Components) Optimize(ctx context.Context) (Shape, bool) {
+	newIt, optimized := it.edges.Optimize(ctx)
+	if optimized {
+		it.edges = newIt
+	}
+	return it, false
+}
+
+func (it *Components) Stats(ctx context.Context) (Costs, error) {
+	subStats, err := it.edges.Stats(ctx)
+	return Costs{
+		NextCost: subStats.NextCost,
+		// all edges are loaded on the first call, lookups are cheap after that
+		ContainsCost: 1,
+		Size: refs.Size{
+			Value: subStats.Size.Value,
+			Exact: false,
+		},
+	}, err
+}
+
+func (it *Components) String() string {
+	return "Components"
+}
+
+// components is a set of nodes split into connected components.
+type components struct {
+	nodes []refs.Ref
+	ids   []int // component id for each node
+	index map[interface{}]int
+}
+
+func (c *components) add(r refs.Ref, parent *[]int) int {
+	k := refs.ToKey(r)
+	i, ok := c.index[k]
+	if !ok {
+		i = len(c.nodes)
+		c.index[k] = i
+		c.nodes = append(c.nodes, r)
+		*parent = append(*parent, i)
+	}
+	return i
+}
+
+// findRoot returns a root of the node in a disjoint set forest, compressing the path along the way.
+func findRoot(parent []int, i int) int {
+	for parent[i] != i {
+		parent[i] = parent[parent[i]]
+		i = parent[i]
+	}
+	return i
+}
+
+// loadComponents reads all edges from the iterator and splits nodes into connected components
+// using the union-find algorithm.
+func loadComponents(ctx context.Context, edges Shape, srcTag string) (*components, error) {
+	it := edges.Iterate()
+	defer it.Close()
+	c := &components{index: make(map[interface{}]int)}
+	var parent []int
+	union := func(tags map[string]refs.Ref, dst refs.Ref) {
+		src, ok := tags[srcTag]
+		if !ok {
+			return
+		}
+		a, b := findRoot(parent, c.add(src, &parent)), findRoot(parent, c.add(dst, &parent))
+		// keep the node that appeared first as a root
+		if a < b {
+			parent[b] = a
+		} else if b < a {
+			parent[a] = b
+		}
+	}
+	for it.Next(ctx) {
+		dst := it.Result()
+		tags := make(map[string]refs.Ref)
+		it.TagResults(tags)
+		union(tags, dst)
+		for it.NextPath(ctx) {
+			tags = make(map[string]refs.Ref)
+			it.TagResults(tags)
+			union(tags, dst)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	c.ids = make([]int, len(c.nodes))
+	rootIDs := make(map[int]int)
+	for i := range c.nodes {
+		root := findRoot(parent, i)
+		id, ok := rootIDs[root]
+		if !ok {
+			id = len(rootIDs)
+			rootIDs[root] = id
+		}
+		c.ids[i] = id
+	}
+	return c, nil
+}
+
+type componentsNext struct {
+	edges  Shape
+	srcTag string
+	tags   []string
+	comps  *components
+	index  int
+	err    error
+}
+
+func newComponentsNext(edges Shape, srcTag string, tags []string) *componentsNext {
+	return &componentsNext{
+		edges:  edges,
+		srcTag: srcTag,
+		tags:   tags,
+	}
+}
+
+func (it *componentsNext) TagResults(dst map[string]refs.Ref) {
+	if it.index == 0 || it.comps == nil {
+		return
+	}
+	// index is already advanced by Next
+	id := refs.PreFetched(quad.Int(it.comps.ids[it.index-1]))
+	for _, tag := range it.tags {
+		dst[tag] = id
+	}
+}
+
+func (it *componentsNext) Err() error {
+	return it.err
+}
+
+func (it *componentsNext) Result() refs.Ref {
+	if it.index == 0 || it.comps == nil {
+		return nil
+	}
+	return it.comps.nodes[it.index-1]
+}
+
+func (it *componentsNext) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	if it.comps == nil {
+		it.comps, it.err = loadComponents(ctx, it.edges, it.srcTag)
+		if it.err != nil {
+			return false
+		}
+	}
+	if it.index >= len(it.comps.nodes) {
+		return false
+	}
+	it.index++
+	return true
+}
+
+func (it *componentsNext) NextPath(ctx context.Context) bool {
+	return false
+}
+
+func (it *componentsNext) Close() error {
+	it.comps = nil
+	return nil
+}
+
+func (it *componentsNext) String() string {
+	return "ComponentsNext"
+}
+
+type componentsContains struct {
+	edges  Shape
+	srcTag string
+	tags   []string
+	comps  *components
+	cur    int
+	result refs.Ref
+	err    error
+}
+
+func newComponentsContains(edges Shape, srcTag string, tags []string) *componentsContains {
+	return &componentsContains{
+		edges:  edges,
+		srcTag: srcTag,
+		tags:   tags,
+	}
+}
+
+func (it *componentsContains) TagResults(dst map[string]refs.Ref) {
+	if it.result == nil {
+		return
+	}
+	id := refs.PreFetched(quad.Int(it.comps.ids[it.cur]))
+	for _, tag := range it.tags {
+		dst[tag] = id
+	}
+}
+
+func (it *componentsContains) Err() error {
+	return it.err
+}
+
+func (it *componentsContains) Result() refs.Ref {
+	return it.result
+}
+
+func (it *componentsContains) Contains(ctx context.Context, val refs.Ref) bool {
+	it.result = nil
+	if it.err != nil {
+		return false
+	}
+	if it.comps == nil {
+		it.comps, it.err = loadComponents(ctx, it.edges, it.srcTag)
+		if it.err != nil {
+			return false
+		}
+	}
+	i, ok := it.comps.index[refs.ToKey(val)]
+	if !ok {
+		return false
+	}
+	it.cur, it.result = i, val
+	return true
+}
+
+func (it *componentsContains) NextPath(ctx context.Context) bool {
+	return false
+}
+
+func (it *componentsContains) Close() error {
+	it.comps = nil
+	return nil
+}
+
+func (it *componentsContains) String() string {
+	return "ComponentsContains"
+}
@@ -0,0 +1,54 @@
+package iterator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	. "github.com/cayleygraph/cayley/graph/iterator"
+	"github.com/cayleygraph/cayley/graph/refs"
+	"github.com/cayleygraph/quad"
+)
+
+func TestComponents(t *testing.T) {
+	ctx := context.TODO()
+	edge := func(src, dst string) Shape {
+		it := NewSave(NewFixed(refs.PreFetched(quad.IRI(dst))))
+		it.AddFixedTag("src", refs.PreFetched(quad.IRI(src)))
+		return it
+	}
+	edges := NewOr(
+		edge("a", "b"),
+		edge("c", "d"),
+		edge("c", "b"),
+		edge("e", "f"),
+	)
+	it := NewComponents(edges, "src", "comp")
+
+	got := make(map[quad.Value]quad.Value)
+	sc := it.Iterate()
+	for sc.Next(ctx) {
+		tags := make(map[string]refs.Ref)
+		sc.TagResults(tags)
+		got[sc.Result().(refs.PreFetchedValue).NameOf()] = tags["comp"].(refs.PreFetchedValue).NameOf()
+	}
+	require.NoError(t, sc.Err())
+	require.NoError(t, sc.Close())
+	require.Equal(t, map[quad.Value]quad.Value{
+		quad.IRI("a"): quad.Int(0),
+		quad.IRI("b"): quad.Int(0),
+		quad.IRI("c"): quad.Int(0),
+		quad.IRI("d"): quad.Int(0),
+		quad.IRI("e"): quad.Int(1),
+		quad.IRI("f"): quad.Int(1),
+	}, got)
+
+	ix := it.Lookup()
+	require.True(t, ix.Contains(ctx, refs.PreFetched(quad.IRI("f"))))
+	tags := make(map[string]refs.Ref)
+	ix.TagResults(tags)
+	require.Equal(t, quad.Int(1), tags["comp"].(refs.PreFetchedValue).NameOf())
+	require.False(t, ix.Contains(ctx, refs.PreFetched(quad.IRI("g"))))
+	require.NoError(t, ix.Close())
+}
@@ -9,8 +9,9 @@ import (
 
 // Count iterator returns one element with size of underlying iterator.
 type Count struct {
-	it Shape
-	qs refs.Namer
+	it    Shape
+	qs    refs.Namer
+	paths bool
 }
 
 // NewCount creates a new iterator to count a number of results from a provided subiterator.
@@ -21,12 +22,20 @@ func NewCount(it Shape, qs refs.Namer) *Count {
 	}
 }
 
+// NewPathCount is the same as NewCount, but it counts all paths for each result of the subiterator
+// instead of relying on its size. For example, a node that can be reached in two ways is counted twice.
+func NewPathCount(it Shape, qs refs.Namer) *Count {
+	c := NewCount(it, qs)
+	c.paths = true
+	return c
+}
+
 func (it *Count) Iterate() Scanner {
-	return newCountNext(it.it)
+	return newCountNext(it.it, it.paths)
 }
 
 func (it *Count) Lookup() Index {
-	return newCountContains(it.it, it.qs)
+	return newCountContains(it.it, it.qs, it.paths)
 }
 
 // SubIterators returns a slice of the sub iterators.
@@ -48,18 +57,24 @@ func (it *Count) Stats(ctx context.Context) (Costs, error) {
 			Exact: true,
 		},
 	}
-	if sub, err := it.it.Stats(ctx); err == nil && !sub.Size.Exact {
+	if sub, err := it.it.Stats(ctx); err == nil && (it.paths || !sub.Size.Exact) {
 		stats.NextCost = sub.NextCost * sub.Size.Value
 	}
 	stats.ContainsCost = stats.NextCost
 	return stats, nil
 }
 
-func (it *Count) String() string { return "Count" }
+func (it *Count) String() string {
+	if it.paths {
+		return "PathCount"
+	}
+	return "Count"
+}
 
 // Count iterator returns one element with size of underlying iterator.
 type countNext struct {
 	it     Shape
+	paths  bool
 	done   bool
 	result quad.Value
 	err    error
@@ -67,30 +82,38 @@ type countNext struct {
 
 // NewCount creates a new iterator to count a number of results from a provided subiterator.
 // qs may be nil - it's used to check if count Contains (is) a given value.
-func newCountNext(it Shape) *countNext {
+func newCountNext(it Shape, paths bool) *countNext {
 	return &countNext{
-		it: it,
+		it:    it,
+		paths: paths,
 	}
 }
 
 func (it *countNext) TagResults(dst map[string]refs.Ref) {}
 
 // Next counts a number of results in underlying iterator.
+//
+// It always returns exactly one value, even if underlying iterator is empty.
 func (it *countNext) Next(ctx context.Context) bool {
 	if it.done {
 		return false
 	}
-	// TODO(dennwc): this most likely won't include the NextPath
-	st, err := it.it.Stats(ctx)
-	if err != nil {
-		it.err = err
-		return false
+	var (
+		st  Costs
+		err error
+	)
+	if !it.paths {
+		st, err = it.it.Stats(ctx)
 	}
-	if !st.Size.Exact {
+	if it.paths || err != nil || !st.Size.Exact {
+		// paths were requested, or stats are either not available or not exact - count manually
 		sit := it.it.Iterate()
 		defer sit.Close()
 		for st.Size.Value = 0; sit.Next(ctx); st.Size.Value++ {
-			// TODO(dennwc): it's unclear if we should call it here or not
+			if !it.paths {
+				// only count results; alternative paths to the same result are counted by PathCount
+				continue
+			}
 			for ; sit.NextPath(ctx); st.Size.Value++ {
 			}
 		}
@@ -131,9 +154,9 @@ type countContains struct {
 
 // NewCount creates a new iterator to count a number of results from a provided subiterator.
 // qs may be nil - it's used to check if count Contains (is) a given value.
-func newCountContains(it Shape, qs refs.Namer) *countContains {
+func newCountContains(it Shape, qs refs.Namer, paths bool) *countContains {
 	return &countContains{
-		it: newCountNext(it),
+		it: newCountNext(it, paths),
 		qs: qs,
 	}
 }
@@ -43,4 +43,64 @@ func TestCount(t *testing.T) {
 	itc = its.Lookup()
 	require.False(t, itc.Contains(ctx, refs.PreFetched(quad.Int(5))))
 	require.True(t, itc.Contains(ctx, refs.PreFetched(quad.Int(2))))
+
+	its = NewCount(NewNull(), nil)
+
+	itn = its.Iterate()
+	require.True(t, itn.Next(ctx))
+	require.Equal(t, refs.PreFetched(quad.Int(0)), itn.Result())
+	require.False(t, itn.Next(ctx))
+}
+
+// inexactSize hides the exact size of a subiterator, so it must be counted by iterating it.
+type inexactSize struct {
+	Shape
+}
+
+func (it inexactSize) Stats(ctx context.Context) (Costs, error) {
+	st, err := it.Shape.Stats(ctx)
+	st.Size.Exact = false
+	return st, err
+}
+
+func TestPathCount(t *testing.T) {
+	ctx := context.TODO()
+	path := func(v, tag string) Shape {
+		it := NewSave(NewFixed(refs.PreFetched(quad.String(v))))
+		it.AddFixedTag("via", refs.PreFetched(quad.String(tag)))
+		return it
+	}
+	// materialize groups the same values together, thus "a" will have two paths
+	sub := NewMaterialize(NewOr(
+		path("a", "x"),
+		path("a", "y"),
+		path("b", "x"),
+	))
+	its := NewPathCount(sub, nil)
+
+	itn := its.Iterate()
+	require.True(t, itn.Next(ctx))
+	require.Equal(t, refs.PreFetched(quad.Int(3)), itn.Result())
+	require.False(t, itn.Next(ctx))
+
+	itc := its.Lookup()
+	require.True(t, itc.Contains(ctx, refs.PreFetched(quad.Int(3))))
+	require.False(t, itc.Contains(ctx, refs.PreFetched(quad.Int(2))))
+
+	// plain count does not include alternative paths; size of the subiterator is not exact here
+	inexact := inexactSize{sub}
+	itn = NewCount(inexact, nil).Iterate()
+	require.True(t, itn.Next(ctx))
+	require.Equal(t, refs.PreFetched(quad.Int(2)), itn.Result())
+
+	itn = NewPathCount(inexact, nil).Iterate()
+	require.True(t, itn.Next(ctx))
+	require.Equal(t, refs.PreFetched(quad.Int(3)), itn.Result())
+
+	its = NewPathCount(NewNull(), nil)
+
+	itn = its.Iterate()
+	require.True(t, itn.Next(ctx))
+	require.Equal(t, refs.PreFetched(quad.Int(0)), itn.Result())
+	require.False(t, itn.Next(ctx))
 }
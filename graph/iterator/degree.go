@@ -0,0 +1,219 @@
+package iterator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cayleygraph/cayley/graph/refs"
+	"github.com/cayleygraph/quad"
+)
+
+// Degree iterator returns nodes in a given direction of quads from the subiterator, but only if the number
+// of quads linked to the node is in a given range.
+//
+// It counts quads for all nodes on the first call, thus the full set of quads is loaded into memory.
+// Each node is returned once, in the order it was first seen. Tags of the subiterator are not preserved.
+type Degree struct {
+	qs    QuadIndex
+	quads Shape
+	dir   quad.Direction
+	min   int64
+	max   int64
+}
+
+// NewDegree creates a new iterator that returns nodes in the dir direction of quads, that have at least min
+// and at most max quads linked to them. Zero max means there is no upper limit.
+func NewDegree(qs QuadIndex, quads Shape, dir quad.Direction, min, max int64) *Degree {
+	return &Degree{
+		qs:    qs,
+		quads: quads,
+		dir:   dir,
+		min:   min,
+		max:   max,
+	}
+}
+
+func (it *Degree) Iterate() Scanner {
+	return newDegreeNext(it)
+}
+
+func (it *Degree) Lookup() Index {
+	return newDegreeContains(it)
+}
+
+// SubIterators returns a slice of the sub iterators.
+func (it *Degree) SubIterators() []Shape {
+	return []Shape{it.quads}
+}
+
+func (it *Degree) Optimize(ctx context.Context) (Shape, bool) {
+	newIt, optimized := it.quads.Optimize(ctx)
+	if optimized {
+		it.quads = newIt
+	}
+	return it, false
+}
+
+func (it *Degree) Stats(ctx context.Context) (Costs, error) {
+	subStats, err := it.quads.Stats(ctx)
+	return Costs{
+		NextCost: subStats.NextCost,
+		// all nodes are loaded on the first call, lookups are cheap after that
+		ContainsCost: 1,
+		Size: refs.Size{
+			Value: subStats.Size.Value,
+			Exact: false,
+		},
+	}, err
+}
+
+func (it *Degree) String() string {
+	return fmt.Sprintf("Degree(%v, %d, %d)", it.dir, it.min, it.max)
+}
+
+// degrees is a set of nodes that passed the degree filter.
+type degrees struct {
+	nodes []refs.Ref
+	index map[interface{}]struct{}
+}
+
+// loadDegrees counts quads for each node and keeps nodes with the number of quads in the range.
+func loadDegrees(ctx context.Context, it *Degree) (*degrees, error) {
+	var order []refs.Ref
+	counts := make(map[interface{}]int64)
+	sc := it.quads.Iterate()
+	defer sc.Close()
+	for sc.Next(ctx) {
+		n, err := it.qs.QuadDirection(sc.Result(), it.dir)
+		if err != nil {
+			return nil, err
+		} else if n == nil {
+			continue
+		}
+		k := refs.ToKey(n)
+		if _, ok := counts[k]; !ok {
+			order = append(order, n)
+		}
+		counts[k]++
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	d := &degrees{index: make(map[interface{}]struct{})}
+	for _, n := range order {
+		k := refs.ToKey(n)
+		c := counts[k]
+		if c < it.min || (it.max > 0 && c > it.max) {
+			continue
+		}
+		d.nodes = append(d.nodes, n)
+		d.index[k] = struct{}{}
+	}
+	return d, nil
+}
+
+type degreeNext struct {
+	it    *Degree
+	d     *degrees
+	index int
+	err   error
+}
+
+func newDegreeNext(it *Degree) *degreeNext {
+	return &degreeNext{it: it}
+}
+
+func (it *degreeNext) TagResults(dst map[string]refs.Ref) {}
+
+func (it *degreeNext) Err() error {
+	return it.err
+}
+
+func (it *degreeNext) Result() refs.Ref {
+	if it.index == 0 || it.d == nil {
+		return nil
+	}
+	return it.d.nodes[it.index-1]
+}
+
+func (it *degreeNext) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	if it.d == nil {
+		it.d, it.err = loadDegrees(ctx, it.it)
+		if it.err != nil {
+			return false
+		}
+	}
+	if it.index >= len(it.d.nodes) {
+		return false
+	}
+	it.index++
+	return true
+}
+
+func (it *degreeNext) NextPath(ctx context.Context) bool {
+	return false
+}
+
+func (it *degreeNext) Close() error {
+	it.d = nil
+	return nil
+}
+
+func (it *degreeNext) String() string {
+	return "DegreeNext"
+}
+
+type degreeContains struct {
+	it     *Degree
+	d      *degrees
+	result refs.Ref
+	err    error
+}
+
+func newDegreeContains(it *Degree) *degreeContains {
+	return &degreeContains{it: it}
+}
+
+func (it *degreeContains) TagResults(dst map[string]refs.Ref) {}
+
+func (it *degreeContains) Err() error {
+	return it.err
+}
+
+func (it *degreeContains) Result() refs.Ref {
+	return it.result
+}
+
+func (it *degreeContains) Contains(ctx context.Context, val refs.Ref) bool {
+	it.result = nil
+	if it.err != nil {
+		return false
+	}
+	if it.d == nil {
+		it.d, it.err = loadDegrees(ctx, it.it)
+		if it.err != nil {
+			return false
+		}
+	}
+	if _, ok := it.d.index[refs.ToKey(val)]; !ok {
+		return false
+	}
+	it.result = val
+	return true
+}
+
+func (it *degreeContains) NextPath(ctx context.Context) bool {
+	return false
+}
+
+func (it *degreeContains) Close() error {
+	it.d = nil
+	return nil
+}
+
+func (it *degreeContains) String() string {
+	return "DegreeContains"
+}
@@ -0,0 +1,44 @@
+package iterator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	. "github.com/cayleygraph/cayley/graph/iterator"
+	"github.com/cayleygraph/cayley/graph/refs"
+	"github.com/cayleygraph/quad"
+)
+
+func TestDegree(t *testing.T) {
+	ctx := context.TODO()
+	qs := closureTestQs
+	values := func(it Shape) []quad.Value {
+		var out []quad.Value
+		sc := it.Iterate()
+		for sc.Next(ctx) {
+			out = append(out, sc.Result().(refs.PreFetchedValue).NameOf())
+		}
+		require.NoError(t, sc.Err())
+		require.NoError(t, sc.Close())
+		return out
+	}
+
+	// c has 3 quads, a and b have a single one
+	it := NewDegree(qs, qs.QuadsAllIterator(), quad.Subject, 2, 0)
+	require.Equal(t, []quad.Value{quad.IRI("c")}, values(it))
+
+	it = NewDegree(qs, qs.QuadsAllIterator(), quad.Subject, 1, 2)
+	require.Equal(t, []quad.Value{quad.IRI("a"), quad.IRI("b")}, values(it))
+
+	// objects are counted in the same way
+	it = NewDegree(qs, qs.QuadsAllIterator(), quad.Object, 2, 0)
+	require.Empty(t, values(it))
+
+	ix := NewDegree(qs, qs.QuadsAllIterator(), quad.Subject, 3, 3).Lookup()
+	require.True(t, ix.Contains(ctx, refs.PreFetched(quad.IRI("c"))))
+	require.False(t, ix.Contains(ctx, refs.PreFetched(quad.IRI("a"))))
+	require.False(t, ix.Contains(ctx, refs.PreFetched(quad.IRI("e"))))
+	require.NoError(t, ix.Close())
+}
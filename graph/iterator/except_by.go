@@ -0,0 +1,238 @@
+package iterator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cayleygraph/cayley/graph/refs"
+)
+
+// ExceptBy iterator removes paths from it's subiterator that have a value of the tag in the excluded set.
+//
+// Unlike Not, the value of the result itself is not considered - only the value of the specified tag.
+// Paths that don't have the tag are not removed.
+type ExceptBy struct {
+	subIt   Shape
+	tag     string
+	exclude Shape
+}
+
+// NewExceptBy creates a new iterator that removes paths with a value of a given tag found in the excluded set.
+func NewExceptBy(subIt Shape, tag string, exclude Shape) *ExceptBy {
+	return &ExceptBy{
+		subIt:   subIt,
+		tag:     tag,
+		exclude: exclude,
+	}
+}
+
+func (it *ExceptBy) Iterate() Scanner {
+	return newExceptByNext(it.subIt.Iterate(), it.tag, it.exclude.Lookup())
+}
+
+func (it *ExceptBy) Lookup() Index {
+	return newExceptByContains(it.subIt.Lookup(), it.tag, it.exclude.Lookup())
+}
+
+// SubIterators returns a slice of the sub iterators.
+func (it *ExceptBy) SubIterators() []Shape {
+	return []Shape{it.subIt, it.exclude}
+}
+
+func (it *ExceptBy) Optimize(ctx context.Context) (Shape, bool) {
+	newIt, optimized := it.subIt.Optimize(ctx)
+	if optimized {
+		it.subIt = newIt
+	}
+	newEx, optimized := it.exclude.Optimize(ctx)
+	if optimized {
+		it.exclude = newEx
+	}
+	return it, false
+}
+
+func (it *ExceptBy) Stats(ctx context.Context) (Costs, error) {
+	subStats, err := it.subIt.Stats(ctx)
+	exStats, err2 := it.exclude.Stats(ctx)
+	if err == nil {
+		err = err2
+	}
+	return Costs{
+		NextCost:     subStats.NextCost + exStats.ContainsCost,
+		ContainsCost: subStats.ContainsCost + exStats.ContainsCost,
+		Size: refs.Size{
+			Value: subStats.Size.Value,
+			Exact: false,
+		},
+	}, err
+}
+
+func (it *ExceptBy) String() string {
+	return fmt.Sprintf("ExceptBy(%q)", it.tag)
+}
+
+// exceptByFilter checks tag values of the current path against the excluded set.
+type exceptByFilter struct {
+	tag     string
+	exclude Index
+	buf     map[string]refs.Ref
+	err     error
+}
+
+// allowed checks if the value of the tag on the current path of the iterator is not excluded.
+func (f *exceptByFilter) allowed(ctx context.Context, it Base) bool {
+	for k := range f.buf {
+		delete(f.buf, k)
+	}
+	it.TagResults(f.buf)
+	v, ok := f.buf[f.tag]
+	if !ok {
+		return true
+	}
+	if f.exclude.Contains(ctx, v) {
+		return false
+	}
+	f.err = f.exclude.Err()
+	return f.err == nil
+}
+
+type exceptByNext struct {
+	subIt Scanner
+	f     exceptByFilter
+	err   error
+}
+
+func newExceptByNext(subIt Scanner, tag string, exclude Index) *exceptByNext {
+	return &exceptByNext{
+		subIt: subIt,
+		f: exceptByFilter{
+			tag:     tag,
+			exclude: exclude,
+			buf:     make(map[string]refs.Ref),
+		},
+	}
+}
+
+func (it *exceptByNext) TagResults(dst map[string]refs.Ref) {
+	it.subIt.TagResults(dst)
+}
+
+// Next advances the subiterator, continuing until it returns a path with a value of the tag
+// that is not in the excluded set.
+func (it *exceptByNext) Next(ctx context.Context) bool {
+	for it.subIt.Next(ctx) {
+		if it.f.allowed(ctx, it.subIt) {
+			return true
+		} else if it.f.err != nil {
+			it.err = it.f.err
+			return false
+		}
+		// other paths for the same value might still be allowed
+		if it.NextPath(ctx) {
+			return true
+		} else if it.err != nil {
+			return false
+		}
+	}
+	it.err = it.subIt.Err()
+	return false
+}
+
+func (it *exceptByNext) Err() error {
+	return it.err
+}
+
+func (it *exceptByNext) Result() refs.Ref {
+	return it.subIt.Result()
+}
+
+func (it *exceptByNext) NextPath(ctx context.Context) bool {
+	for it.subIt.NextPath(ctx) {
+		if it.f.allowed(ctx, it.subIt) {
+			return true
+		} else if it.f.err != nil {
+			it.err = it.f.err
+			return false
+		}
+	}
+	return false
+}
+
+func (it *exceptByNext) Close() error {
+	err := it.subIt.Close()
+	if err2 := it.f.exclude.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+func (it *exceptByNext) String() string {
+	return fmt.Sprintf("ExceptByNext(%q)", it.f.tag)
+}
+
+type exceptByContains struct {
+	subIt Index
+	f     exceptByFilter
+	err   error
+}
+
+func newExceptByContains(subIt Index, tag string, exclude Index) *exceptByContains {
+	return &exceptByContains{
+		subIt: subIt,
+		f: exceptByFilter{
+			tag:     tag,
+			exclude: exclude,
+			buf:     make(map[string]refs.Ref),
+		},
+	}
+}
+
+func (it *exceptByContains) TagResults(dst map[string]refs.Ref) {
+	it.subIt.TagResults(dst)
+}
+
+func (it *exceptByContains) Err() error {
+	return it.err
+}
+
+func (it *exceptByContains) Result() refs.Ref {
+	return it.subIt.Result()
+}
+
+func (it *exceptByContains) Contains(ctx context.Context, val refs.Ref) bool {
+	if !it.subIt.Contains(ctx, val) {
+		it.err = it.subIt.Err()
+		return false
+	}
+	if it.f.allowed(ctx, it.subIt) {
+		return true
+	} else if it.f.err != nil {
+		it.err = it.f.err
+		return false
+	}
+	return it.NextPath(ctx)
+}
+
+func (it *exceptByContains) NextPath(ctx context.Context) bool {
+	for it.subIt.NextPath(ctx) {
+		if it.f.allowed(ctx, it.subIt) {
+			return true
+		} else if it.f.err != nil {
+			it.err = it.f.err
+			return false
+		}
+	}
+	return false
+}
+
+func (it *exceptByContains) Close() error {
+	err := it.subIt.Close()
+	if err2 := it.f.exclude.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+func (it *exceptByContains) String() string {
+	return fmt.Sprintf("ExceptByContains(%q)", it.f.tag)
+}
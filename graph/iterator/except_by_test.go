@@ -0,0 +1,38 @@
+package iterator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	. "github.com/cayleygraph/cayley/graph/iterator"
+)
+
+func TestExceptByIteratorBasics(t *testing.T) {
+	ctx := context.TODO()
+	cool := NewSave(NewFixed(
+		Int64Node(1),
+		Int64Node(2),
+	))
+	cool.AddFixedTag("status", Int64Node(10))
+	smart := NewSave(NewFixed(Int64Node(3)))
+	smart.AddFixedTag("status", Int64Node(20))
+
+	e := NewExceptBy(NewOr(cool, smart), "status", NewFixed(Int64Node(10)))
+
+	expect := []int{3}
+	for i := 0; i < 2; i++ {
+		require.Equal(t, expect, iterated(e))
+	}
+
+	ec := e.Lookup()
+	for _, v := range []int{1, 2} {
+		require.False(t, ec.Contains(ctx, Int64Node(v)))
+	}
+	require.True(t, ec.Contains(ctx, Int64Node(3)))
+
+	// results without the tag are not removed
+	e = NewExceptBy(NewFixed(Int64Node(1), Int64Node(2)), "status", NewFixed(Int64Node(10)))
+	require.Equal(t, []int{1, 2}, iterated(e))
+}
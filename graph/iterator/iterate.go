@@ -130,11 +130,10 @@ func (c *Chain) Count() (int64, error) {
 	if c.optimize {
 		c.s, _ = c.s.Optimize(c.ctx)
 	}
-	if st, err := c.s.Stats(c.ctx); err != nil {
-		return st.Size.Value, err
-	} else if st.Size.Exact {
+	if st, err := c.s.Stats(c.ctx); err == nil && st.Size.Exact {
 		return st.Size.Value, nil
 	}
+	// stats are either not available or not exact - count manually
 	c.start()
 	defer c.end()
 	if err := c.it.Err(); err != nil {
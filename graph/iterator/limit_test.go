@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	. "github.com/cayleygraph/cayley/graph/iterator"
+	"github.com/cayleygraph/cayley/graph/refs"
 )
 
 func TestLimitIteratorBasics(t *testing.T) {
@@ -42,3 +43,27 @@ func TestLimitIteratorBasics(t *testing.T) {
 	}
 	require.False(t, uc.Contains(ctx, Int64Node(2)))
 }
+
+func TestLimitExactSize(t *testing.T) {
+	ctx := context.TODO()
+	allIt := NewFixed(
+		Int64Node(1),
+		Int64Node(2),
+		Int64Node(3),
+		Int64Node(4),
+		Int64Node(5),
+	)
+
+	st, err := NewLimit(allIt, 3).Stats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, refs.Size{Value: 3, Exact: true}, st.Size)
+
+	st, err = NewLimit(allIt, 10).Stats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, refs.Size{Value: 5, Exact: true}, st.Size)
+
+	// an estimate of the source should not become exact
+	st, err = NewLimit(NewUnique(allIt), 3).Stats(ctx)
+	require.NoError(t, err)
+	require.False(t, st.Size.Exact)
+}
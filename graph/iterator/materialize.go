@@ -33,6 +33,15 @@ type result struct {
 type Materialize struct {
 	sub        Shape
 	expectSize int64
+	cache      *materializeCache // only set for shared iterators
+}
+
+// materializeCache holds results that are shared between all scanners of the same iterator.
+type materializeCache struct {
+	done        bool
+	aborted     bool
+	containsMap map[interface{}]int
+	values      [][]result
 }
 
 func NewMaterialize(sub Shape) *Materialize {
@@ -46,12 +55,25 @@ func NewMaterializeWithSize(sub Shape, size int64) *Materialize {
 	}
 }
 
+// NewMaterializeShared is the same as NewMaterialize, but the subiterator is only executed once
+// and the results are reused by all scanners created from this iterator. It's useful when the same
+// iterator is used in multiple branches of the iterator tree.
+func NewMaterializeShared(sub Shape) *Materialize {
+	it := NewMaterialize(sub)
+	it.cache = new(materializeCache)
+	return it
+}
+
 func (it *Materialize) Iterate() Scanner {
-	return newMaterializeNext(it.sub)
+	n := newMaterializeNext(it.sub)
+	n.cache = it.cache
+	return n
 }
 
 func (it *Materialize) Lookup() Index {
-	return newMaterializeContains(it.sub)
+	c := newMaterializeContains(it.sub)
+	c.next.cache = it.cache
+	return c
 }
 
 func (it *Materialize) String() string {
@@ -92,8 +114,9 @@ func (it *Materialize) Stats(ctx context.Context) (Costs, error) {
 }
 
 type materializeNext struct {
-	sub  Shape
-	next Scanner
+	sub   Shape
+	next  Scanner
+	cache *materializeCache
 
 	containsMap map[interface{}]int
 	values      [][]result
@@ -202,6 +225,13 @@ func (it *materializeNext) NextPath(ctx context.Context) bool {
 }
 
 func (it *materializeNext) materializeSet(ctx context.Context) {
+	if c := it.cache; c != nil && c.done {
+		// results were already loaded by another scanner; they must not be modified
+		it.aborted = c.aborted
+		it.containsMap, it.values = c.containsMap, c.values
+		it.hasRun = true
+		return
+	}
 	i := 0
 	mn := 0
 	for it.next.Next(ctx) {
@@ -248,6 +278,10 @@ func (it *materializeNext) materializeSet(ctx context.Context) {
 		it.next = it.sub.Iterate()
 	}
 	it.hasRun = true
+	if c := it.cache; c != nil && it.err == nil {
+		c.done, c.aborted = true, it.aborted
+		c.containsMap, c.values = it.containsMap, it.values
+	}
 }
 
 type materializeContains struct {
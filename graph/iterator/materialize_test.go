@@ -22,6 +22,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	. "github.com/cayleygraph/cayley/graph/iterator"
+	"github.com/cayleygraph/cayley/graph/refs"
 )
 
 func TestMaterializeIteratorError(t *testing.T) {
@@ -62,3 +63,46 @@ func TestMaterializeIteratorErrorAbort(t *testing.T) {
 	require.False(t, mIt.Next(ctx))
 	require.Equal(t, wantErr, mIt.Err())
 }
+
+type countingIterator struct {
+	Shape
+	next int
+}
+
+func (it *countingIterator) Iterate() Scanner {
+	return &countingScanner{Scanner: it.Shape.Iterate(), it: it}
+}
+
+type countingScanner struct {
+	Scanner
+	it *countingIterator
+}
+
+func (it *countingScanner) Next(ctx context.Context) bool {
+	it.it.next++
+	return it.Scanner.Next(ctx)
+}
+
+func TestMaterializeShared(t *testing.T) {
+	ctx := context.TODO()
+	sub := &countingIterator{Shape: newInt64(1, 3, true)}
+	it := NewMaterializeShared(sub)
+
+	for i := 0; i < 2; i++ {
+		sc := it.Iterate()
+		var got []refs.Ref
+		for sc.Next(ctx) {
+			got = append(got, sc.Result())
+		}
+		require.NoError(t, sc.Err())
+		require.NoError(t, sc.Close())
+		require.Equal(t, []refs.Ref{Int64Node(1), Int64Node(2), Int64Node(3)}, got)
+	}
+	ix := it.Lookup()
+	require.True(t, ix.Contains(ctx, Int64Node(2)))
+	require.False(t, ix.Contains(ctx, Int64Node(4)))
+	require.NoError(t, ix.Close())
+
+	// the subiterator must only be executed once: 3 results and the end of iteration
+	require.Equal(t, 4, sub.next)
+}
@@ -0,0 +1,382 @@
+package iterator
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"io"
+	"math"
+	"reflect"
+
+	"github.com/cayleygraph/cayley/graph/refs"
+	"github.com/cayleygraph/quad"
+)
+
+// DefaultFalsePositiveRate is used by NotApprox if false positive rate is not in (0, 1) range.
+const DefaultFalsePositiveRate = 0.01
+
+// NotApprox is an approximate version of Not iterator. Instead of materializing the primary iterator,
+// it builds a bloom filter from its values and uses it to exclude values of the all iterator.
+//
+// Bloom filter never has false negatives, so the iterator will never return a value from the primary
+// iterator. But it may have false positives, thus it may also exclude values that are not in the primary
+// iterator (over-exclude). The rate of false positives is configured when creating the iterator.
+//
+// This iterator should only be used for very large exclusion sets, where exact membership check is
+// prohibitive in terms of memory.
+type NotApprox struct {
+	primary Shape
+	allIt   Shape
+	rate    float64
+	filter  *bloomFilter
+	err     error
+}
+
+// NewNotApprox creates a new approximate Not iterator with a given false positive rate.
+func NewNotApprox(primaryIt, allIt Shape, rate float64) *NotApprox {
+	if rate <= 0 || rate >= 1 {
+		rate = DefaultFalsePositiveRate
+	}
+	return &NotApprox{
+		primary: primaryIt,
+		allIt:   allIt,
+		rate:    rate,
+	}
+}
+
+// build populates the bloom filter from the primary iterator. It's a no-op if filter was already built.
+func (it *NotApprox) build(ctx context.Context) error {
+	if it.filter != nil || it.err != nil {
+		return it.err
+	}
+	st, err := it.primary.Stats(ctx)
+	if err != nil {
+		it.err = err
+		return err
+	}
+	// the size might be an estimate, thus the filter will grow if there are more values
+	f := newBloomFilter(st.Size.Value, st.Size.Exact, it.rate)
+	pi := it.primary.Iterate()
+	defer pi.Close()
+	for pi.Next(ctx) {
+		f.Add(pi.Result())
+	}
+	if err = pi.Err(); err != nil {
+		it.err = err
+		return err
+	}
+	it.filter = f
+	return nil
+}
+
+func (it *NotApprox) Iterate() Scanner {
+	return newNotApproxNext(it, it.allIt.Iterate())
+}
+
+func (it *NotApprox) Lookup() Index {
+	return newNotApproxContains(it)
+}
+
+// SubIterators returns a slice of the sub iterators.
+// The first iterator is the primary iterator, for which the complement
+// is generated.
+func (it *NotApprox) SubIterators() []Shape {
+	return []Shape{it.primary, it.allIt}
+}
+
+// Optimize optimizes sub-iterators and builds the bloom filter from the primary iterator.
+func (it *NotApprox) Optimize(ctx context.Context) (Shape, bool) {
+	if it.filter == nil {
+		if p, ok := it.primary.Optimize(ctx); ok {
+			it.primary = p
+		}
+		// error will be reported during iteration
+		_ = it.build(ctx)
+	}
+	if a, ok := it.allIt.Optimize(ctx); ok {
+		it.allIt = a
+	}
+	return it, false
+}
+
+func (it *NotApprox) Stats(ctx context.Context) (Costs, error) {
+	primaryStats, err := it.primary.Stats(ctx)
+	allStats, err2 := it.allIt.Stats(ctx)
+	if err == nil {
+		err = err2
+	}
+	return Costs{
+		NextCost:     allStats.NextCost + 1,
+		ContainsCost: allStats.ContainsCost + 1,
+		Size: refs.Size{
+			Value: allStats.Size.Value - primaryStats.Size.Value,
+			Exact: false,
+		},
+	}, err
+}
+
+func (it *NotApprox) String() string {
+	return fmt.Sprintf("NotApprox(%v)", it.rate)
+}
+
+type notApproxNext struct {
+	it     *NotApprox
+	allIt  Scanner
+	result refs.Ref
+	err    error
+}
+
+func newNotApproxNext(it *NotApprox, allIt Scanner) *notApproxNext {
+	return &notApproxNext{
+		it:    it,
+		allIt: allIt,
+	}
+}
+
+func (it *notApproxNext) TagResults(dst map[string]refs.Ref) {
+	it.allIt.TagResults(dst)
+}
+
+// Next advances the iterator. It fetches the next value of the all iterator
+// which is not contained in the bloom filter built from the primary iterator.
+func (it *notApproxNext) Next(ctx context.Context) bool {
+	if it.err = it.it.build(ctx); it.err != nil {
+		return false
+	}
+	for it.allIt.Next(ctx) {
+		if curr := it.allIt.Result(); !it.it.filter.Has(curr) {
+			it.result = curr
+			return true
+		}
+	}
+	return false
+}
+
+func (it *notApproxNext) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.allIt.Err()
+}
+
+func (it *notApproxNext) Result() refs.Ref {
+	return it.result
+}
+
+func (it *notApproxNext) NextPath(ctx context.Context) bool {
+	return it.allIt.NextPath(ctx)
+}
+
+func (it *notApproxNext) Close() error {
+	return it.allIt.Close()
+}
+
+func (it *notApproxNext) String() string {
+	return "NotApproxNext"
+}
+
+type notApproxContains struct {
+	it     *NotApprox
+	allIt  Index
+	result refs.Ref
+	err    error
+}
+
+func newNotApproxContains(it *NotApprox) *notApproxContains {
+	return &notApproxContains{
+		it:    it,
+		allIt: it.allIt.Lookup(),
+	}
+}
+
+func (it *notApproxContains) TagResults(dst map[string]refs.Ref) {
+	it.allIt.TagResults(dst)
+}
+
+func (it *notApproxContains) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.allIt.Err()
+}
+
+func (it *notApproxContains) Result() refs.Ref {
+	return it.result
+}
+
+// Contains checks whether the passed value is a part of the all iterator and is not in
+// the bloom filter built from the primary iterator.
+func (it *notApproxContains) Contains(ctx context.Context, val refs.Ref) bool {
+	if it.err = it.it.build(ctx); it.err != nil {
+		return false
+	}
+	if it.it.filter.Has(val) {
+		return false
+	}
+	if !it.allIt.Contains(ctx, val) {
+		return false
+	}
+	it.result = val
+	return true
+}
+
+func (it *notApproxContains) NextPath(ctx context.Context) bool {
+	return it.allIt.NextPath(ctx)
+}
+
+func (it *notApproxContains) Close() error {
+	return it.allIt.Close()
+}
+
+func (it *notApproxContains) String() string {
+	return "NotApproxContains"
+}
+
+// bloomFilter is a growing bloom filter for refs.
+//
+// It starts with a single layer sized for an expected number of elements. When the layer is full,
+// a new layer with twice the capacity and half the false positive rate is added. This way the overall
+// false positive rate stays within the configured one, even if the number of elements was underestimated.
+type bloomFilter struct {
+	layers []*bloomLayer
+	rate   float64 // false positive rate of the last layer
+}
+
+// newBloomFilter creates a bloom filter for n expected elements with a given false positive rate.
+// If n is only an estimate, the rate of the first layer is halved to allow the filter to grow.
+func newBloomFilter(n int64, exact bool, rate float64) *bloomFilter {
+	if !exact {
+		rate /= 2
+	}
+	return &bloomFilter{
+		layers: []*bloomLayer{newBloomLayer(n, rate)},
+		rate:   rate,
+	}
+}
+
+func (f *bloomFilter) hash(v refs.Ref) (uint64, uint64) {
+	h := fnv.New64a()
+	hashKey(h, refs.ToKey(v))
+	sum := h.Sum64()
+	// use double hashing to simulate k hash functions
+	return sum & 0xffffffff, sum>>32 | 1
+}
+
+// Add adds a value to the filter.
+func (f *bloomFilter) Add(v refs.Ref) {
+	last := f.layers[len(f.layers)-1]
+	if last.count >= last.n {
+		f.rate /= 2
+		last = newBloomLayer(2*last.n, f.rate)
+		f.layers = append(f.layers, last)
+	}
+	last.add(f.hash(v))
+}
+
+// Has checks if the value might be in the filter. It may return true for values that
+// were not added, but never returns false for values that were.
+func (f *bloomFilter) Has(v refs.Ref) bool {
+	h1, h2 := f.hash(v)
+	for _, l := range f.layers {
+		if l.has(h1, h2) {
+			return true
+		}
+	}
+	return false
+}
+
+// bloomLayer is a fixed-size bloom filter.
+type bloomLayer struct {
+	bits  []uint64
+	m     uint64 // number of bits
+	k     uint64 // number of hash functions
+	n     int64  // number of elements the layer was sized for
+	count int64  // number of added elements
+}
+
+// newBloomLayer creates a bloom filter layer for n elements with a given false positive rate.
+func newBloomLayer(n int64, rate float64) *bloomLayer {
+	if n < 1 {
+		n = 1
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(rate) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := uint64(math.Ceil(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &bloomLayer{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+		n:    n,
+	}
+}
+
+func (l *bloomLayer) add(h1, h2 uint64) {
+	for i := uint64(0); i < l.k; i++ {
+		b := (h1 + i*h2) % l.m
+		l.bits[b/64] |= 1 << (b % 64)
+	}
+	l.count++
+}
+
+func (l *bloomLayer) has(h1, h2 uint64) bool {
+	for i := uint64(0); i < l.k; i++ {
+		b := (h1 + i*h2) % l.m
+		if l.bits[b/64]&(1<<(b%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// hashKey writes a binary representation of a ref key to the hash.
+func hashKey(h hash.Hash64, key interface{}) {
+	switch k := key.(type) {
+	case nil:
+		return
+	case refs.ValueHash:
+		h.Write(k[:])
+		return
+	case refs.QuadHash:
+		for _, v := range []refs.ValueHash{k.Subject, k.Predicate, k.Object, k.Label} {
+			h.Write(v[:])
+		}
+		return
+	case quad.Value:
+		io.WriteString(h, k.String())
+		return
+	}
+	hashReflect(h, reflect.ValueOf(key))
+}
+
+// hashReflect hashes keys of backend-specific types, which are usually named integers, strings or arrays of them.
+func hashReflect(h hash.Hash64, rv reflect.Value) {
+	var buf [8]byte
+	switch rv.Kind() {
+	case reflect.String:
+		io.WriteString(h, rv.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		binary.LittleEndian.PutUint64(buf[:], uint64(rv.Int()))
+		h.Write(buf[:])
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		binary.LittleEndian.PutUint64(buf[:], rv.Uint())
+		h.Write(buf[:])
+	case reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			hashReflect(h, rv.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < rv.NumField(); i++ {
+			hashReflect(h, rv.Field(i))
+		}
+	default:
+		// uncommon key types; slow, but still correct
+		fmt.Fprintf(h, "%#v", rv)
+	}
+}
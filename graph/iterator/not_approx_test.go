@@ -0,0 +1,70 @@
+package iterator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	. "github.com/cayleygraph/cayley/graph/iterator"
+	"github.com/cayleygraph/cayley/graph/refs"
+)
+
+func TestNotApproxIterator(t *testing.T) {
+	ctx := context.TODO()
+	const n = 1000
+	all := NewFixed()
+	excl := NewFixed()
+	for i := 0; i < n; i++ {
+		all.Add(Int64Node(i))
+		if i%2 == 0 {
+			excl.Add(Int64Node(i))
+		}
+	}
+	it := NewNotApprox(excl, all, 0.01)
+
+	got := iterated(it)
+	for _, v := range got {
+		// it must never return values from the excluded set
+		require.True(t, v%2 == 1, "excluded value returned: %d", v)
+	}
+	// and should not over-exclude too much
+	require.True(t, len(got) > n/2*9/10, "too many values excluded: %d", n/2-len(got))
+
+	ic := it.Lookup()
+	for i := 0; i < n; i += 2 {
+		require.False(t, ic.Contains(ctx, Int64Node(i)))
+	}
+	require.False(t, ic.Contains(ctx, Int64Node(n+1)))
+}
+
+// underestimated is a shape that reports a small inexact size, regardless of the actual number of values.
+type underestimated struct {
+	*Fixed
+}
+
+func (it underestimated) Stats(ctx context.Context) (Costs, error) {
+	st, err := it.Fixed.Stats(ctx)
+	st.Size = refs.Size{Value: 1, Exact: false}
+	return st, err
+}
+
+func TestNotApproxInexactSize(t *testing.T) {
+	const n = 1000
+	all := NewFixed()
+	excl := NewFixed()
+	for i := 0; i < n; i++ {
+		all.Add(Int64Node(i))
+		if i%2 == 0 {
+			excl.Add(Int64Node(i))
+		}
+	}
+	it := NewNotApprox(underestimated{excl}, all, 0.01)
+
+	got := iterated(it)
+	for _, v := range got {
+		require.True(t, v%2 == 1, "excluded value returned: %d", v)
+	}
+	// the filter must grow instead of saturating
+	require.True(t, len(got) > n/2*9/10, "too many values excluded: %d", n/2-len(got))
+}
@@ -0,0 +1,190 @@
+package iterator
+
+import (
+	"context"
+
+	"github.com/cayleygraph/cayley/graph/refs"
+	"github.com/cayleygraph/quad"
+)
+
+// QuadGetter resolves quad references to quads.
+type QuadGetter interface {
+	Quad(refs.Ref) (quad.Quad, error)
+}
+
+type QuadFilterFunc func(quad.Quad) (bool, error)
+
+// QuadFilter iterator is similar to ValueFilter, but works on quads from the subiterator.
+type QuadFilter struct {
+	sub    Shape
+	filter QuadFilterFunc
+	qs     QuadGetter
+}
+
+func NewQuadFilter(qs QuadGetter, sub Shape, filter QuadFilterFunc) *QuadFilter {
+	return &QuadFilter{
+		sub:    sub,
+		qs:     qs,
+		filter: filter,
+	}
+}
+
+// NewQuadComparison creates a filter that compares values of two directions of each quad.
+func NewQuadComparison(qs QuadGetter, sub Shape, a quad.Direction, op Operator, b quad.Direction) *QuadFilter {
+	return NewQuadFilter(qs, sub, func(q quad.Quad) (bool, error) {
+		return CompareValues(q.Get(a), op, q.Get(b)), nil
+	})
+}
+
+func (it *QuadFilter) Iterate() Scanner {
+	return newQuadFilterNext(it.qs, it.sub.Iterate(), it.filter)
+}
+
+func (it *QuadFilter) Lookup() Index {
+	return newQuadFilterContains(it.qs, it.sub.Lookup(), it.filter)
+}
+
+func (it *QuadFilter) SubIterators() []Shape {
+	return []Shape{it.sub}
+}
+
+func (it *QuadFilter) String() string {
+	return "QuadFilter"
+}
+
+func (it *QuadFilter) Optimize(ctx context.Context) (Shape, bool) {
+	newSub, changed := it.sub.Optimize(ctx)
+	if changed {
+		it.sub = newSub
+	}
+	return it, true
+}
+
+func (it *QuadFilter) Stats(ctx context.Context) (Costs, error) {
+	st, err := it.sub.Stats(ctx)
+	st.Size.Value = st.Size.Value/2 + 1
+	st.Size.Exact = false
+	return st, err
+}
+
+// doQuadFilter loads a quad and checks it against the filter.
+func doQuadFilter(qs QuadGetter, filter QuadFilterFunc, ref refs.Ref) (bool, error) {
+	q, err := qs.Quad(ref)
+	if err != nil {
+		return false, err
+	}
+	return filter(q)
+}
+
+type quadFilterNext struct {
+	sub    Scanner
+	filter QuadFilterFunc
+	qs     QuadGetter
+	result refs.Ref
+	err    error
+}
+
+func newQuadFilterNext(qs QuadGetter, sub Scanner, filter QuadFilterFunc) *quadFilterNext {
+	return &quadFilterNext{
+		sub:    sub,
+		qs:     qs,
+		filter: filter,
+	}
+}
+
+func (it *quadFilterNext) Close() error {
+	return it.sub.Close()
+}
+
+func (it *quadFilterNext) Next(ctx context.Context) bool {
+	for it.sub.Next(ctx) {
+		val := it.sub.Result()
+		ok, err := doQuadFilter(it.qs, it.filter, val)
+		if err != nil {
+			it.err = err
+			return false
+		} else if ok {
+			it.result = val
+			return true
+		}
+	}
+	it.err = it.sub.Err()
+	return false
+}
+
+func (it *quadFilterNext) Err() error {
+	return it.err
+}
+
+func (it *quadFilterNext) Result() refs.Ref {
+	return it.result
+}
+
+func (it *quadFilterNext) NextPath(ctx context.Context) bool {
+	return it.sub.NextPath(ctx)
+}
+
+func (it *quadFilterNext) TagResults(dst map[string]refs.Ref) {
+	it.sub.TagResults(dst)
+}
+
+func (it *quadFilterNext) String() string {
+	return "QuadFilterNext"
+}
+
+type quadFilterContains struct {
+	sub    Index
+	filter QuadFilterFunc
+	qs     QuadGetter
+	result refs.Ref
+	err    error
+}
+
+func newQuadFilterContains(qs QuadGetter, sub Index, filter QuadFilterFunc) *quadFilterContains {
+	return &quadFilterContains{
+		sub:    sub,
+		qs:     qs,
+		filter: filter,
+	}
+}
+
+func (it *quadFilterContains) Close() error {
+	return it.sub.Close()
+}
+
+func (it *quadFilterContains) Err() error {
+	return it.err
+}
+
+func (it *quadFilterContains) Result() refs.Ref {
+	return it.result
+}
+
+func (it *quadFilterContains) NextPath(ctx context.Context) bool {
+	return it.sub.NextPath(ctx)
+}
+
+func (it *quadFilterContains) Contains(ctx context.Context, val refs.Ref) bool {
+	it.result = nil
+	ok, err := doQuadFilter(it.qs, it.filter, val)
+	if err != nil {
+		it.err = err
+		return false
+	} else if !ok {
+		return false
+	}
+	if !it.sub.Contains(ctx, val) {
+		it.err = it.sub.Err()
+		return false
+	}
+	it.result = val
+	return true
+}
+
+func (it *quadFilterContains) TagResults(dst map[string]refs.Ref) {
+	it.sub.TagResults(dst)
+}
+
+func (it *quadFilterContains) String() string {
+	return "QuadFilterContains"
+}
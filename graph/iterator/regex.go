@@ -15,12 +15,50 @@
 package iterator
 
 import (
+	"errors"
+	"fmt"
 	"regexp"
+	"regexp/syntax"
 
 	"github.com/cayleygraph/cayley/graph/refs"
 	"github.com/cayleygraph/quad"
 )
 
+// Limits for user-supplied regexp patterns, checked by CompileRegexp.
+//
+// Go regexp engine guarantees a linear matching time, but the time is also proportional
+// to the size of compiled program, thus large patterns can still be expensive to run
+// against every candidate value.
+var (
+	MaxRegexpLength = 1024  // maximal length of the pattern in bytes
+	MaxRegexpInsts  = 10000 // maximal number of instructions in the compiled pattern
+)
+
+// ErrRegexpTooComplex is returned by CompileRegexp for patterns that exceed configured limits.
+var ErrRegexpTooComplex = errors.New("regexp pattern is too complex")
+
+// CompileRegexp compiles a user-supplied regexp pattern, rejecting patterns that are
+// too large or compile to a program that is too expensive to run.
+//
+// Total matching time should still be bounded by the query context.
+func CompileRegexp(pattern string) (*regexp.Regexp, error) {
+	if MaxRegexpLength > 0 && len(pattern) > MaxRegexpLength {
+		return nil, fmt.Errorf("%w: pattern length %d exceeds %d", ErrRegexpTooComplex, len(pattern), MaxRegexpLength)
+	}
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		return nil, err
+	}
+	if MaxRegexpInsts > 0 && len(prog.Inst) > MaxRegexpInsts {
+		return nil, fmt.Errorf("%w: %d instructions exceed %d", ErrRegexpTooComplex, len(prog.Inst), MaxRegexpInsts)
+	}
+	return regexp.Compile(pattern)
+}
+
 func newRegex(qs refs.Namer, sub Shape, re *regexp.Regexp, refs bool) Shape {
 	return NewValueFilter(qs, sub, func(v quad.Value) (bool, error) {
 		switch v := v.(type) {
@@ -0,0 +1,52 @@
+package iterator_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cayleygraph/cayley/graph/graphmock"
+	. "github.com/cayleygraph/cayley/graph/iterator"
+	"github.com/cayleygraph/cayley/graph/refs"
+	"github.com/cayleygraph/quad"
+)
+
+func TestCompileRegexp(t *testing.T) {
+	_, err := CompileRegexp(`ar?li.*e`)
+	require.NoError(t, err)
+
+	_, err = CompileRegexp(strings.Repeat("a", MaxRegexpLength+1))
+	require.True(t, errors.Is(err, ErrRegexpTooComplex), "%v", err)
+
+	_, err = CompileRegexp(strings.Repeat(`[a-z]{1000}`, 11))
+	require.True(t, errors.Is(err, ErrRegexpTooComplex), "%v", err)
+}
+
+func TestRegexBoundedRuntime(t *testing.T) {
+	re, err := CompileRegexp(`^(a+)+$`)
+	require.NoError(t, err)
+
+	// a classic catastrophic backtracking case, but it runs in linear time in Go
+	large := quad.String(strings.Repeat("a", 1<<20) + "!")
+	start := time.Now()
+	it := NewRegex(NewFixed(refs.PreFetched(large)), re, &graphmock.Store{})
+	sc := it.Iterate()
+	require.False(t, sc.Next(context.Background()))
+	require.NoError(t, sc.Err())
+	require.True(t, time.Since(start) < 5*time.Second, "matching took %v", time.Since(start))
+
+	// total matching time is bounded by the context
+	fixed := NewFixed()
+	for i := 0; i < 1000; i++ {
+		fixed.Add(refs.PreFetched(quad.String("b")))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sc = NewRegex(fixed, re, &graphmock.Store{}).Iterate()
+	require.False(t, sc.Next(ctx))
+	require.Equal(t, context.Canceled, sc.Err())
+}
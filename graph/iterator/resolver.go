@@ -114,9 +114,12 @@ func (it *resolverNext) resolve(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	it.values = make([]refs.Ref, len(it.order))
-	for i, value := range values {
-		it.values[i] = value
+	it.values = make([]refs.Ref, 0, len(it.order))
+	for _, value := range values {
+		// batch lookups return nil for values that are not found
+		if value != nil {
+			it.values = append(it.values, value)
+		}
 	}
 	it.order = nil
 	it.cached = true
@@ -193,6 +196,9 @@ func (it *resolverContains) resolve(ctx context.Context) error {
 	// so allocate maps large enough to accommodate all
 	it.nodes = make(map[interface{}]quad.Value, len(it.order))
 	for index, value := range values {
+		if value == nil {
+			continue
+		}
 		node := it.order[index]
 		it.nodes[value.Key()] = node
 	}
@@ -3,25 +3,54 @@ package iterator
 import (
 	"context"
 	"sort"
+	"time"
 
 	"github.com/cayleygraph/cayley/graph/refs"
+	"github.com/cayleygraph/quad"
 )
 
 // Sort iterator orders values from it's subiterator.
 type Sort struct {
-	namer refs.Namer
-	subIt Shape
+	namer   refs.Namer
+	subIt   Shape
+	rowTags []string
+	desc    bool
+	opts    SortOptions
+}
+
+// SortOptions controls how values are compared by the Sort iterator.
+type SortOptions struct {
+	// Numeric enables ordering by a numeric or time value if all values share a comparable type
+	// (integers and floats, or times). Otherwise, values are ordered by their string representation.
+	Numeric bool
+	// Stable keeps the original order of equal values to make results reproducible.
+	Stable bool
 }
 
 // NewSort creates a new Sort iterator.
-// TODO(dennwc): This iterator must not be used inside And: it may be moved to a Contains branch and won't do anything.
-//               We should make And/Intersect account for this.
+// This iterator must not be used inside And: it may be moved to a Contains branch and won't do anything.
+// shape.Intersect accounts for this by moving Sort outside of the intersection.
 func NewSort(namer refs.Namer, subIt Shape) *Sort {
-	return &Sort{namer, subIt}
+	return &Sort{namer: namer, subIt: subIt}
+}
+
+// NewSortBy creates a new Sort iterator with given options.
+func NewSortBy(namer refs.Namer, subIt Shape, opts SortOptions) *Sort {
+	return &Sort{namer: namer, subIt: subIt, opts: opts}
+}
+
+// AddRowNumberTags adds tags that will store a 1-based position of each result in the ordered output.
+func (it *Sort) AddRowNumberTags(tags ...string) {
+	it.rowTags = append(it.rowTags, tags...)
+}
+
+// SetDescending changes the sort order to descending.
+func (it *Sort) SetDescending(desc bool) {
+	it.desc = desc
 }
 
 func (it *Sort) Iterate() Scanner {
-	return newSortNext(it.namer, it.subIt.Iterate())
+	return newSortNext(it.namer, it.subIt.Iterate(), it.rowTags, it.desc, it.opts)
 }
 
 func (it *Sort) Lookup() Index {
@@ -45,14 +74,15 @@ func (it *Sort) Stats(ctx context.Context) (Costs, error) {
 		// TODO(dennwc): better cost calculation; we probably need an InitCost defined in Costs
 		NextCost:     subStats.NextCost * 2,
 		ContainsCost: subStats.ContainsCost,
-		Size: refs.Size{
-			Value: subStats.Size.Value,
-			Exact: true,
-		},
+		// results are not materialized yet, thus the size is only as exact as the subiterator's one
+		Size: subStats.Size,
 	}, err
 }
 
 func (it *Sort) String() string {
+	if it.desc {
+		return "Sort(desc)"
+	}
 	return "Sort"
 }
 
@@ -63,6 +93,7 @@ func (it *Sort) SubIterators() []Shape {
 
 type sortValue struct {
 	result
+	val   quad.Value
 	str   string
 	paths []result
 }
@@ -74,9 +105,99 @@ func (v sortByString) Less(i, j int) bool {
 }
 func (v sortByString) Swap(i, j int) { v[i], v[j] = v[j], v[i] }
 
+// sortByValue orders values by their numeric or time value, using the string representation as a secondary key.
+// All values must have the same sortKind.
+type sortByValue struct {
+	sortByString
+}
+
+func (v sortByValue) Less(i, j int) bool {
+	if c := compareTyped(v.sortByString[i].val, v.sortByString[j].val); c != 0 {
+		return c < 0
+	}
+	return v.sortByString[i].str < v.sortByString[j].str
+}
+
+type sortKind int
+
+const (
+	sortNone = sortKind(iota)
+	sortNumber
+	sortTime
+)
+
+func sortKindOf(v quad.Value) sortKind {
+	switch v.(type) {
+	case quad.Int, quad.Float:
+		return sortNumber
+	case quad.Time:
+		return sortTime
+	}
+	return sortNone
+}
+
+// typedKind returns a comparable kind shared by all values, or sortNone if there is no such kind.
+func (v sortByString) typedKind() sortKind {
+	kind := sortNone
+	for i, sv := range v {
+		k := sortKindOf(sv.val)
+		if k == sortNone || (i != 0 && k != kind) {
+			return sortNone
+		}
+		kind = k
+	}
+	return kind
+}
+
+func toFloat(v quad.Value) float64 {
+	switch v := v.(type) {
+	case quad.Int:
+		return float64(v)
+	case quad.Float:
+		return float64(v)
+	}
+	return 0
+}
+
+// compareTyped compares two values of the same sortKind.
+func compareTyped(a, b quad.Value) int {
+	switch a := a.(type) {
+	case quad.Int:
+		if b, ok := b.(quad.Int); ok {
+			switch {
+			case a < b:
+				return -1
+			case a > b:
+				return +1
+			}
+			return 0
+		}
+	case quad.Time:
+		ta, tb := time.Time(a), time.Time(b.(quad.Time))
+		switch {
+		case ta.Before(tb):
+			return -1
+		case ta.After(tb):
+			return +1
+		}
+		return 0
+	}
+	fa, fb := toFloat(a), toFloat(b)
+	switch {
+	case fa < fb:
+		return -1
+	case fa > fb:
+		return +1
+	}
+	return 0
+}
+
 type sortNext struct {
 	namer     refs.Namer
 	subIt     Scanner
+	rowTags   []string
+	desc      bool
+	opts      SortOptions
 	ordered   sortByString
 	result    result
 	err       error
@@ -84,10 +205,13 @@ type sortNext struct {
 	pathIndex int
 }
 
-func newSortNext(namer refs.Namer, subIt Scanner) *sortNext {
+func newSortNext(namer refs.Namer, subIt Scanner, rowTags []string, desc bool, opts SortOptions) *sortNext {
 	return &sortNext{
 		namer:     namer,
 		subIt:     subIt,
+		rowTags:   rowTags,
+		desc:      desc,
+		opts:      opts,
 		pathIndex: -1,
 	}
 }
@@ -96,6 +220,13 @@ func (it *sortNext) TagResults(dst map[string]refs.Ref) {
 	for tag, value := range it.result.tags {
 		dst[tag] = value
 	}
+	if len(it.rowTags) != 0 {
+		// index is already advanced by Next, thus it's 1-based
+		row := refs.PreFetched(quad.Int(it.index))
+		for _, tag := range it.rowTags {
+			dst[tag] = row
+		}
+	}
 }
 
 func (it *sortNext) Err() error {
@@ -111,7 +242,7 @@ func (it *sortNext) Next(ctx context.Context) bool {
 		return false
 	}
 	if it.ordered == nil {
-		v, err := getSortedValues(ctx, it.namer, it.subIt)
+		v, err := getSortedValues(ctx, it.namer, it.subIt, it.desc, it.opts)
 		it.ordered = v
 		it.err = err
 		if it.err != nil {
@@ -128,10 +259,11 @@ func (it *sortNext) Next(ctx context.Context) bool {
 }
 
 func (it *sortNext) NextPath(ctx context.Context) bool {
-	if it.index >= len(it.ordered) {
+	if it.index == 0 || it.index > len(it.ordered) {
 		return false
 	}
-	r := it.ordered[it.index]
+	// index is already advanced by Next
+	r := it.ordered[it.index-1]
 	if it.pathIndex+1 >= len(r.paths) {
 		return false
 	}
@@ -149,9 +281,12 @@ func (it *sortNext) String() string {
 	return "SortNext"
 }
 
-func getSortedValues(ctx context.Context, namer refs.Namer, it Scanner) (sortByString, error) {
+func getSortedValues(ctx context.Context, namer refs.Namer, it Scanner, desc bool, opts SortOptions) (sortByString, error) {
 	var v sortByString
 	for it.Next(ctx) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		id := it.Result()
 		// TODO(dennwc): batch and use refs.ValuesOf
 		name, err := namer.NameOf(id)
@@ -163,6 +298,7 @@ func getSortedValues(ctx context.Context, namer refs.Namer, it Scanner) (sortByS
 		it.TagResults(tags)
 		val := sortValue{
 			result: result{id, tags},
+			val:    name,
 			str:    str,
 		}
 		for it.NextPath(ctx) {
@@ -175,6 +311,17 @@ func getSortedValues(ctx context.Context, namer refs.Namer, it Scanner) (sortByS
 	if err := it.Err(); err != nil {
 		return v, err
 	}
-	sort.Sort(v)
+	var s sort.Interface = v
+	if opts.Numeric && v.typedKind() != sortNone {
+		s = sortByValue{v}
+	}
+	if desc {
+		s = sort.Reverse(s)
+	}
+	if opts.Stable {
+		sort.Stable(s)
+	} else {
+		sort.Sort(s)
+	}
 	return v, nil
 }
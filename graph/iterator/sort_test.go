@@ -0,0 +1,155 @@
+package iterator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cayleygraph/cayley/graph/graphmock"
+	. "github.com/cayleygraph/cayley/graph/iterator"
+	"github.com/cayleygraph/cayley/graph/refs"
+	"github.com/cayleygraph/quad"
+)
+
+func TestSortRowNumber(t *testing.T) {
+	ctx := context.TODO()
+	it := NewSort(stringStore, stringFixedIterator())
+	it.AddRowNumberTags("row")
+
+	sc := it.Iterate()
+	defer sc.Close()
+
+	var (
+		names []string
+		rows  []quad.Value
+	)
+	for sc.Next(ctx) {
+		names = append(names, string(sc.Result().(graphmock.StringNode)))
+		tags := make(map[string]refs.Ref)
+		sc.TagResults(tags)
+		rows = append(rows, tags["row"].(refs.PreFetchedValue).NameOf())
+	}
+	require.NoError(t, sc.Err())
+	require.Equal(t, []string{"bar", "baz", "echo", "foo"}, names)
+	require.Equal(t, []quad.Value{quad.Int(1), quad.Int(2), quad.Int(3), quad.Int(4)}, rows)
+}
+
+func TestSortDescending(t *testing.T) {
+	ctx := context.TODO()
+	it := NewSort(stringStore, stringFixedIterator())
+	it.SetDescending(true)
+
+	sc := it.Iterate()
+	defer sc.Close()
+
+	var names []string
+	for sc.Next(ctx) {
+		names = append(names, string(sc.Result().(graphmock.StringNode)))
+	}
+	require.NoError(t, sc.Err())
+	require.Equal(t, []string{"foo", "echo", "baz", "bar"}, names)
+}
+
+func TestSortAllResults(t *testing.T) {
+	ctx := context.TODO()
+	for _, c := range []struct {
+		name   string
+		values []string
+		expect []string
+	}{
+		{name: "single", values: []string{"foo"}, expect: []string{"foo"}},
+		{name: "three", values: []string{"foo", "bar", "echo"}, expect: []string{"bar", "echo", "foo"}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			fixed := NewFixed()
+			for _, v := range c.values {
+				fixed.Add(graphmock.StringNode(v))
+			}
+			sc := NewSort(stringStore, fixed).Iterate()
+			defer sc.Close()
+
+			var names []string
+			for sc.Next(ctx) {
+				names = append(names, string(sc.Result().(graphmock.StringNode)))
+			}
+			require.NoError(t, sc.Err())
+			require.Equal(t, c.expect, names)
+		})
+	}
+}
+
+func TestSortNumeric(t *testing.T) {
+	ctx := context.TODO()
+	for _, c := range []struct {
+		name   string
+		values []quad.Value
+		expect []quad.Value
+	}{
+		{
+			name:   "integers",
+			values: []quad.Value{quad.Int(10), quad.Int(9), quad.Int(-1), quad.Int(100)},
+			expect: []quad.Value{quad.Int(-1), quad.Int(9), quad.Int(10), quad.Int(100)},
+		},
+		{
+			name:   "numbers",
+			values: []quad.Value{quad.Float(2.5), quad.Int(10), quad.Int(2)},
+			expect: []quad.Value{quad.Int(2), quad.Float(2.5), quad.Int(10)},
+		},
+		{
+			name:   "mixed",
+			values: []quad.Value{quad.Int(10), quad.String("b"), quad.Int(9), quad.String("a")},
+			// falls back to string order: "10" is before "9"
+			expect: []quad.Value{quad.Int(10), quad.Int(9), quad.String("a"), quad.String("b")},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			fixed := NewFixed()
+			for _, v := range c.values {
+				fixed.Add(refs.PreFetched(v))
+			}
+			sc := NewSortBy(&graphmock.Store{}, fixed, SortOptions{Numeric: true}).Iterate()
+			defer sc.Close()
+
+			var got []quad.Value
+			for sc.Next(ctx) {
+				got = append(got, sc.Result().(refs.PreFetchedValue).NameOf())
+			}
+			require.NoError(t, sc.Err())
+			require.Equal(t, c.expect, got)
+		})
+	}
+}
+
+// cancelNamer cancels the context after a given number of names were resolved.
+type cancelNamer struct {
+	refs.Namer
+	n      int
+	cancel func()
+}
+
+func (qs *cancelNamer) NameOf(v refs.Ref) (quad.Value, error) {
+	qs.n--
+	if qs.n == 0 {
+		qs.cancel()
+	}
+	return qs.Namer.NameOf(v)
+}
+
+func TestSortCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	f := NewFixed()
+	for i := 0; i < 100; i++ {
+		f.Add(refs.PreFetched(quad.Int(i)))
+	}
+	namer := &cancelNamer{Namer: &graphmock.Store{}, n: 2, cancel: cancel}
+	it := NewSort(namer, f)
+
+	sc := it.Iterate()
+	defer sc.Close()
+	require.False(t, sc.Next(ctx))
+	require.Equal(t, context.Canceled, sc.Err())
+	// materialization stopped right after the cancellation
+	require.Equal(t, 0, namer.n)
+}
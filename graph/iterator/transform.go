@@ -0,0 +1,212 @@
+package iterator
+
+import (
+	"context"
+
+	"github.com/cayleygraph/cayley/graph/refs"
+	"github.com/cayleygraph/quad"
+)
+
+// TransformFunc computes a new value from values of tags. Missing tags are passed as nil.
+type TransformFunc func(args []quad.Value) (quad.Value, error)
+
+// Transform iterator saves a value computed from other tags of each result into a new tag.
+//
+// Results of the subiterator are not changed. If the function returns nil, the tag is not set.
+type Transform struct {
+	namer refs.Namer
+	sub   Shape
+	fnc   TransformFunc
+	tag   string
+	args  []string
+}
+
+// NewTransform creates a new iterator that applies a function to values of tags from args,
+// and saves the result into a given tag.
+func NewTransform(namer refs.Namer, sub Shape, fnc TransformFunc, tag string, args ...string) *Transform {
+	return &Transform{
+		namer: namer,
+		sub:   sub,
+		fnc:   fnc,
+		tag:   tag,
+		args:  args,
+	}
+}
+
+func (it *Transform) newTransformer() *transformer {
+	return &transformer{namer: it.namer, fnc: it.fnc, tag: it.tag, args: it.args}
+}
+
+func (it *Transform) Iterate() Scanner {
+	return newTransformNext(it.sub.Iterate(), it.newTransformer())
+}
+
+func (it *Transform) Lookup() Index {
+	return newTransformContains(it.sub.Lookup(), it.newTransformer())
+}
+
+// SubIterators returns a slice of the sub iterators.
+func (it *Transform) SubIterators() []Shape {
+	return []Shape{it.sub}
+}
+
+func (it *Transform) Optimize(ctx context.Context) (Shape, bool) {
+	newIt, optimized := it.sub.Optimize(ctx)
+	if optimized {
+		it.sub = newIt
+	}
+	return it, false
+}
+
+func (it *Transform) Stats(ctx context.Context) (Costs, error) {
+	st, err := it.sub.Stats(ctx)
+	st.NextCost++
+	st.ContainsCost++
+	return st, err
+}
+
+func (it *Transform) String() string {
+	return "Transform(" + it.tag + ")"
+}
+
+// transformer computes a value for the current path of the subiterator.
+type transformer struct {
+	namer refs.Namer
+	fnc   TransformFunc
+	args  []string
+	tag   string
+	val   refs.Ref
+}
+
+func (t *transformer) apply(sub Base) error {
+	t.val = nil
+	tags := make(map[string]refs.Ref)
+	sub.TagResults(tags)
+	args := make([]quad.Value, len(t.args))
+	for i, name := range t.args {
+		r, ok := tags[name]
+		if !ok || r == nil {
+			continue
+		}
+		v, err := t.namer.NameOf(r)
+		if err != nil {
+			return err
+		}
+		args[i] = v
+	}
+	v, err := t.fnc(args)
+	if err != nil {
+		return err
+	} else if v != nil {
+		t.val = refs.PreFetched(v)
+	}
+	return nil
+}
+
+func (t *transformer) tagResults(dst map[string]refs.Ref) {
+	if t.val != nil {
+		dst[t.tag] = t.val
+	}
+}
+
+type transformNext struct {
+	sub Scanner
+	tr  *transformer
+	err error
+}
+
+func newTransformNext(sub Scanner, tr *transformer) *transformNext {
+	return &transformNext{sub: sub, tr: tr}
+}
+
+func (it *transformNext) TagResults(dst map[string]refs.Ref) {
+	it.sub.TagResults(dst)
+	it.tr.tagResults(dst)
+}
+
+func (it *transformNext) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	if !it.sub.Next(ctx) {
+		it.err = it.sub.Err()
+		return false
+	}
+	it.err = it.tr.apply(it.sub)
+	return it.err == nil
+}
+
+func (it *transformNext) NextPath(ctx context.Context) bool {
+	if it.err != nil || !it.sub.NextPath(ctx) {
+		return false
+	}
+	it.err = it.tr.apply(it.sub)
+	return it.err == nil
+}
+
+func (it *transformNext) Err() error {
+	return it.err
+}
+
+func (it *transformNext) Result() refs.Ref {
+	return it.sub.Result()
+}
+
+func (it *transformNext) Close() error {
+	return it.sub.Close()
+}
+
+func (it *transformNext) String() string {
+	return "TransformNext"
+}
+
+type transformContains struct {
+	sub Index
+	tr  *transformer
+	err error
+}
+
+func newTransformContains(sub Index, tr *transformer) *transformContains {
+	return &transformContains{sub: sub, tr: tr}
+}
+
+func (it *transformContains) TagResults(dst map[string]refs.Ref) {
+	it.sub.TagResults(dst)
+	it.tr.tagResults(dst)
+}
+
+func (it *transformContains) Contains(ctx context.Context, val refs.Ref) bool {
+	if it.err != nil {
+		return false
+	}
+	if !it.sub.Contains(ctx, val) {
+		it.err = it.sub.Err()
+		return false
+	}
+	it.err = it.tr.apply(it.sub)
+	return it.err == nil
+}
+
+func (it *transformContains) NextPath(ctx context.Context) bool {
+	if it.err != nil || !it.sub.NextPath(ctx) {
+		return false
+	}
+	it.err = it.tr.apply(it.sub)
+	return it.err == nil
+}
+
+func (it *transformContains) Err() error {
+	return it.err
+}
+
+func (it *transformContains) Result() refs.Ref {
+	return it.sub.Result()
+}
+
+func (it *transformContains) Close() error {
+	return it.sub.Close()
+}
+
+func (it *transformContains) String() string {
+	return "TransformContains"
+}
@@ -0,0 +1,167 @@
+package iterator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cayleygraph/cayley/graph/refs"
+)
+
+// UniqueBy iterator removes results from it's subiterator that have the same combination of tag values.
+//
+// Unlike Unique, the value of the result itself is not considered - only the values of the specified tags.
+type UniqueBy struct {
+	subIt Shape
+	tags  []string
+}
+
+// NewUniqueBy creates a new iterator that removes results with duplicate values of given tags.
+func NewUniqueBy(subIt Shape, tags ...string) *UniqueBy {
+	return &UniqueBy{
+		subIt: subIt,
+		tags:  tags,
+	}
+}
+
+func (it *UniqueBy) Iterate() Scanner {
+	return newUniqueByNext(it.subIt.Iterate(), it.tags)
+}
+
+func (it *UniqueBy) Lookup() Index {
+	// uniqueness of tags doesn't affect the set of values, so there is nothing to do in lookup mode
+	return newUniqueContains(it.subIt.Lookup())
+}
+
+// SubIterators returns a slice of the sub iterators.
+func (it *UniqueBy) SubIterators() []Shape {
+	return []Shape{it.subIt}
+}
+
+func (it *UniqueBy) Optimize(ctx context.Context) (Shape, bool) {
+	newIt, optimized := it.subIt.Optimize(ctx)
+	if optimized {
+		it.subIt = newIt
+	}
+	return it, false
+}
+
+func (it *UniqueBy) Stats(ctx context.Context) (Costs, error) {
+	subStats, err := it.subIt.Stats(ctx)
+	return Costs{
+		NextCost:     subStats.NextCost * uniquenessFactor,
+		ContainsCost: subStats.ContainsCost,
+		Size: refs.Size{
+			Value: subStats.Size.Value / uniquenessFactor,
+			Exact: false,
+		},
+	}, err
+}
+
+func (it *UniqueBy) String() string {
+	return fmt.Sprintf("UniqueBy(%v)", it.tags)
+}
+
+// UniqueBy iterator removes results with the same combination of tag values.
+type uniqueByNext struct {
+	subIt Scanner
+	tags  []string
+	err   error
+	seen  seenTags
+	buf   map[string]refs.Ref
+}
+
+// seenTags is a tree of tag value keys with one level per tag.
+// Values on the last level are always nil.
+type seenTags map[interface{}]seenTags
+
+func newUniqueByNext(subIt Scanner, tags []string) *uniqueByNext {
+	return &uniqueByNext{
+		subIt: subIt,
+		tags:  tags,
+		seen:  make(seenTags),
+		buf:   make(map[string]refs.Ref),
+	}
+}
+
+func (it *uniqueByNext) TagResults(dst map[string]refs.Ref) {
+	it.subIt.TagResults(dst)
+}
+
+// firstSeen checks if current combination of tag values was not seen before and marks it as seen.
+func (it *uniqueByNext) firstSeen() bool {
+	for k := range it.buf {
+		delete(it.buf, k)
+	}
+	it.subIt.TagResults(it.buf)
+	if len(it.tags) == 0 {
+		// all results have the same (empty) combination of tags
+		_, ok := it.seen[nil]
+		it.seen[nil] = nil
+		return !ok
+	}
+	node := it.seen
+	for i, t := range it.tags {
+		key := refs.ToKey(it.buf[t])
+		next, ok := node[key]
+		if i == len(it.tags)-1 {
+			if ok {
+				return false
+			}
+			node[key] = nil
+			return true
+		}
+		if !ok {
+			next = make(seenTags)
+			node[key] = next
+		}
+		node = next
+	}
+	return false
+}
+
+// Next advances the subiterator, continuing until it returns a path with a combination
+// of tag values which it has not previously seen.
+func (it *uniqueByNext) Next(ctx context.Context) bool {
+	for it.subIt.Next(ctx) {
+		if it.firstSeen() {
+			return true
+		}
+		// other paths for the same value might still have unique tags
+		for it.subIt.NextPath(ctx) {
+			if it.firstSeen() {
+				return true
+			}
+		}
+	}
+	it.err = it.subIt.Err()
+	return false
+}
+
+func (it *uniqueByNext) Err() error {
+	return it.err
+}
+
+func (it *uniqueByNext) Result() refs.Ref {
+	return it.subIt.Result()
+}
+
+// NextPath advances to the next path of the current result with a combination of tag values
+// which was not seen before.
+func (it *uniqueByNext) NextPath(ctx context.Context) bool {
+	for it.subIt.NextPath(ctx) {
+		if it.firstSeen() {
+			return true
+		}
+	}
+	return false
+}
+
+// Close closes the primary iterators.
+func (it *uniqueByNext) Close() error {
+	it.seen = nil
+	return it.subIt.Close()
+}
+
+func (it *uniqueByNext) String() string {
+	return fmt.Sprintf("UniqueByNext(%v)", it.tags)
+}
@@ -0,0 +1,57 @@
+package iterator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	. "github.com/cayleygraph/cayley/graph/iterator"
+)
+
+func TestUniqueByIteratorBasics(t *testing.T) {
+	ctx := context.TODO()
+	// all values have the same tag value, except the last one
+	same := NewSave(NewFixed(
+		Int64Node(1),
+		Int64Node(2),
+		Int64Node(3),
+	))
+	same.AddFixedTag("status", Int64Node(10))
+	other := NewSave(NewFixed(Int64Node(4)))
+	other.AddFixedTag("status", Int64Node(20))
+
+	u := NewUniqueBy(NewOr(same, other), "status")
+
+	expect := []int{1, 4}
+	for i := 0; i < 2; i++ {
+		require.Equal(t, expect, iterated(u))
+	}
+
+	// uniqueness by tags doesn't affect lookups
+	uc := u.Lookup()
+	for _, v := range []int{1, 2, 3, 4} {
+		require.True(t, uc.Contains(ctx, Int64Node(v)))
+	}
+
+	// results without tags are considered to have the same value
+	u = NewUniqueBy(NewFixed(Int64Node(1), Int64Node(2)), "status")
+	require.Equal(t, []int{1}, iterated(u))
+}
+
+func TestUniqueByMultipleTags(t *testing.T) {
+	tagged := func(a, b int64, v Int64Node) Shape {
+		it := NewSave(NewFixed(v))
+		it.AddFixedTag("a", Int64Node(a))
+		it.AddFixedTag("b", Int64Node(b))
+		return it
+	}
+	u := NewUniqueBy(NewOr(
+		tagged(1, 1, 1),
+		tagged(1, 2, 2),
+		tagged(1, 1, 3),
+		tagged(2, 1, 4),
+		tagged(1, 2, 5),
+	), "a", "b")
+	require.Equal(t, []int{1, 2, 4}, iterated(u))
+}
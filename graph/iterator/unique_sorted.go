@@ -0,0 +1,113 @@
+package iterator
+
+import (
+	"context"
+
+	"github.com/cayleygraph/cayley/graph/refs"
+)
+
+// UniqueSorted iterator removes duplicate values from a sorted subiterator.
+//
+// Unlike Unique, it doesn't remember all values it has seen, and only compares each value with
+// the previous one. Thus, it expects equal values to be adjacent, e.g. in the output of Sort.
+type UniqueSorted struct {
+	subIt Shape
+}
+
+// NewUniqueSorted creates a new iterator that removes duplicates from a sorted subiterator.
+func NewUniqueSorted(subIt Shape) *UniqueSorted {
+	return &UniqueSorted{
+		subIt: subIt,
+	}
+}
+
+func (it *UniqueSorted) Iterate() Scanner {
+	return newUniqueSortedNext(it.subIt.Iterate())
+}
+
+func (it *UniqueSorted) Lookup() Index {
+	return newUniqueContains(it.subIt.Lookup())
+}
+
+// SubIterators returns a slice of the sub iterators.
+func (it *UniqueSorted) SubIterators() []Shape {
+	return []Shape{it.subIt}
+}
+
+func (it *UniqueSorted) Optimize(ctx context.Context) (Shape, bool) {
+	newIt, optimized := it.subIt.Optimize(ctx)
+	if optimized {
+		it.subIt = newIt
+	}
+	return it, false
+}
+
+func (it *UniqueSorted) Stats(ctx context.Context) (Costs, error) {
+	subStats, err := it.subIt.Stats(ctx)
+	return Costs{
+		NextCost:     subStats.NextCost * uniquenessFactor,
+		ContainsCost: subStats.ContainsCost,
+		Size: refs.Size{
+			Value: subStats.Size.Value / uniquenessFactor,
+			Exact: false,
+		},
+	}, err
+}
+
+func (it *UniqueSorted) String() string {
+	return "UniqueSorted"
+}
+
+// uniqueSortedNext skips values that are equal to the previous one.
+type uniqueSortedNext struct {
+	subIt  Scanner
+	result refs.Ref
+	last   interface{} // key of the last returned value
+	err    error
+}
+
+func newUniqueSortedNext(subIt Scanner) *uniqueSortedNext {
+	return &uniqueSortedNext{
+		subIt: subIt,
+	}
+}
+
+func (it *uniqueSortedNext) TagResults(dst map[string]refs.Ref) {
+	if it.subIt != nil {
+		it.subIt.TagResults(dst)
+	}
+}
+
+func (it *uniqueSortedNext) Next(ctx context.Context) bool {
+	for it.subIt.Next(ctx) {
+		curr := it.subIt.Result()
+		key := refs.ToKey(curr)
+		if it.result == nil || key != it.last {
+			it.result, it.last = curr, key
+			return true
+		}
+	}
+	it.err = it.subIt.Err()
+	return false
+}
+
+func (it *uniqueSortedNext) Err() error {
+	return it.err
+}
+
+func (it *uniqueSortedNext) Result() refs.Ref {
+	return it.result
+}
+
+// NextPath for unique always returns false, see uniqueNext for details.
+func (it *uniqueSortedNext) NextPath(ctx context.Context) bool {
+	return false
+}
+
+func (it *uniqueSortedNext) Close() error {
+	return it.subIt.Close()
+}
+
+func (it *uniqueSortedNext) String() string {
+	return "UniqueSortedNext"
+}
@@ -31,3 +31,25 @@ func TestUniqueIteratorBasics(t *testing.T) {
 		require.True(t, uc.Contains(ctx, Int64Node(v)))
 	}
 }
+
+func TestUniqueSorted(t *testing.T) {
+	ctx := context.TODO()
+	newSorted := func() Shape {
+		return NewSort(simpleStore, NewFixed(
+			Int64Node(3),
+			Int64Node(1),
+			Int64Node(3),
+			Int64Node(2),
+			Int64Node(1),
+			Int64Node(3),
+		))
+	}
+
+	expect := []int{1, 2, 3}
+	require.Equal(t, expect, iterated(NewUnique(newSorted())))
+	require.Equal(t, expect, iterated(NewUniqueSorted(newSorted())))
+
+	uc := NewUniqueSorted(newSorted()).Lookup()
+	require.True(t, uc.Contains(ctx, Int64Node(2)))
+	require.False(t, uc.Contains(ctx, Int64Node(4)))
+}
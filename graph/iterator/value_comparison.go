@@ -46,6 +46,8 @@ func (op Operator) String() string {
 		return ">"
 	case CompareGTE:
 		return ">="
+	case CompareEQ:
+		return "="
 	default:
 		return fmt.Sprintf("op(%d)", int(op))
 	}
@@ -56,48 +58,66 @@ const (
 	CompareLTE
 	CompareGT
 	CompareGTE
-	// Why no Equals? Because that's usually an AndIterator.
+	// CompareEQ is only useful for comparing values that are not known in advance,
+	// for example different directions of the same quad. Otherwise, it's usually an AndIterator.
+	CompareEQ
 )
 
 func NewComparison(sub Shape, op Operator, val quad.Value, qs refs.Namer) Shape {
 	return NewValueFilter(qs, sub, func(qval quad.Value) (bool, error) {
-		switch cVal := val.(type) {
-		case quad.Int:
-			if cVal2, ok := qval.(quad.Int); ok {
-				return RunIntOp(cVal2, op, cVal), nil
-			}
-			return false, nil
-		case quad.Float:
-			if cVal2, ok := qval.(quad.Float); ok {
-				return RunFloatOp(cVal2, op, cVal), nil
-			}
-			return false, nil
-		case quad.String:
-			if cVal2, ok := qval.(quad.String); ok {
-				return RunStrOp(string(cVal2), op, string(cVal)), nil
-			}
-			return false, nil
-		case quad.BNode:
-			if cVal2, ok := qval.(quad.BNode); ok {
-				return RunStrOp(string(cVal2), op, string(cVal)), nil
-			}
-			return false, nil
-		case quad.IRI:
-			if cVal2, ok := qval.(quad.IRI); ok {
-				return RunStrOp(string(cVal2), op, string(cVal)), nil
-			}
-			return false, nil
-		case quad.Time:
-			if cVal2, ok := qval.(quad.Time); ok {
-				return RunTimeOp(time.Time(cVal2), op, time.Time(cVal)), nil
-			}
-			return false, nil
-		default:
-			return RunStrOp(quad.StringOf(qval), op, quad.StringOf(val)), nil
-		}
+		return CompareValues(qval, op, val), nil
 	})
 }
 
+// CompareValues compares two values with a given operator. Values of different types are
+// never matched, except for the ones that have no special handling; those are compared as strings.
+func CompareValues(qval quad.Value, op Operator, val quad.Value) bool {
+	switch cVal := val.(type) {
+	case quad.Int:
+		if cVal2, ok := qval.(quad.Int); ok {
+			return RunIntOp(cVal2, op, cVal)
+		}
+		return false
+	case quad.Float:
+		if cVal2, ok := qval.(quad.Float); ok {
+			return RunFloatOp(cVal2, op, cVal)
+		}
+		return false
+	case quad.String:
+		if cVal2, ok := qval.(quad.String); ok {
+			return RunStrOp(string(cVal2), op, string(cVal))
+		}
+		return false
+	case quad.BNode:
+		if cVal2, ok := qval.(quad.BNode); ok {
+			return RunStrOp(string(cVal2), op, string(cVal))
+		}
+		return false
+	case quad.IRI:
+		if cVal2, ok := qval.(quad.IRI); ok {
+			return RunStrOp(string(cVal2), op, string(cVal))
+		}
+		return false
+	case quad.Time:
+		if cVal2, ok := qval.(quad.Time); ok {
+			return RunTimeOp(time.Time(cVal2), op, time.Time(cVal))
+		}
+		return false
+	case quad.Bool:
+		if cVal2, ok := qval.(quad.Bool); ok {
+			return RunBoolOp(cVal2, op, cVal)
+		}
+		return false
+	case quad.LangString:
+		if cVal2, ok := qval.(quad.LangString); ok && cVal2.Lang == cVal.Lang {
+			return RunStrOp(string(cVal2.Value), op, string(cVal.Value))
+		}
+		return false
+	default:
+		return RunStrOp(quad.StringOf(qval), op, quad.StringOf(val))
+	}
+}
+
 func RunIntOp(a quad.Int, op Operator, b quad.Int) bool {
 	switch op {
 	case CompareLT:
@@ -108,6 +128,8 @@ func RunIntOp(a quad.Int, op Operator, b quad.Int) bool {
 		return a > b
 	case CompareGTE:
 		return a >= b
+	case CompareEQ:
+		return a == b
 	default:
 		panic("Unknown operator type")
 	}
@@ -123,6 +145,8 @@ func RunFloatOp(a quad.Float, op Operator, b quad.Float) bool {
 		return a > b
 	case CompareGTE:
 		return a >= b
+	case CompareEQ:
+		return a == b
 	default:
 		panic("Unknown operator type")
 	}
@@ -138,6 +162,8 @@ func RunStrOp(a string, op Operator, b string) bool {
 		return a > b
 	case CompareGTE:
 		return a >= b
+	case CompareEQ:
+		return a == b
 	default:
 		panic("Unknown operator type")
 	}
@@ -153,7 +179,20 @@ func RunTimeOp(a time.Time, op Operator, b time.Time) bool {
 		return a.After(b)
 	case CompareGTE:
 		return !a.Before(b)
+	case CompareEQ:
+		return a.Equal(b)
 	default:
 		panic("Unknown operator type")
 	}
 }
+
+// RunBoolOp compares two boolean values, assuming false < true.
+func RunBoolOp(a quad.Bool, op Operator, b quad.Bool) bool {
+	toInt := func(v quad.Bool) quad.Int {
+		if v {
+			return 1
+		}
+		return 0
+	}
+	return RunIntOp(toInt(a), op, toInt(b))
+}
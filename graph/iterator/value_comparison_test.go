@@ -238,6 +238,8 @@ func TestVCIContains(t *testing.T) {
 		vc := NewComparison(test.iterator(), test.operator, test.val, test.qs).Lookup()
 		if vc.Contains(ctx, test.check) != test.expect {
 			t.Errorf("Failed to show %s", test.message)
+		} else if test.expect && vc.Result() != test.check {
+			t.Errorf("Unexpected result for %s: %v", test.message, vc.Result())
 		}
 	}
 }
@@ -271,3 +273,27 @@ func TestComparisonIteratorErr(t *testing.T) {
 		require.Equal(t, wantErr, vc.Err())
 	}
 }
+
+var compareValuesTests = []struct {
+	message string
+	a       quad.Value
+	op      Operator
+	b       quad.Value
+	expect  bool
+}{
+	{"true is greater than false", quad.Bool(true), CompareGT, quad.Bool(false), true},
+	{"false is less than true", quad.Bool(false), CompareLT, quad.Bool(true), true},
+	{"false is not greater than false", quad.Bool(false), CompareGT, quad.Bool(false), false},
+	{"bool is not compared to string", quad.String("true"), CompareGT, quad.Bool(false), false},
+	{"lang strings compare lexically", quad.LangString{Value: "c", Lang: "en"}, CompareGT, quad.LangString{Value: "b", Lang: "en"}, true},
+	{"lang strings with different languages", quad.LangString{Value: "c", Lang: "fr"}, CompareGT, quad.LangString{Value: "b", Lang: "en"}, false},
+	{"lang string is not compared to string", quad.String("c"), CompareGT, quad.LangString{Value: "b", Lang: "en"}, false},
+}
+
+func TestCompareValues(t *testing.T) {
+	for _, test := range compareValuesTests {
+		if got := CompareValues(test.a, test.op, test.b); got != test.expect {
+			t.Errorf("Failed to show %s", test.message)
+		}
+	}
+}
@@ -108,6 +108,11 @@ func (it *valueFilterNext) Close() error {
 
 func (it *valueFilterNext) Next(ctx context.Context) bool {
 	for it.sub.Next(ctx) {
+		// filters may reject most of the values, so check for cancellation while scanning
+		if err := ctx.Err(); err != nil {
+			it.err = err
+			return false
+		}
 		val := it.sub.Result()
 		if it.doFilter(val) {
 			it.result = val
@@ -186,14 +191,17 @@ func (it *valueFilterContains) NextPath(ctx context.Context) bool {
 }
 
 func (it *valueFilterContains) Contains(ctx context.Context, val refs.Ref) bool {
+	it.result = nil
 	if !it.doFilter(val) {
 		return false
 	}
 	ok := it.sub.Contains(ctx, val)
 	if !ok {
 		it.err = it.sub.Err()
+		return false
 	}
-	return ok
+	it.result = val
+	return true
 }
 
 // If we failed the check, then the subiterator should not contribute to the result
@@ -0,0 +1,232 @@
+package iterator
+
+import (
+	"context"
+
+	"github.com/cayleygraph/cayley/graph/refs"
+	"github.com/cayleygraph/quad"
+	"github.com/cayleygraph/quad/voc/rdf"
+	"github.com/cayleygraph/quad/voc/rdfs"
+	"github.com/cayleygraph/quad/voc/xsd"
+)
+
+// ValueType iterator replaces each value from it's subiterator with an IRI of the value type.
+//
+// Literals are replaced with their datatype (xsd:string for plain strings), while IRIs and
+// blank nodes are replaced with rdfs:Resource. Tags of the subiterator are preserved.
+type ValueType struct {
+	namer refs.Namer
+	subIt Shape
+}
+
+// NewValueType creates a new iterator that returns types of values from a subiterator.
+func NewValueType(namer refs.Namer, subIt Shape) *ValueType {
+	return &ValueType{
+		namer: namer,
+		subIt: subIt,
+	}
+}
+
+// TypeOf returns an IRI of the value type. See ValueType for details.
+func TypeOf(v quad.Value) quad.IRI {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case quad.IRI, quad.BNode:
+		return quad.IRI(rdfs.Resource)
+	case quad.String:
+		return quad.IRI(xsd.String)
+	case quad.LangString:
+		return quad.IRI(rdf.LangString)
+	case quad.TypedString:
+		return v.Type
+	case quad.TypedStringer:
+		return v.TypedString().Type
+	}
+	return quad.IRI(rdfs.Literal)
+}
+
+func (it *ValueType) Iterate() Scanner {
+	return newValueTypeNext(it.namer, it.subIt.Iterate())
+}
+
+func (it *ValueType) Lookup() Index {
+	return newValueTypeContains(it.namer, it.subIt)
+}
+
+// SubIterators returns a slice of the sub iterators.
+func (it *ValueType) SubIterators() []Shape {
+	return []Shape{it.subIt}
+}
+
+func (it *ValueType) Optimize(ctx context.Context) (Shape, bool) {
+	newIt, optimized := it.subIt.Optimize(ctx)
+	if optimized {
+		it.subIt = newIt
+	}
+	return it, false
+}
+
+func (it *ValueType) Stats(ctx context.Context) (Costs, error) {
+	subStats, err := it.subIt.Stats(ctx)
+	return Costs{
+		NextCost: subStats.NextCost + 1,
+		// to check if the type is present, we need to scan the whole subiterator
+		ContainsCost: subStats.NextCost * subStats.Size.Value,
+		Size:         subStats.Size,
+	}, err
+}
+
+func (it *ValueType) String() string {
+	return "ValueType"
+}
+
+// typeRef resolves a type of a given value reference.
+func typeRef(namer refs.Namer, v refs.Ref) (refs.Ref, error) {
+	name, err := namer.NameOf(v)
+	if err != nil {
+		return nil, err
+	}
+	return refs.PreFetched(TypeOf(name)), nil
+}
+
+// valueTypeNext replaces values of the subiterator with their types.
+type valueTypeNext struct {
+	namer  refs.Namer
+	subIt  Scanner
+	result refs.Ref
+	err    error
+}
+
+func newValueTypeNext(namer refs.Namer, subIt Scanner) *valueTypeNext {
+	return &valueTypeNext{
+		namer: namer,
+		subIt: subIt,
+	}
+}
+
+func (it *valueTypeNext) TagResults(dst map[string]refs.Ref) {
+	it.subIt.TagResults(dst)
+}
+
+func (it *valueTypeNext) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	if !it.subIt.Next(ctx) {
+		it.err = it.subIt.Err()
+		return false
+	}
+	it.result, it.err = typeRef(it.namer, it.subIt.Result())
+	return it.err == nil
+}
+
+func (it *valueTypeNext) Err() error {
+	return it.err
+}
+
+func (it *valueTypeNext) Result() refs.Ref {
+	return it.result
+}
+
+func (it *valueTypeNext) NextPath(ctx context.Context) bool {
+	return it.subIt.NextPath(ctx)
+}
+
+func (it *valueTypeNext) Close() error {
+	return it.subIt.Close()
+}
+
+func (it *valueTypeNext) String() string {
+	return "ValueTypeNext"
+}
+
+// valueTypeContains checks if any value of the subiterator has a given type.
+type valueTypeContains struct {
+	namer  refs.Namer
+	subIt  Shape
+	sc     Scanner // subiterator positioned on the value of a given type
+	result refs.Ref
+	err    error
+}
+
+func newValueTypeContains(namer refs.Namer, subIt Shape) *valueTypeContains {
+	return &valueTypeContains{
+		namer: namer,
+		subIt: subIt,
+	}
+}
+
+func (it *valueTypeContains) TagResults(dst map[string]refs.Ref) {
+	if it.sc != nil {
+		it.sc.TagResults(dst)
+	}
+}
+
+func (it *valueTypeContains) closeScanner() {
+	if it.sc == nil {
+		return
+	}
+	if err := it.sc.Close(); err != nil && it.err == nil {
+		it.err = err
+	}
+	it.sc = nil
+}
+
+func (it *valueTypeContains) Contains(ctx context.Context, val refs.Ref) bool {
+	it.closeScanner()
+	it.result = nil
+	if it.err != nil {
+		return false
+	}
+	var typ quad.Value
+	if v, ok := val.(refs.PreFetchedValue); ok {
+		typ = v.NameOf()
+	} else if name, err := it.namer.NameOf(val); err != nil {
+		it.err = err
+		return false
+	} else {
+		typ = name
+	}
+	sc := it.subIt.Iterate()
+	for sc.Next(ctx) {
+		name, err := it.namer.NameOf(sc.Result())
+		if err != nil {
+			it.err = err
+			break
+		}
+		if TypeOf(name) == typ {
+			it.sc, it.result = sc, val
+			return true
+		}
+	}
+	if it.err == nil {
+		it.err = sc.Err()
+	}
+	sc.Close()
+	return false
+}
+
+func (it *valueTypeContains) Err() error {
+	return it.err
+}
+
+func (it *valueTypeContains) Result() refs.Ref {
+	return it.result
+}
+
+func (it *valueTypeContains) NextPath(ctx context.Context) bool {
+	if it.sc == nil {
+		return false
+	}
+	return it.sc.NextPath(ctx)
+}
+
+func (it *valueTypeContains) Close() error {
+	it.closeScanner()
+	return it.err
+}
+
+func (it *valueTypeContains) String() string {
+	return "ValueTypeContains"
+}
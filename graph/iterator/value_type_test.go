@@ -0,0 +1,60 @@
+package iterator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cayleygraph/cayley/graph/graphmock"
+	. "github.com/cayleygraph/cayley/graph/iterator"
+	"github.com/cayleygraph/cayley/graph/refs"
+	"github.com/cayleygraph/quad"
+	"github.com/cayleygraph/quad/voc/rdf"
+	"github.com/cayleygraph/quad/voc/rdfs"
+	"github.com/cayleygraph/quad/voc/xsd"
+)
+
+func TestTypeOf(t *testing.T) {
+	for _, c := range []struct {
+		val    quad.Value
+		expect quad.IRI
+	}{
+		{quad.IRI("bob"), rdfs.Resource},
+		{quad.BNode("b1"), rdfs.Resource},
+		{quad.String("bob"), xsd.String},
+		{quad.LangString{Value: "bob", Lang: "en"}, rdf.LangString},
+		{quad.Int(1), xsd.Integer},
+		{quad.TypedString{Value: "1", Type: "custom"}, "custom"},
+	} {
+		require.Equal(t, c.expect, TypeOf(c.val), "%#v", c.val)
+	}
+}
+
+func TestValueType(t *testing.T) {
+	ctx := context.TODO()
+	qs := &graphmock.Store{Data: []quad.Quad{
+		quad.Make(quad.IRI("bob"), quad.IRI("status"), quad.String("cool"), nil),
+	}}
+	fixed := NewFixed(
+		refs.PreFetched(quad.IRI("bob")),
+		refs.PreFetched(quad.String("cool")),
+	)
+	it := NewValueType(qs, fixed)
+
+	var got []quad.Value
+	sc := it.Iterate()
+	for sc.Next(ctx) {
+		v, err := qs.NameOf(sc.Result())
+		require.NoError(t, err)
+		got = append(got, v)
+	}
+	require.NoError(t, sc.Err())
+	require.NoError(t, sc.Close())
+	require.Equal(t, []quad.Value{quad.IRI(rdfs.Resource), quad.IRI(xsd.String)}, got)
+
+	ix := it.Lookup()
+	require.True(t, ix.Contains(ctx, refs.PreFetched(quad.IRI(xsd.String))))
+	require.False(t, ix.Contains(ctx, refs.PreFetched(quad.IRI(xsd.Integer))))
+	require.NoError(t, ix.Close())
+}
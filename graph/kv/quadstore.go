@@ -344,21 +344,23 @@ func (qs *QuadStore) ValuesOf(ctx context.Context, vals []graph.Ref) ([]quad.Val
 	return out, last
 }
 
+// RefsOf resolves multiple values in a single batch. References for values that are not found are nil.
 func (qs *QuadStore) RefsOf(ctx context.Context, nodes []quad.Value) ([]graph.Ref, error) {
-	values := make([]graph.Ref, len(nodes))
+	var ids []uint64
 	err := kv.View(qs.db, func(tx kv.Tx) error {
-		for i, node := range nodes {
-			value, err := qs.resolveQuadValue(ctx, tx, node)
-			if err != nil {
-				return err
-			}
-			values[i] = Int64Value(value)
-		}
-		return nil
+		var err error
+		ids, err = qs.resolveQuadValues(ctx, tx, nodes)
+		return err
 	})
 	if err != nil {
 		return nil, err
 	}
+	values := make([]graph.Ref, len(nodes))
+	for i, id := range ids {
+		if id != 0 {
+			values[i] = Int64Value(id)
+		}
+	}
 	return values, nil
 }
 
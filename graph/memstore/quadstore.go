@@ -393,6 +393,43 @@ func (qs *QuadStore) Delete(id int64) bool {
 	return true
 }
 
+// Compact rebuilds internal indexes of the quad store, dropping entries left after deleted quads
+// and reclaiming memory. It is useful after deleting a large number of quads.
+//
+// Similar to writes, compaction requires an exclusive access to the quad store.
+func (qs *QuadStore) Compact() error {
+	vals := make(map[string]int64, len(qs.vals))
+	for k, id := range qs.vals {
+		vals[k] = id
+	}
+	quads := make(map[internalQuad]int64, len(qs.quads))
+	for q, id := range qs.quads {
+		quads[q] = id
+	}
+	prim := make(map[int64]*Primitive, len(qs.prim))
+	for id, p := range qs.prim {
+		prim[id] = p
+	}
+	// iterators may still hold the old slice, so always allocate a new one
+	all := make([]*Primitive, len(qs.all))
+	copy(all, qs.all)
+
+	index := NewQuadDirectionIndex()
+	for d, trees := range qs.index.index {
+		for id, tree := range trees {
+			if tree.Len() == 0 {
+				// node was removed, or it's not used in this direction anymore
+				continue
+			}
+			index.index[d][id] = tree
+		}
+	}
+	qs.vals, qs.quads, qs.prim = vals, quads, prim
+	qs.all, qs.reading = all, false
+	qs.index = index
+	return nil
+}
+
 func (qs *QuadStore) findQuad(q quad.Quad) (int64, internalQuad, bool) {
 	p, ok := qs.resolveQuad(q, false)
 	if !ok {
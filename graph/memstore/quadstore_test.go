@@ -16,6 +16,7 @@ package memstore
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"sort"
 	"testing"
@@ -264,3 +265,65 @@ func TestTransaction(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, st, st2, "Appended a new quad in a failed transaction")
 }
+
+func TestCompact(t *testing.T) {
+	ctx := context.TODO()
+	const n, keep = 1000, 10
+
+	qs := New()
+	var quads []quad.Quad
+	for i := 0; i < n; i++ {
+		q := quad.Make(quad.IRI(fmt.Sprintf("n%d", i)), quad.IRI("follows"), quad.IRI("hub"), nil)
+		qs.AddQuad(q)
+		quads = append(quads, q)
+	}
+	var deltas []graph.Delta
+	for _, q := range quads[keep:] {
+		deltas = append(deltas, graph.Delta{Quad: q, Action: graph.Delete})
+	}
+	err := qs.ApplyDeltas(deltas, graph.IgnoreOpts{})
+	require.NoError(t, err)
+
+	trees := func() int {
+		var cnt int
+		for _, m := range qs.index.index {
+			cnt += len(m)
+		}
+		return cnt
+	}
+	before := trees()
+
+	err = qs.Compact()
+	require.NoError(t, err)
+	require.True(t, trees() < before, "expected less index entries after compaction: %d vs %d", trees(), before)
+	// one entry per remaining subject, one for the predicate and one for the object
+	require.Equal(t, keep+2, trees())
+
+	st, err := qs.Stats(ctx, true)
+	require.NoError(t, err)
+	require.Equal(t, int64(keep), st.Quads.Value)
+
+	var expect, got []string
+	for _, q := range quads[:keep] {
+		expect = append(expect, q.Subject.String())
+	}
+	hub, err := qs.ValueOf(quad.IRI("hub"))
+	require.NoError(t, err)
+	it := graph.NewHasA(qs, qs.QuadIterator(quad.Object, hub), quad.Subject).Iterate()
+	defer it.Close()
+	for it.Next(ctx) {
+		v, err := qs.NameOf(it.Result())
+		require.NoError(t, err)
+		got = append(got, v.String())
+	}
+	require.NoError(t, it.Err())
+	sort.Strings(expect)
+	sort.Strings(got)
+	require.Equal(t, expect, got)
+
+	// store is still writable after compaction
+	qs.AddQuad(quads[keep])
+	st, err = qs.Stats(ctx, true)
+	require.NoError(t, err)
+	require.Equal(t, int64(keep+1), st.Quads.Value)
+}
@@ -180,6 +180,8 @@ type QuadStore struct {
 	opt   Traits
 }
 
+var _ refs.BatchNamer = (*QuadStore)(nil)
+
 func ensureIndexes(ctx context.Context, db nosql.Database) error {
 	err := db.EnsureIndex(ctx, colLog, nosql.Index{
 		Fields: []string{fldLogID},
@@ -702,6 +704,34 @@ func (qs *QuadStore) NameOf(v graph.Ref) (quad.Value, error) {
 	return qv, nil
 }
 
+// ValuesOf resolves multiple references at once. Values that are not found are nil.
+func (qs *QuadStore) ValuesOf(ctx context.Context, vals []graph.Ref) ([]quad.Value, error) {
+	out := make([]quad.Value, len(vals))
+	for i, v := range vals {
+		qv, err := qs.NameOf(v)
+		if err == nosql.ErrNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		out[i] = qv
+	}
+	return out, nil
+}
+
+// RefsOf resolves multiple values at once. References are derived from value hashes,
+// thus it never queries the database and, like ValueOf, returns references even for
+// values that are not stored.
+func (qs *QuadStore) RefsOf(ctx context.Context, nodes []quad.Value) ([]graph.Ref, error) {
+	out := make([]graph.Ref, len(nodes))
+	for i, v := range nodes {
+		if v != nil {
+			out[i] = qs.hashOf(v)
+		}
+	}
+	return out, nil
+}
+
 func (qs *QuadStore) Stats(ctx context.Context, exact bool) (graph.Stats, error) {
 	// TODO(barakmich): Make size real; store it in the log, and retrieve it.
 	nodes, err := qs.db.Query(colNodes).Count(ctx)
@@ -99,6 +99,8 @@ func toFieldFilter(opt *Traits, c shape.Comparison) ([]nosql.FieldFilter, bool)
 		op = nosql.LT
 	case iterator.CompareLTE:
 		op = nosql.LTE
+	case iterator.CompareEQ:
+		op = nosql.Equal
 	default:
 		return nil, false
 	}
@@ -143,6 +145,16 @@ func toFieldFilter(opt *Traits, c shape.Comparison) ([]nosql.FieldFilter, bool)
 		filters = []nosql.FieldFilter{
 			{Path: fieldPath(fldValTime), Filter: op, Value: nosql.Time(v)},
 		}
+	case quad.Bool:
+		filters = []nosql.FieldFilter{
+			{Path: fieldPath(fldValBool), Filter: op, Value: nosql.Bool(v)},
+		}
+	case quad.LangString:
+		// compare lexical forms of strings with the same language tag
+		filters = []nosql.FieldFilter{
+			{Path: fieldPath(fldValData), Filter: op, Value: nosql.String(v.Value)},
+			{Path: fieldPath(fldLang), Filter: nosql.Equal, Value: nosql.String(v.Lang)},
+		}
 	default:
 		return nil, false
 	}
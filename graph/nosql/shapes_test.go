@@ -0,0 +1,153 @@
+package nosql
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/hidal-go/hidalgo/legacy/nosql"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cayleygraph/cayley/graph/iterator"
+	"github.com/cayleygraph/cayley/query/shape"
+	"github.com/cayleygraph/quad"
+)
+
+var optimizeFilterCases = []struct {
+	name   string
+	from   shape.Shape
+	filter shape.ValueFilter
+	expect shape.Shape
+}{
+	{
+		name:   "regexp strings",
+		from:   shape.AllNodes{},
+		filter: shape.Regexp{Re: regexp.MustCompile("^a")},
+		expect: Shape{Collection: colNodes, Filters: []nosql.FieldFilter{
+			{Path: []string{fldValue, fldValData}, Filter: nosql.Regexp, Value: nosql.String("^a")},
+			{Path: []string{fldValue, fldIRI}, Filter: nosql.NotEqual, Value: nosql.Bool(true)},
+			{Path: []string{fldValue, fldBNode}, Filter: nosql.NotEqual, Value: nosql.Bool(true)},
+		}},
+	},
+	{
+		name:   "regexp refs",
+		from:   shape.AllNodes{},
+		filter: shape.Regexp{Re: regexp.MustCompile("^a"), Refs: true},
+		expect: Shape{Collection: colNodes, Filters: []nosql.FieldFilter{
+			{Path: []string{fldValue, fldValData}, Filter: nosql.Regexp, Value: nosql.String("^a")},
+		}},
+	},
+	{
+		name:   "regexp not all nodes",
+		from:   shape.Lookup{quad.String("a")},
+		filter: shape.Regexp{Re: regexp.MustCompile("^a")},
+	},
+	{
+		name:   "compare bool gt",
+		from:   shape.AllNodes{},
+		filter: shape.Comparison{Op: iterator.CompareGT, Val: quad.Bool(false)},
+		expect: Shape{Collection: colNodes, Filters: []nosql.FieldFilter{
+			{Path: []string{fldValue, fldValBool}, Filter: nosql.GT, Value: nosql.Bool(false)},
+		}},
+	},
+	{
+		name:   "compare bool lt",
+		from:   shape.AllNodes{},
+		filter: shape.Comparison{Op: iterator.CompareLT, Val: quad.Bool(true)},
+		expect: Shape{Collection: colNodes, Filters: []nosql.FieldFilter{
+			{Path: []string{fldValue, fldValBool}, Filter: nosql.LT, Value: nosql.Bool(true)},
+		}},
+	},
+	{
+		name:   "compare lang string gt",
+		from:   shape.AllNodes{},
+		filter: shape.Comparison{Op: iterator.CompareGT, Val: quad.LangString{Value: "b", Lang: "en"}},
+		expect: Shape{Collection: colNodes, Filters: []nosql.FieldFilter{
+			{Path: []string{fldValue, fldValData}, Filter: nosql.GT, Value: nosql.String("b")},
+			{Path: []string{fldValue, fldLang}, Filter: nosql.Equal, Value: nosql.String("en")},
+		}},
+	},
+	{
+		name:   "compare lang string lte",
+		from:   shape.AllNodes{},
+		filter: shape.Comparison{Op: iterator.CompareLTE, Val: quad.LangString{Value: "b", Lang: "fr"}},
+		expect: Shape{Collection: colNodes, Filters: []nosql.FieldFilter{
+			{Path: []string{fldValue, fldValData}, Filter: nosql.LTE, Value: nosql.String("b")},
+			{Path: []string{fldValue, fldLang}, Filter: nosql.Equal, Value: nosql.String("fr")},
+		}},
+	},
+	{
+		name:   "compare typed string",
+		from:   shape.AllNodes{},
+		filter: shape.Comparison{Op: iterator.CompareGT, Val: quad.TypedString{Value: "b", Type: "ex:t"}},
+	},
+}
+
+func TestOptimizeFilter(t *testing.T) {
+	qs := &QuadStore{}
+	for _, c := range optimizeFilterCases {
+		t.Run(c.name, func(t *testing.T) {
+			s := shape.Filter{From: c.from, Filters: []shape.ValueFilter{c.filter}}
+			got, opt := qs.OptimizeShape(context.TODO(), s)
+			if c.expect == nil {
+				require.False(t, opt)
+				require.Equal(t, s, got)
+				return
+			}
+			require.True(t, opt)
+			require.Equal(t, c.expect, got)
+		})
+	}
+}
+
+func TestOptimizeFilterEqual(t *testing.T) {
+	qs := &QuadStore{opt: Traits{Number32: true}}
+	tm := time.Unix(123, 0).UTC()
+	fld := func(name string) []string { return []string{fldValue, name} }
+	for _, c := range []struct {
+		val    quad.Value
+		expect []nosql.FieldFilter
+	}{
+		{quad.String("a"), []nosql.FieldFilter{
+			{Path: fld(fldValData), Filter: nosql.Equal, Value: nosql.String("a")},
+			{Path: fld(fldIRI), Filter: nosql.NotEqual, Value: nosql.Bool(true)},
+			{Path: fld(fldBNode), Filter: nosql.NotEqual, Value: nosql.Bool(true)},
+		}},
+		{quad.IRI("a"), []nosql.FieldFilter{
+			{Path: fld(fldValData), Filter: nosql.Equal, Value: nosql.String("a")},
+			{Path: fld(fldIRI), Filter: nosql.Equal, Value: nosql.Bool(true)},
+		}},
+		{quad.BNode("a"), []nosql.FieldFilter{
+			{Path: fld(fldValData), Filter: nosql.Equal, Value: nosql.String("a")},
+			{Path: fld(fldBNode), Filter: nosql.Equal, Value: nosql.Bool(true)},
+		}},
+		{quad.Int(5), []nosql.FieldFilter{
+			{Path: fld(fldValInt), Filter: nosql.Equal, Value: nosql.Int(5)},
+		}},
+		{quad.Int(math.MaxInt64), []nosql.FieldFilter{
+			{Path: fld(fldValStrInt), Filter: nosql.Equal, Value: nosql.String(itos(math.MaxInt64))},
+		}},
+		{quad.Float(1.5), []nosql.FieldFilter{
+			{Path: fld(fldValFloat), Filter: nosql.Equal, Value: nosql.Float(1.5)},
+		}},
+		{quad.Time(tm), []nosql.FieldFilter{
+			{Path: fld(fldValTime), Filter: nosql.Equal, Value: nosql.Time(tm)},
+		}},
+		{quad.Bool(true), []nosql.FieldFilter{
+			{Path: fld(fldValBool), Filter: nosql.Equal, Value: nosql.Bool(true)},
+		}},
+		{quad.LangString{Value: "a", Lang: "en"}, []nosql.FieldFilter{
+			{Path: fld(fldValData), Filter: nosql.Equal, Value: nosql.String("a")},
+			{Path: fld(fldLang), Filter: nosql.Equal, Value: nosql.String("en")},
+		}},
+	} {
+		s := shape.Filter{From: shape.AllNodes{}, Filters: []shape.ValueFilter{
+			shape.Comparison{Op: iterator.CompareEQ, Val: c.val},
+		}}
+		got, opt := qs.OptimizeShape(context.TODO(), s)
+		require.True(t, opt, "%#v", c.val)
+		require.Equal(t, Shape{Collection: colNodes, Filters: c.expect}, got, "%#v", c.val)
+	}
+}
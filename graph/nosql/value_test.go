@@ -1,9 +1,12 @@
 package nosql
 
 import (
+	"context"
 	"math"
 	"sort"
 	"testing"
+
+	"github.com/cayleygraph/quad"
 )
 
 func TestIntStr(t *testing.T) {
@@ -25,3 +28,19 @@ func TestIntStr(t *testing.T) {
 		}
 	}
 }
+
+func TestRefsOf(t *testing.T) {
+	qs := &QuadStore{}
+	vals := []quad.Value{quad.IRI("a"), nil, quad.String("b")}
+	got, err := qs.RefsOf(context.TODO(), vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range vals {
+		// references are computed the same way as in ValueOf; nil for nil values
+		exp, _ := qs.ValueOf(v)
+		if got[i] != exp {
+			t.Errorf("unexpected ref for %v: %v vs %v", v, got[i], exp)
+		}
+	}
+}
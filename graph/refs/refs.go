@@ -39,8 +39,14 @@ type Namer interface {
 	NameOf(Ref) (quad.Value, error)
 }
 
+// BatchNamer is an optional interface for QuadStores that can resolve multiple values at once.
 type BatchNamer interface {
+	// ValuesOf returns nodes for given opaque tokens, in the same order.
+	// Values that are not found are nil.
 	ValuesOf(ctx context.Context, vals []Ref) ([]quad.Value, error)
+	// RefsOf returns opaque tokens for given nodes, in the same order.
+	// Tokens for nodes that are not found are nil; unlike the RefsOf function,
+	// it does not return an error in this case.
 	RefsOf(ctx context.Context, nodes []quad.Value) ([]Ref, error)
 }
 
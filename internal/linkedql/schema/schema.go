@@ -25,6 +25,7 @@ const (
 var (
 	pathStep         = reflect.TypeOf((*linkedql.PathStep)(nil)).Elem()
 	iteratorStep     = reflect.TypeOf((*linkedql.IteratorStep)(nil)).Elem()
+	operator         = reflect.TypeOf((*linkedql.Operator)(nil)).Elem()
 	entityIdentifier = reflect.TypeOf((*linkedql.EntityIdentifier)(nil)).Elem()
 	value            = reflect.TypeOf((*quad.Value)(nil)).Elem()
 	propertyPath     = reflect.TypeOf((*linkedql.PropertyPath)(nil))
@@ -63,6 +64,9 @@ func typeToRange(t reflect.Type) string {
 	if t == propertyPath {
 		return linkedql.Prefix + "PropertyPath"
 	}
+	if t == operator {
+		return linkedql.Prefix + "Operator"
+	}
 	panic("Unexpected type " + t.String())
 }
 
@@ -181,6 +185,9 @@ func getStepTypeClasses(t reflect.Type) []string {
 	if t.Implements(iteratorStep) {
 		typeClasses = append(typeClasses, linkedql.Prefix+"IteratorStep")
 	}
+	if t.Implements(operator) {
+		typeClasses = append(typeClasses, linkedql.Prefix+"Operator")
+	}
 	return typeClasses
 }
 
@@ -343,6 +350,11 @@ func (g *generator) Generate() []byte {
 			"@type":         owl.Class,
 			rdfs.SubClassOf: identified{ID: linkedql.Prefix + "Step"},
 		},
+		map[string]interface{}{
+			"@id":           linkedql.Prefix + "Operator",
+			"@type":         owl.Class,
+			rdfs.SubClassOf: identified{ID: linkedql.Prefix + "Step"},
+		},
 	}
 	graph = append(graph, g.out...)
 	data, err := json.Marshal(map[string]interface{}{
@@ -96,6 +96,10 @@ func Repl(ctx context.Context, h *graph.Handle, queryLanguage string, timeout ti
 		return fmt.Errorf("unsupported query language: %q", queryLanguage)
 	}
 	ses := l.Session(h.QuadStore)
+	qw := h.QuadWriter
+	if wt, ok := ses.(query.WriteTracker); ok {
+		qw = wt.TrackWrites(qw)
+	}
 
 	term, err := terminal(history)
 	if os.IsNotExist(err) {
@@ -171,7 +175,7 @@ func Repl(ctx context.Context, h *graph.Handle, queryLanguage string, timeout ti
 			case ":a":
 				quad, err := nquads.Parse(args)
 				if err == nil {
-					err = h.QuadWriter.AddQuad(quad)
+					err = qw.AddQuad(quad)
 				}
 				if err != nil {
 					fmt.Printf("Error: not a valid quad: %v\n", err)
@@ -185,7 +189,7 @@ func Repl(ctx context.Context, h *graph.Handle, queryLanguage string, timeout ti
 					fmt.Printf("Error: not a valid quad: %v\n", err)
 					continue
 				}
-				err = h.QuadWriter.RemoveQuad(quad)
+				err = qw.RemoveQuad(quad)
 				if err != nil {
 					fmt.Printf("error deleting: %v\n", err)
 				}
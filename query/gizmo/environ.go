@@ -17,13 +17,17 @@ package gizmo
 // Builds a new Gizmo environment pointing at a session.
 
 import (
+	"container/heap"
 	"fmt"
 	"regexp"
+	"strconv"
 	"time"
 
 	"github.com/dop251/goja"
 
+	"github.com/cayleygraph/cayley/graph"
 	"github.com/cayleygraph/cayley/graph/iterator"
+	"github.com/cayleygraph/cayley/graph/refs"
 	"github.com/cayleygraph/cayley/query/path"
 	"github.com/cayleygraph/cayley/query/shape"
 	"github.com/cayleygraph/quad"
@@ -86,6 +90,818 @@ func (g *graphObject) NewVertex(call goja.FunctionCall) goja.Value {
 	})
 }
 
+// View starts a query path at the nodes cached by `path.saveView(name)`.
+// Signature: (name)
+//
+// Arguments:
+//
+// * `name`: A name of the view.
+//
+// Returns: Path object
+func (g *graphObject) View(name string) (*pathObject, error) {
+	p, err := g.viewPath(name)
+	if err != nil {
+		return nil, err
+	}
+	return &pathObject{
+		s:      g.s,
+		finals: true,
+		path:   p,
+	}, nil
+}
+
+// viewPath returns a path that starts at the nodes of a named view.
+func (g *graphObject) viewPath(name string) (*path.Path, error) {
+	nodes, err := g.s.loadView(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		// empty set of nodes means all nodes for the path, so exclude everything instead
+		return path.StartMorphism().Except(path.StartMorphism()), nil
+	}
+	return path.StartPathNodes(nil, nodes...), nil
+}
+
+// DiffViews starts a query path at the nodes that are present in the first view, but not in the second one.
+// Signature: (name, name, [both])
+//
+// Arguments:
+//
+// * `name`: A name of the view.
+// * `both` (Optional): Also include nodes that are present in the second view, but not in the first one.
+//
+// Returns: Path object
+//
+// Example:
+//
+//	// javascript
+//	// Find people that bob stopped following since the last snapshot
+//	g.V("<bob>").out("<follows>").saveView("now")
+//	g.diffViews("before", "now").all()
+func (g *graphObject) DiffViews(a, b string, both bool) (*pathObject, error) {
+	pa, err := g.viewPath(a)
+	if err != nil {
+		return nil, err
+	}
+	pb, err := g.viewPath(b)
+	if err != nil {
+		return nil, err
+	}
+	p := pa.Clone().Except(pb)
+	if both {
+		p = p.Or(pb.Clone().Except(pa))
+	}
+	return &pathObject{
+		s:      g.s,
+		finals: true,
+		path:   p,
+	}, nil
+}
+
+// Jaccard computes Jaccard similarity of two nodes, which is a ratio of common neighbors
+// to the total number of neighbors of both nodes. Returns 0 if both nodes have no neighbors.
+// Signature: (node, node, [predicatePath])
+//
+// Arguments:
+//
+// * `node`: A node to compare.
+// * `predicatePath` (Optional): A predicate or a list of predicates to follow to find neighbors. No predicates means "all predicates".
+//
+// Returns: A float value
+//
+// Example:
+//
+//	// javascript
+//	// charlie and dani both follow bob, and in total they follow 3 people
+//	// Returns 0.333
+//	g.emit(g.jaccard("<charlie>", "<dani>", "<follows>"))
+func (g *graphObject) Jaccard(call goja.FunctionCall) goja.Value {
+	args := exportArgs(call.Arguments)
+	if len(args) < 2 {
+		return throwErr(g.s.vm, errArgCount2{Expected: 2, Got: len(args)})
+	}
+	nodes, err := toQuadValues(args[:2])
+	if err != nil {
+		return throwErr(g.s.vm, err)
+	}
+	var via []interface{}
+	if len(args) > 2 {
+		via = toVia(args[2:3])
+	}
+	a := path.StartMorphism(nodes[0]).Out(via...)
+	b := path.StartMorphism(nodes[1]).Out(via...)
+	union, err := g.s.countResults(a.Clone().Or(b).Unique().BuildIteratorOn(g.s.ctx, g.s.qs))
+	if err != nil {
+		return throwErr(g.s.vm, err)
+	} else if union == 0 {
+		return g.s.vm.ToValue(quad.Float(0))
+	}
+	inter, err := g.s.countResults(a.Clone().And(b).Unique().BuildIteratorOn(g.s.ctx, g.s.qs))
+	if err != nil {
+		return throwErr(g.s.vm, err)
+	}
+	return g.s.vm.ToValue(quad.Float(float64(inter) / float64(union)))
+}
+
+// HasCycle checks if following given predicates from a node eventually leads back to one of the visited nodes,
+// in other words, if a part of the graph reachable from the node contains a cycle.
+// Signature: (node, predicatePath)
+//
+// Arguments:
+//
+// * `node`: A node to start from.
+// * `predicatePath`: A predicate or a list of predicates to follow.
+//
+// Returns: A boolean value
+//
+// Example:
+//
+//	// javascript
+//	// Check that the hierarchy of categories has no loops
+//	g.emit(g.hasCycle("<root>", "<child>"))
+func (g *graphObject) HasCycle(call goja.FunctionCall) goja.Value {
+	args := exportArgs(call.Arguments)
+	if len(args) != 2 {
+		return throwErr(g.s.vm, errArgCount2{Expected: 2, Got: len(args)})
+	}
+	nodes, err := toQuadValues(args[:1])
+	if err != nil {
+		return throwErr(g.s.vm, err)
+	}
+	via := toVia(args[1:])
+	if len(via) == 0 {
+		return throwErr(g.s.vm, errNoVia)
+	}
+	ok, err := g.s.hasCycle(nodes[0], via)
+	if err != nil {
+		return throwErr(g.s.vm, err)
+	}
+	return g.s.vm.ToValue(ok)
+}
+
+// hasCycle runs a depth-first search from a given node and reports if any of the nodes on the current
+// search path can be reached again.
+func (s *Session) hasCycle(node quad.Value, via []interface{}) (bool, error) {
+	ctx := s.context()
+	start, err := iterator.Iterate(ctx, path.StartPath(s.qs, node).BuildIteratorOn(ctx, s.qs)).First()
+	if err != nil || start == nil {
+		return false, err
+	}
+	const (
+		onStack = 1 + iota // node is on the current search path
+		done               // all nodes reachable from this one were checked
+	)
+	state := make(map[interface{}]int)
+	var visit func(r graph.Ref) (bool, error)
+	visit = func(r graph.Ref) (bool, error) {
+		k := refs.ToKey(r)
+		switch state[k] {
+		case onStack:
+			return true, nil
+		case done:
+			return false, nil
+		}
+		state[k] = onStack
+		next, err := iterator.Iterate(ctx, path.StartPathNodes(s.qs, r).Out(via...).BuildIteratorOn(ctx, s.qs)).Paths(false).All()
+		if err != nil {
+			return false, err
+		}
+		for _, n := range next {
+			if ok, err := visit(n); err != nil || ok {
+				return ok, err
+			}
+		}
+		state[k] = done
+		return false, nil
+	}
+	return visit(start)
+}
+
+// QuadLabels returns labels (named graphs) of all quads that link a given subject, predicate and object.
+// Quads without a label are ignored.
+// Signature: (subject, predicate, object)
+//
+// Arguments:
+//
+// * `subject`: A subject of the quad.
+// * `predicate`: A predicate of the quad.
+// * `object`: An object of the quad.
+//
+// Returns: An array of labels
+//
+// Example:
+//
+//	// javascript
+//	// Find which graph contains the status of emily
+//	g.emit(g.quadLabels("<emily>", "<status>", "smart_person"))
+func (g *graphObject) QuadLabels(call goja.FunctionCall) goja.Value {
+	args := exportArgs(call.Arguments)
+	if len(args) != 3 {
+		return throwErr(g.s.vm, errArgCount2{Expected: 3, Got: len(args)})
+	}
+	vals, err := toQuadValues(args)
+	if err != nil {
+		return throwErr(g.s.vm, err)
+	}
+	labels, err := g.s.quadLabels(vals[0], vals[1], vals[2])
+	if err != nil {
+		return throwErr(g.s.vm, err)
+	}
+	return g.s.vm.ToValue(labels)
+}
+
+// quadLabels finds all quads with a given subject, predicate and object and returns unique values of their labels.
+func (s *Session) quadLabels(sub, pred, obj quad.Value) ([]interface{}, error) {
+	ctx := s.context()
+	nodes := [3]graph.Ref{}
+	for i, v := range []quad.Value{sub, pred, obj} {
+		r, err := s.qs.ValueOf(v)
+		if err != nil {
+			return nil, err
+		} else if r == nil {
+			return []interface{}{}, nil
+		}
+		nodes[i] = r
+	}
+	match := func(q graph.Ref, d quad.Direction, exp graph.Ref) (bool, error) {
+		r, err := s.qs.QuadDirection(q, d)
+		if err != nil || r == nil {
+			return false, err
+		}
+		return refs.ToKey(r) == refs.ToKey(exp), nil
+	}
+	out := make([]interface{}, 0)
+	seen := make(map[interface{}]struct{})
+	err := iterator.Iterate(ctx, s.qs.QuadIterator(quad.Subject, nodes[0])).Paths(false).Each(func(q graph.Ref) error {
+		if ok, err := match(q, quad.Predicate, nodes[1]); err != nil || !ok {
+			return err
+		}
+		if ok, err := match(q, quad.Object, nodes[2]); err != nil || !ok {
+			return err
+		}
+		l, err := s.qs.QuadDirection(q, quad.Label)
+		if err != nil || l == nil {
+			return err
+		}
+		k := refs.ToKey(l)
+		if _, ok := seen[k]; ok {
+			return nil
+		}
+		seen[k] = struct{}{}
+		v, err := s.qs.NameOf(l)
+		if err != nil {
+			return err
+		} else if v != nil {
+			out = append(out, s.quadValueToNative(v))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TransitiveReduction finds a transitive reduction of a directed acyclic graph formed by given predicates.
+// It returns only edges that are not implied by other edges, for example, "a -> c" edge is removed
+// if there are "a -> b" and "b -> c" edges. Results are targets of the remaining edges, their sources are
+// saved into "source" tag and quads can be exported with toNQuads.
+// An error is returned if the graph contains cycles, since the reduction is not defined for them.
+// Signature: (predicatePath)
+//
+// Arguments:
+//
+// * `predicatePath`: A predicate or a list of predicates to follow.
+//
+// Returns: Path object
+//
+// Example:
+//
+//	// javascript
+//	// Export a hierarchy of categories without redundant links
+//	g.emit(g.transitiveReduction("<parent>").toNQuads())
+func (g *graphObject) TransitiveReduction(call goja.FunctionCall) goja.Value {
+	args := exportArgs(call.Arguments)
+	if len(args) != 1 {
+		return throwErr(g.s.vm, errArgCount{Got: len(args)})
+	}
+	via := toVia(args)
+	if len(via) == 0 {
+		return throwErr(g.s.vm, errNoVia)
+	}
+	edges, err := g.s.transitiveReduction(via)
+	if err != nil {
+		return throwErr(g.s.vm, err)
+	}
+	its := make([]iterator.Shape, 0, len(edges))
+	for _, e := range edges {
+		it := iterator.NewSave(iterator.NewFixed(e.dst))
+		it.AddFixedTag(sourceTag, e.src)
+		it.AddFixedTag(quadsTag, e.quad)
+		its = append(its, it)
+	}
+	var it iterator.Shape = iterator.NewNull()
+	if len(its) != 0 {
+		it = iterator.NewOr(its...)
+	}
+	return g.s.vm.ToValue(&pathObject{
+		s:      g.s,
+		finals: true,
+		path:   path.PathFromIterator(g.s.qs, it),
+	})
+}
+
+type edgeQuad struct {
+	src, dst graph.Ref
+	quad     graph.Ref
+}
+
+// transitiveReduction returns quads with given predicates that are not implied by transitivity.
+func (s *Session) transitiveReduction(via []interface{}) ([]edgeQuad, error) {
+	const srcTag, dstTag = "src", "dst"
+	p := path.StartPath(s.qs).Tag(srcTag).Out(via...).SaveQuadRef(quadsTag).Tag(dstTag)
+	var (
+		quads []edgeQuad
+		nodes = make(map[interface{}]int)
+		out   []map[int]struct{} // outgoing edges for each node
+	)
+	add := func(r graph.Ref) int {
+		k := refs.ToKey(r)
+		i, ok := nodes[k]
+		if !ok {
+			i = len(nodes)
+			nodes[k] = i
+			out = append(out, make(map[int]struct{}))
+		}
+		return i
+	}
+	err := iterator.Iterate(s.context(), p.BuildIteratorOn(s.context(), s.qs)).Paths(true).TagEach(func(tags map[string]graph.Ref) error {
+		e := edgeQuad{src: tags[srcTag], dst: tags[dstTag], quad: tags[quadsTag]}
+		out[add(e.src)][add(e.dst)] = struct{}{}
+		quads = append(quads, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// sort nodes topologically, so each node goes after all nodes reachable from it
+	const (
+		onStack = 1 + iota
+		done
+	)
+	state := make([]int, len(out))
+	order := make([]int, 0, len(out))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case onStack:
+			return errCyclicGraph
+		case done:
+			return nil
+		}
+		state[i] = onStack
+		for j := range out[i] {
+			if err := visit(j); err != nil {
+				return err
+			}
+		}
+		state[i] = done
+		order = append(order, i)
+		return nil
+	}
+	for i := range out {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	// find nodes reachable from each node with paths of any length
+	reach := make([]map[int]struct{}, len(out))
+	for _, i := range order {
+		r := make(map[int]struct{})
+		for j := range out[i] {
+			r[j] = struct{}{}
+			for k := range reach[j] {
+				r[k] = struct{}{}
+			}
+		}
+		reach[i] = r
+	}
+	// edge is redundant if its target is reachable from any other target of the same source
+	redundant := func(src, dst int) bool {
+		for j := range out[src] {
+			if j == dst {
+				continue
+			}
+			if _, ok := reach[j][dst]; ok {
+				return true
+			}
+		}
+		return false
+	}
+	kept := quads[:0]
+	for _, e := range quads {
+		if !redundant(nodes[refs.ToKey(e.src)], nodes[refs.ToKey(e.dst)]) {
+			kept = append(kept, e)
+		}
+	}
+	return kept, nil
+}
+
+// DegreeHistogram computes a distribution of out-degrees of nodes for given predicates. It returns an object
+// that maps each degree to a number of nodes that have exactly this number of outgoing edges.
+// Nodes without such edges are not included.
+// Signature: (predicatePath)
+//
+// Arguments:
+//
+// * `predicatePath`: A predicate or a list of predicates to follow.
+//
+// Returns: An object with degrees as keys and numbers of nodes as values
+//
+// Example:
+//
+//	// javascript
+//	// Four people follow one person, while two people follow two persons
+//	// Returns: {"1": 4, "2": 2}
+//	g.emit(g.degreeHistogram("<follows>"))
+func (g *graphObject) DegreeHistogram(call goja.FunctionCall) goja.Value {
+	args := exportArgs(call.Arguments)
+	if len(args) != 1 {
+		return throwErr(g.s.vm, errArgCount{Got: len(args)})
+	}
+	via := toVia(args)
+	if len(via) == 0 {
+		return throwErr(g.s.vm, errNoVia)
+	}
+	const srcTag = "src"
+	p := path.StartPath(g.s.qs).Tag(srcTag).Out(via...)
+	degrees := make(map[interface{}]int64)
+	err := iterator.Iterate(g.s.context(), p.BuildIteratorOn(g.s.context(), g.s.qs)).Paths(true).TagEach(func(tags map[string]graph.Ref) error {
+		degrees[refs.ToKey(tags[srcTag])]++
+		return nil
+	})
+	if err != nil {
+		return throwErr(g.s.vm, err)
+	}
+	hist := make(map[string]interface{})
+	for _, d := range degrees {
+		k := strconv.FormatInt(d, 10)
+		n, _ := hist[k].(int64)
+		hist[k] = n + 1
+	}
+	return g.s.vm.ToValue(hist)
+}
+
+// TopByDegree finds k nodes with the highest number of incoming edges with given predicates.
+// Each node in the results has its degree saved into "degree" tag. Nodes with the same degree
+// are ordered by their value.
+// Signature: (predicatePath, k)
+//
+// Arguments:
+//
+// * `predicatePath`: A predicate or a list of predicates to follow.
+// * `k`: A number of nodes to return.
+//
+// Returns: Path object
+//
+// Example:
+//
+//	// javascript
+//	// Find two most-followed people
+//	// Returns: bob (3), fred (2)
+//	g.topByDegree("<follows>", 2).all()
+func (g *graphObject) TopByDegree(call goja.FunctionCall) goja.Value {
+	args := exportArgs(call.Arguments)
+	if len(args) != 2 {
+		return throwErr(g.s.vm, errArgCount2{Expected: 2, Got: len(args)})
+	}
+	via := toVia(args[:1])
+	if len(via) == 0 {
+		return throwErr(g.s.vm, errNoVia)
+	}
+	k, ok := toInt(args[1])
+	if !ok || k < 0 {
+		return throwErr(g.s.vm, fmt.Errorf("expected a number of nodes, got: %v", args[1]))
+	}
+	top, err := g.s.topByDegree(via, k)
+	if err != nil {
+		return throwErr(g.s.vm, err)
+	}
+	its := make([]iterator.Shape, 0, len(top))
+	for _, nd := range top {
+		it := iterator.NewSave(iterator.NewFixed(nd.node))
+		it.AddFixedTag("degree", refs.PreFetched(quad.Int(nd.degree)))
+		its = append(its, it)
+	}
+	var it iterator.Shape = iterator.NewNull()
+	if len(its) != 0 {
+		it = iterator.NewOr(its...)
+	}
+	return g.s.vm.ToValue(&pathObject{
+		s:      g.s,
+		finals: true,
+		path:   path.PathFromIterator(g.s.qs, it),
+	})
+}
+
+type nodeDegree struct {
+	node   graph.Ref
+	name   string
+	degree int64
+}
+
+// degreeHeap is a min-heap of nodes, with the node that should be evicted first at the top.
+type degreeHeap []nodeDegree
+
+func (h degreeHeap) Len() int { return len(h) }
+func (h degreeHeap) Less(i, j int) bool {
+	if h[i].degree != h[j].degree {
+		return h[i].degree < h[j].degree
+	}
+	return h[i].name > h[j].name
+}
+func (h degreeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *degreeHeap) Push(x interface{}) { *h = append(*h, x.(nodeDegree)) }
+func (h *degreeHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// topByDegree returns k nodes with the highest in-degree, sorted by degree in descending order.
+// It keeps only k nodes in a heap instead of sorting all of them.
+func (s *Session) topByDegree(via []interface{}, k int) ([]nodeDegree, error) {
+	p := path.StartPath(s.qs).Out(via...)
+	var (
+		nodes   []graph.Ref
+		degrees = make(map[interface{}]int64)
+	)
+	err := iterator.Iterate(s.context(), p.BuildIteratorOn(s.context(), s.qs)).Paths(true).Each(func(r graph.Ref) error {
+		key := refs.ToKey(r)
+		if _, ok := degrees[key]; !ok {
+			nodes = append(nodes, r)
+		}
+		degrees[key]++
+		return nil
+	})
+	if err != nil || k == 0 {
+		return nil, err
+	}
+	h := make(degreeHeap, 0, k)
+	for _, r := range nodes {
+		nd := nodeDegree{node: r, degree: degrees[refs.ToKey(r)]}
+		if len(h) == k && nd.degree < h[0].degree {
+			continue // cannot get into the top without resolving a name
+		}
+		name, err := s.qs.NameOf(r)
+		if err != nil {
+			return nil, err
+		}
+		nd.name = quad.StringOf(name)
+		if len(h) < k {
+			heap.Push(&h, nd)
+		} else if (degreeHeap{h[0], nd}).Less(0, 1) {
+			h[0] = nd
+			heap.Fix(&h, 0)
+		}
+	}
+	top := make([]nodeDegree, len(h))
+	for i := len(top) - 1; i >= 0; i-- {
+		top[i] = heap.Pop(&h).(nodeDegree)
+	}
+	return top, nil
+}
+
+// Roots finds nodes that have outgoing edges with given predicates, but are never an object of them.
+// For hierarchies it returns top-level nodes.
+// Signature: (predicatePath)
+//
+// Arguments:
+//
+// * `predicatePath`: A predicate or a list of predicates to follow.
+//
+// Returns: Path object
+//
+// Example:
+//
+//	// javascript
+//	// Find people that follow someone, but are not followed by anyone
+//	// Returns: alice, charlie, emily
+//	g.roots("<follows>").all()
+func (g *graphObject) Roots(call goja.FunctionCall) goja.Value {
+	return g.rootsOrLeaves(call, false)
+}
+
+// Leaves finds nodes that are objects of given predicates, but have no outgoing edges with them.
+// For hierarchies it returns bottom-level nodes.
+// Signature: (predicatePath)
+//
+// Arguments:
+//
+// * `predicatePath`: A predicate or a list of predicates to follow.
+//
+// Returns: Path object
+//
+// Example:
+//
+//	// javascript
+//	// Find people that are followed by someone, but don't follow anyone
+//	// Returns: greg
+//	g.leaves("<follows>").all()
+func (g *graphObject) Leaves(call goja.FunctionCall) goja.Value {
+	return g.rootsOrLeaves(call, true)
+}
+
+func (g *graphObject) rootsOrLeaves(call goja.FunctionCall, leaves bool) goja.Value {
+	args := exportArgs(call.Arguments)
+	if len(args) != 1 {
+		return throwErr(g.s.vm, errArgCount{Got: len(args)})
+	}
+	via := toVia(args)
+	if len(via) == 0 {
+		return throwErr(g.s.vm, errNoVia)
+	}
+	subjects := path.StartPath(g.s.qs).In(via...)
+	objects := path.StartPath(g.s.qs).Out(via...)
+	if leaves {
+		subjects, objects = objects, subjects
+	}
+	return g.s.vm.ToValue(&pathObject{
+		s:      g.s,
+		finals: true,
+		path:   subjects.Unique().Except(objects),
+	})
+}
+
+// componentTag is a tag used by Components to save ids of connected components.
+const componentTag = "component"
+
+// Components finds connected components of a graph formed by given predicates. Edge directions are ignored.
+// Each node in the results has an id of its component saved into "component" tag.
+// Nodes without such edges are not included.
+// Signature: (predicatePath)
+//
+// Arguments:
+//
+// * `predicatePath`: A predicate or a list of predicates to follow.
+//
+// Returns: Path object
+//
+// Example:
+//
+//	// javascript
+//	// Find groups of people that are connected by "<follows>"
+//	g.components("<follows>").all()
+func (g *graphObject) Components(call goja.FunctionCall) goja.Value {
+	args := exportArgs(call.Arguments)
+	if len(args) != 1 {
+		return throwErr(g.s.vm, errArgCount{Got: len(args)})
+	}
+	via := toVia(args)
+	if len(via) == 0 {
+		return throwErr(g.s.vm, errNoVia)
+	}
+	return g.s.vm.ToValue(&pathObject{
+		s:      g.s,
+		finals: true,
+		path:   path.StartPath(g.s.qs).ConnectedComponents(componentTag, via...),
+	})
+}
+
+const (
+	// pageRankDamping is a damping factor used by PageRank.
+	pageRankDamping = 0.85
+	// pageRankIterations is a default number of iterations of PageRank.
+	pageRankIterations = 20
+)
+
+// Pagerank computes PageRank scores of nodes connected by given predicates. Scores are computed
+// with a damping factor of 0.85 and sum up to 1.
+// Each node in the results has its score saved into "score" tag.
+// Signature: (predicatePath, [iterations])
+//
+// Arguments:
+//
+// * `predicatePath`: A predicate or a list of predicates to follow.
+// * `iterations` (Optional): A number of iterations to run, default is 20.
+//
+// Returns: Path object
+//
+// Example:
+//
+//	// javascript
+//	// Find the most popular people by following "<follows>" links
+//	g.pagerank("<follows>", 50).all()
+func (g *graphObject) Pagerank(call goja.FunctionCall) goja.Value {
+	args := exportArgs(call.Arguments)
+	if len(args) == 0 || len(args) > 2 {
+		return throwErr(g.s.vm, errArgCount{Got: len(args)})
+	}
+	via := toVia(args[:1])
+	if len(via) == 0 {
+		return throwErr(g.s.vm, errNoVia)
+	}
+	iters := pageRankIterations
+	if len(args) > 1 {
+		n, ok := toInt(args[1])
+		if !ok || n < 0 {
+			return throwErr(g.s.vm, fmt.Errorf("expected a number of iterations, got: %v", args[1]))
+		}
+		iters = n
+	}
+	scores, err := g.s.pageRank(via, iters)
+	if err != nil {
+		return throwErr(g.s.vm, err)
+	}
+	its := make([]iterator.Shape, 0, len(scores))
+	for _, sc := range scores {
+		it := iterator.NewSave(iterator.NewFixed(sc.node))
+		it.AddFixedTag("score", refs.PreFetched(quad.Float(sc.score)))
+		its = append(its, it)
+	}
+	var it iterator.Shape = iterator.NewNull()
+	if len(its) != 0 {
+		it = iterator.NewOr(its...)
+	}
+	return g.s.vm.ToValue(&pathObject{
+		s:      g.s,
+		finals: true,
+		path:   path.PathFromIterator(g.s.qs, it),
+	})
+}
+
+type nodeScore struct {
+	node  graph.Ref
+	score float64
+}
+
+// pageRank runs a given number of PageRank iterations on a subgraph that includes only given predicates.
+func (s *Session) pageRank(via []interface{}, iters int) ([]nodeScore, error) {
+	const srcTag, dstTag = "src", "dst"
+	p := path.StartPath(s.qs).Tag(srcTag).Out(via...).Tag(dstTag)
+	var (
+		nodes []graph.Ref
+		index = make(map[interface{}]int)
+		out   [][]int // outgoing edges for each node
+	)
+	add := func(r graph.Ref) int {
+		k := refs.ToKey(r)
+		i, ok := index[k]
+		if !ok {
+			i = len(nodes)
+			index[k] = i
+			nodes = append(nodes, r)
+			out = append(out, nil)
+		}
+		return i
+	}
+	seen := make(map[[2]int]struct{})
+	err := iterator.Iterate(s.context(), p.BuildIteratorOn(s.context(), s.qs)).Paths(true).TagEach(func(tags map[string]graph.Ref) error {
+		src, dst := add(tags[srcTag]), add(tags[dstTag])
+		// multiple quads may connect the same nodes
+		if _, ok := seen[[2]int{src, dst}]; !ok {
+			seen[[2]int{src, dst}] = struct{}{}
+			out[src] = append(out[src], dst)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	n := float64(len(nodes))
+	cur := make([]float64, len(nodes))
+	for i := range cur {
+		cur[i] = 1 / n
+	}
+	next := make([]float64, len(nodes))
+	for ; iters > 0; iters-- {
+		// rank of nodes without outgoing links is distributed evenly
+		var dangling float64
+		for i, edges := range out {
+			if len(edges) == 0 {
+				dangling += cur[i]
+			}
+		}
+		base := (1-pageRankDamping)/n + pageRankDamping*dangling/n
+		for i := range next {
+			next[i] = base
+		}
+		for i, edges := range out {
+			for _, j := range edges {
+				next[j] += pageRankDamping * cur[i] / float64(len(edges))
+			}
+		}
+		cur, next = next, cur
+	}
+	scores := make([]nodeScore, 0, len(nodes))
+	for i, r := range nodes {
+		scores = append(scores, nodeScore{node: r, score: cur[i]})
+	}
+	return scores, nil
+}
+
 // M is a shorthand for Morphism.
 func (g *graphObject) NewM() *pathObject {
 	return g.NewMorphism()
@@ -105,10 +921,12 @@ func (g *graphObject) NewMorphism() *pathObject {
 	}
 }
 
-// Emit adds data programmatically to the JSON result list. Can be any JSON type.
+// Emit adds data programmatically to the JSON result list. Can be any JSON type, including nested objects and arrays.
 //
 //	// javascript
 //	g.emit({name:"bob"}) // push {"name":"bob"} as a result
+//	// push {"id":"<bob>","friends":["<alice>","<charlie>","<dani>"]} as a result
+//	g.emit({id:"<bob>", friends:g.V("<bob>").in("<follows>").toArray()})
 func (g *graphObject) Emit(call goja.FunctionCall) goja.Value {
 	value := call.Argument(0)
 	if !goja.IsNull(value) && !goja.IsUndefined(value) {
@@ -185,7 +1003,7 @@ func cmpWildcard(vm *goja.Runtime, call goja.FunctionCall) goja.Value {
 
 func cmpRegexp(vm *goja.Runtime, call goja.FunctionCall) goja.Value {
 	args := exportArgs(call.Arguments)
-	if len(args) != 1 && len(args) != 2 {
+	if len(args) < 1 || len(args) > 3 {
 		return throwErr(vm, errArgCount2{Expected: 1, Got: len(args)})
 	}
 	v, err := toQuadValue(args[0])
@@ -200,6 +1018,14 @@ func cmpRegexp(vm *goja.Runtime, call goja.FunctionCall) goja.Value {
 		}
 		allowRefs = b
 	}
+	caseInsensitive := false
+	if len(args) > 2 {
+		b, ok := args[2].(bool)
+		if !ok {
+			return throwErr(vm, fmt.Errorf("expected bool as third argument"))
+		}
+		caseInsensitive = b
+	}
 	switch vt := v.(type) {
 	case quad.String:
 		if allowRefs {
@@ -230,13 +1056,33 @@ func cmpRegexp(vm *goja.Runtime, call goja.FunctionCall) goja.Value {
 	default:
 		return throwErr(vm, fmt.Errorf("regexp from non-string value: %T", v))
 	}
-	re, err := regexp.Compile(string(s))
+	if caseInsensitive {
+		s = "(?i)" + s
+	}
+	re, err := iterator.CompileRegexp(s)
 	if err != nil {
 		return throwErr(vm, err)
 	}
 	return vm.ToValue(valFilter{f: shape.Regexp{Re: re, Refs: refs}})
 }
 
+// toRegexp converts a JavaScript RegExp object or a string pattern to a Go regexp.
+func toRegexp(v goja.Value) (*regexp.Regexp, error) {
+	var pattern string
+	if o, ok := v.(*goja.Object); ok && o.Get("source") != nil {
+		// JS RegExp object
+		pattern = o.Get("source").String()
+		if ic := o.Get("ignoreCase"); ic != nil && ic.ToBoolean() {
+			pattern = "(?i)" + pattern
+		}
+	} else if s, ok := v.Export().(string); ok {
+		pattern = s
+	} else {
+		return nil, fmt.Errorf("expected regexp or string, got: %T", v.Export())
+	}
+	return iterator.CompileRegexp(pattern)
+}
+
 type valFilter struct {
 	f shape.ValueFilter
 }
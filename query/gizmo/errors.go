@@ -19,6 +19,7 @@ import "fmt"
 var (
 	errNoVia       = fmt.Errorf("expected predicate list")
 	errRegexpOnIRI = fmt.Errorf("regexps are not allowed on IRIs")
+	errCyclicGraph = fmt.Errorf("graph contains a cycle")
 )
 
 type errArgCount2 struct {
@@ -45,3 +46,11 @@ type errNotQuadValue struct {
 func (e errNotQuadValue) Error() string {
 	return fmt.Sprintf("not a quad.Value: %T", e.Val)
 }
+
+type errNoView struct {
+	Name string
+}
+
+func (e errNoView) Error() string {
+	return fmt.Sprintf("view is not defined: %q", e.Name)
+}
@@ -15,10 +15,17 @@
 package gizmo
 
 import (
+	"bytes"
+	"fmt"
+
 	"github.com/dop251/goja"
 
+	"github.com/cayleygraph/cayley/graph"
 	"github.com/cayleygraph/cayley/graph/iterator"
+	"github.com/cayleygraph/cayley/graph/refs"
+	"github.com/cayleygraph/cayley/query/shape"
 	"github.com/cayleygraph/quad"
+	"github.com/cayleygraph/quad/nquads"
 )
 
 const TopResultTag = "id"
@@ -29,7 +36,7 @@ func (p *pathObject) GetLimit(limit int) error {
 	it = iterator.Tag(it, TopResultTag)
 	p.s.limit = limit
 	p.s.count = 0
-	return p.s.runIterator(it)
+	return p.s.runIterator(it, p.selectTags)
 }
 
 // All executes the query and adds the results, with all tags, as a string-to-string (tag to node) map in the output set, one for each path that a traversal could take.
@@ -37,25 +44,30 @@ func (p *pathObject) All() error {
 	return p.GetLimit(p.s.limit)
 }
 
+// valueSelector returns a tag selector for results returned without tags. It is nil if no tags were selected.
+func (p *pathObject) valueSelector() tagSelector {
+	if len(p.sel) == 0 {
+		return nil
+	}
+	return p.selectTags
+}
+
 func (p *pathObject) toArray(call goja.FunctionCall, withTags bool) goja.Value {
 	args := exportArgs(call.Arguments)
-	if len(args) > 1 {
-		return throwErr(p.s.vm, errArgCount2{Expected: 1, Got: len(args)})
+	if len(args) > 2 {
+		return throwErr(p.s.vm, errArgCount2{Expected: 2, Got: len(args)})
 	}
-	limit := -1
-	if len(args) > 0 {
-		limit, _ = toInt(args[0])
-	}
-	it := p.buildIteratorTree()
+	skip, limit := pageArgs(args)
+	it := p.buildPageIterator(skip, limit)
 	it = iterator.Tag(it, TopResultTag)
 	var (
 		array interface{}
 		err   error
 	)
 	if !withTags {
-		array, err = p.s.runIteratorToArrayNoTags(it, limit)
+		array, err = p.s.runIteratorToArrayNoTags(it, limit, p.valueSelector())
 	} else {
-		array, err = p.s.runIteratorToArray(it, limit)
+		array, err = p.s.runIteratorToArray(it, limit, p.selectTags)
 	}
 	if err != nil {
 		return throwErr(p.s.vm, err)
@@ -63,7 +75,21 @@ func (p *pathObject) toArray(call goja.FunctionCall, withTags bool) goja.Value {
 	return p.s.vm.ToValue(array)
 }
 
+// pageArgs parses optional (limit) or (skip, limit) arguments. Limit is -1 if not set.
+func pageArgs(args []interface{}) (skip, limit int) {
+	limit = -1
+	switch len(args) {
+	case 1:
+		limit, _ = toInt(args[0])
+	case 2:
+		skip, _ = toInt(args[0])
+		limit, _ = toInt(args[1])
+	}
+	return skip, limit
+}
+
 // ToArray executes a query and returns the results at the end of the query path as an JS array.
+// Optional (limit) or (skip, limit) arguments can be passed to get a single page of results.
 //
 // Example:
 // 	// javascript
@@ -89,7 +115,7 @@ func (p *pathObject) toValue(withTags bool) (interface{}, error) {
 	it = iterator.Tag(it, TopResultTag)
 	const limit = 1
 	if !withTags {
-		array, err := p.s.runIteratorToArrayNoTags(it, limit)
+		array, err := p.s.runIteratorToArrayNoTags(it, limit, p.valueSelector())
 		if err != nil {
 			return nil, err
 		}
@@ -98,7 +124,7 @@ func (p *pathObject) toValue(withTags bool) (interface{}, error) {
 		}
 		return array[0], nil
 	}
-	array, err := p.s.runIteratorToArray(it, limit)
+	array, err := p.s.runIteratorToArray(it, limit, p.selectTags)
 	if err != nil {
 		return nil, err
 	}
@@ -124,10 +150,11 @@ func (p *pathObject) Map(call goja.FunctionCall) goja.Value {
 }
 
 // ForEach calls callback(data) for each result, where data is the tag-to-string map as in All case.
-// Signature: (callback) or (limit, callback)
+// Signature: (callback) or (limit, callback) or (skip, limit, callback)
 //
 // Arguments:
 //
+// * `skip` (Optional): An integer value on the number of paths to skip before processing.
 // * `limit` (Optional): An integer value on the first `limit` paths to process.
 // * `callback`: A javascript function of the form `function(data)`
 //
@@ -136,18 +163,15 @@ func (p *pathObject) Map(call goja.FunctionCall) goja.Value {
 //	// Simulate query.All().All()
 //	graph.V("<alice>").ForEach(function(d) { g.Emit(d) } )
 func (p *pathObject) ForEach(call goja.FunctionCall) goja.Value {
-	it := p.buildIteratorTree()
-	it = iterator.Tag(it, TopResultTag)
-	if n := len(call.Arguments); n != 1 && n != 2 {
+	if n := len(call.Arguments); n < 1 || n > 3 {
 		return throwErr(p.s.vm, errArgCount{Got: len(call.Arguments)})
 	}
 	callback := call.Argument(len(call.Arguments) - 1)
 	args := exportArgs(call.Arguments[:len(call.Arguments)-1])
-	limit := -1
-	if len(args) != 0 {
-		limit, _ = toInt(args[0])
-	}
-	err := p.s.runIteratorWithCallback(it, callback, call, limit)
+	skip, limit := pageArgs(args)
+	it := p.buildPageIterator(skip, limit)
+	it = iterator.Tag(it, TopResultTag)
+	err := p.s.runIteratorWithCallback(it, callback, call, limit, p.selectTags)
 	if err != nil {
 		return throwErr(p.s.vm, err)
 	}
@@ -167,6 +191,143 @@ func (p *pathObject) Count() (int64, error) {
 	return p.s.countResults(it)
 }
 
+// CountDistinct returns a number of distinct values of a given tag. Paths without the tag are not counted.
+//
+// Example:
+//	// javascript
+//	// Count all distinct statuses, namely, cool_person and smart_person
+//	var n = g.V().save("<status>", "status").countDistinct("status")
+//	g.emit(n)
+func (p *pathObject) CountDistinct(tag string) (int64, error) {
+	if p.path == nil {
+		return 0, nil
+	}
+	it := p.clonePath().UniqueBy(tag).BuildIteratorOn(p.s.ctx, p.s.qs)
+	var n int64
+	err := iterator.Iterate(p.s.context(), it).Paths(true).TagEach(func(tags map[string]graph.Ref) error {
+		if _, ok := tags[tag]; ok {
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+// SaveView executes the query and caches resulting nodes in the session under a given name.
+// The view can later be used as a starting point of other queries with `graph.view(name)`,
+// without running the query again. View is refreshed automatically after writes done through the session.
+//
+// Example:
+//	// javascript
+//	// Cache all cool people and then find who they follow
+//	g.V().has("<status>", "cool_person").saveView("cool")
+//	g.view("cool").out("<follows>").all()
+func (p *pathObject) SaveView(name string) error {
+	return p.s.saveView(name, p.path.Clone())
+}
+
+// ToNQuads executes the query and returns quads traversed by `path.outQuads` serialized as NQuads.
+// Each quad is written only once, even if it was traversed multiple times.
+//
+// Example:
+//	// javascript
+//	// Export all quads that describe status of cool people
+//	// Returns:
+//	//   <bob> <status> "cool_person" .
+//	//   <dani> <status> "cool_person" .
+//	//   <greg> <status> "cool_person" .
+//	g.emit(g.V().outQuads("<status>").is("cool_person").toNQuads())
+func (p *pathObject) ToNQuads() (string, error) {
+	it := p.buildIteratorTree()
+	var buf bytes.Buffer
+	w := nquads.NewWriter(&buf)
+	seen := make(map[interface{}]struct{})
+	err := iterator.Iterate(p.s.context(), it).Paths(true).TagEach(func(tags map[string]graph.Ref) error {
+		ref, ok := tags[quadsTag]
+		if !ok {
+			return nil
+		}
+		key := refs.ToKey(ref)
+		if _, ok = seen[key]; ok {
+			return nil
+		}
+		seen[key] = struct{}{}
+		q, err := p.s.qs.Quad(ref)
+		if err != nil {
+			return err
+		}
+		return w.WriteQuad(q)
+	})
+	if err != nil {
+		return "", err
+	}
+	if err = w.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Explain returns an optimized query plan as an indented text tree, without executing the query.
+// The plan is sent as a single result.
+//
+// Example:
+//	// javascript
+//	g.V("<alice>").out("<follows>").explain()
+func (p *pathObject) Explain() {
+	var s shape.Shape = shape.Null{}
+	if p.path != nil {
+		s, _ = shape.Optimize(p.s.context(), p.path.Shape(), p.s.qs)
+	}
+	p.s.send(nil, &Result{Val: shape.Describe(s)})
+}
+
+// ToMap executes the query and returns an object that maps each node at the end of the path to a value
+// saved into the given tag. Paths without the tag are skipped. If there are multiple values for the same node,
+// the last one is used, unless `all` is set. In that case each node is mapped to an array of all values.
+//
+// Example:
+//	// javascript
+//	// Returns: {"<bob>": "cool_person", "<dani>": "cool_person", "<greg>": "cool_person"}
+//	var status = g.V().save("<status>", "status").toMap("status")
+//	// Returns: {"<greg>": ["cool_person", "smart_person"], ...}
+//	var statuses = g.V().save("<status>", "status").toMap("status", true)
+func (p *pathObject) ToMap(tag string, all bool) (map[string]interface{}, error) {
+	it := p.buildIteratorTree()
+	it = iterator.Tag(it, TopResultTag)
+	out := make(map[string]interface{})
+	err := iterator.Iterate(p.s.context(), it).Paths(true).TagEach(func(tags map[string]graph.Ref) error {
+		id, ok := tags[TopResultTag]
+		if !ok {
+			return nil
+		}
+		ref, ok := tags[tag]
+		if !ok {
+			return nil
+		}
+		k, err := p.s.qs.NameOf(id)
+		if err != nil {
+			return err
+		}
+		v, err := p.s.qs.NameOf(ref)
+		if err != nil {
+			return err
+		}
+		key := fmt.Sprint(p.s.quadValueToNative(k))
+		val := p.s.quadValueToNative(v)
+		if !all {
+			out[key] = val
+			return nil
+		}
+		arr, _ := out[key].([]interface{})
+		out[key] = append(arr, val)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Backwards compatibility
 func (p *pathObject) CapitalizedGetLimit(limit int) error {
 	return p.GetLimit(limit)
@@ -15,12 +15,15 @@
 package gizmo
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"unicode"
 	"unicode/utf8"
 
@@ -29,6 +32,8 @@ import (
 	"github.com/cayleygraph/cayley/graph"
 	"github.com/cayleygraph/cayley/graph/iterator"
 	"github.com/cayleygraph/cayley/query"
+	"github.com/cayleygraph/cayley/query/path"
+	"github.com/cayleygraph/cayley/query/shape"
 	"github.com/cayleygraph/cayley/schema"
 	"github.com/cayleygraph/quad"
 	"github.com/cayleygraph/quad/jsonld"
@@ -97,6 +102,9 @@ type Session struct {
 	limit int
 	count int
 
+	views map[string]*view
+	gen   uint64 // incremented on each write done through the session writer; see TrackWrites
+
 	err error
 }
 
@@ -135,9 +143,37 @@ func (s *Session) quadValueToNative(v quad.Value) interface{} {
 	return out
 }
 
+// valueToNative converts quad values nested in emitted objects and arrays to native values.
+func (s *Session) valueToNative(v interface{}) interface{} {
+	switch v := v.(type) {
+	case quad.Value:
+		return s.quadValueToNative(v)
+	case []interface{}:
+		out := make([]interface{}, 0, len(v))
+		for _, o := range v {
+			out = append(out, s.valueToNative(o))
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, o := range v {
+			out[k] = s.valueToNative(o)
+		}
+		return out
+	}
+	return v
+}
+
+// tagSelector filters tags of each result before it is emitted.
+type tagSelector func(tags map[string]graph.Ref) map[string]graph.Ref
+
 func (s *Session) tagsToValueMap(m map[string]graph.Ref) (map[string]interface{}, error) {
 	outputMap := make(map[string]interface{})
 	for k, v := range m {
+		if k == quadsTag {
+			// quad references are not nodes
+			continue
+		}
 		nv, err := s.qs.NameOf(v)
 		if err != nil {
 			return nil, err
@@ -151,12 +187,12 @@ func (s *Session) tagsToValueMap(m map[string]graph.Ref) (map[string]interface{}
 	}
 	return outputMap, nil
 }
-func (s *Session) runIteratorToArray(it iterator.Shape, limit int) ([]map[string]interface{}, error) {
+func (s *Session) runIteratorToArray(it iterator.Shape, limit int, sel tagSelector) ([]map[string]interface{}, error) {
 	ctx := s.context()
 
 	output := make([]map[string]interface{}, 0)
 	err := iterator.Iterate(ctx, it).Limit(limit).TagEach(func(tags map[string]graph.Ref) error {
-		tm, err := s.tagsToValueMap(tags)
+		tm, err := s.tagsToValueMap(sel(tags))
 		if err != nil {
 			return err
 		}
@@ -171,23 +207,40 @@ func (s *Session) runIteratorToArray(it iterator.Shape, limit int) ([]map[string
 	return output, nil
 }
 
-func (s *Session) runIteratorToArrayNoTags(it iterator.Shape, limit int) ([]interface{}, error) {
+// runIteratorToArrayNoTags returns an array of results of an iterator, which must be tagged with TopResultTag.
+// If sel is set, results that have none of the selected tags are skipped, the same way as in runIterator.
+func (s *Session) runIteratorToArrayNoTags(it iterator.Shape, limit int, sel tagSelector) ([]interface{}, error) {
 	ctx := s.context()
 
 	output := make([]interface{}, 0)
-	err := iterator.Iterate(ctx, it).Paths(false).Limit(limit).EachValue(s.qs, func(v quad.Value) error {
+	add := func(v quad.Value) error {
 		if o := s.quadValueToNative(v); o != nil {
 			output = append(output, o)
 		}
 		return nil
-	})
+	}
+	var err error
+	if sel == nil {
+		err = iterator.Iterate(ctx, it).Paths(false).Limit(limit).EachValue(s.qs, add)
+	} else {
+		err = iterator.Iterate(ctx, it).Paths(false).Limit(limit).TagEach(func(tags map[string]graph.Ref) error {
+			if len(sel(tags)) == 0 {
+				return nil
+			}
+			v, err := s.qs.NameOf(tags[TopResultTag])
+			if err != nil {
+				return err
+			}
+			return add(v)
+		})
+	}
 	if err != nil {
 		return nil, err
 	}
 	return output, nil
 }
 
-func (s *Session) runIteratorWithCallback(it iterator.Shape, callback goja.Value, this goja.FunctionCall, limit int) error {
+func (s *Session) runIteratorWithCallback(it iterator.Shape, callback goja.Value, this goja.FunctionCall, limit int, sel tagSelector) error {
 	fnc, ok := goja.AssertFunction(callback)
 	if !ok {
 		return fmt.Errorf("expected js callback function")
@@ -195,7 +248,7 @@ func (s *Session) runIteratorWithCallback(it iterator.Shape, callback goja.Value
 	ctx, cancel := context.WithCancel(s.context())
 	defer cancel()
 	return iterator.Iterate(ctx, it).Paths(true).Limit(limit).TagEach(func(tags map[string]graph.Ref) error {
-		tm, err := s.tagsToValueMap(tags)
+		tm, err := s.tagsToValueMap(sel(tags))
 		if err != nil || tm == nil {
 			return err
 		}
@@ -226,11 +279,15 @@ func (s *Session) send(ctx context.Context, r *Result) bool {
 	return s.limit <= 0 || s.count < s.limit
 }
 
-func (s *Session) runIterator(it iterator.Shape) error {
+func (s *Session) runIterator(it iterator.Shape, sel tagSelector) error {
 	ctx, cancel := context.WithCancel(s.context())
 	defer cancel()
 	stop := false
 	err := iterator.Iterate(ctx, it).Paths(true).TagEach(func(tags map[string]graph.Ref) error {
+		tags = sel(tags)
+		if len(tags) == 0 {
+			return nil
+		}
 		if !s.send(ctx, &Result{Tags: tags}) {
 			cancel()
 			stop = true
@@ -247,6 +304,94 @@ func (s *Session) countResults(it iterator.Shape) (int64, error) {
 	return iterator.Iterate(s.context(), it).Paths(true).Count()
 }
 
+// view is a named set of nodes cached in the session. See pathObject.SaveView.
+type view struct {
+	path  *path.Path  // query used to populate the view
+	nodes []graph.Ref // cached results
+	gen   uint64      // write generation of the session at the moment the view was populated
+}
+
+// refresh runs the view query again and caches the results.
+func (s *Session) refreshView(v *view) error {
+	gen := atomic.LoadUint64(&s.gen)
+	it := v.path.BuildIteratorOn(s.context(), s.qs)
+	nodes, err := iterator.Iterate(s.context(), it).Paths(false).All()
+	if err != nil {
+		return err
+	}
+	v.nodes, v.gen = nodes, gen
+	return nil
+}
+
+// saveView runs the query and caches its results as a named view.
+func (s *Session) saveView(name string, p *path.Path) error {
+	v := &view{path: p}
+	if err := s.refreshView(v); err != nil {
+		return err
+	}
+	if s.views == nil {
+		s.views = make(map[string]*view)
+	}
+	s.views[name] = v
+	return nil
+}
+
+// loadView returns cached nodes of a named view. View will be refreshed if there were writes
+// through the session writer since it was populated.
+func (s *Session) loadView(name string) ([]graph.Ref, error) {
+	v, ok := s.views[name]
+	if !ok {
+		return nil, errNoView{Name: name}
+	}
+	if v.gen != atomic.LoadUint64(&s.gen) {
+		if err := s.refreshView(v); err != nil {
+			return nil, err
+		}
+	}
+	return v.nodes, nil
+}
+
+// TrackWrites wraps a quad writer so that each write done through it invalidates views cached in the session.
+// Writes done to the quad store directly are not tracked.
+func (s *Session) TrackWrites(w graph.QuadWriter) graph.QuadWriter {
+	return &sessionWriter{QuadWriter: w, s: s}
+}
+
+// sessionWriter is a quad writer that increments the write generation of a session.
+type sessionWriter struct {
+	graph.QuadWriter
+	s *Session
+}
+
+func (w *sessionWriter) written() {
+	atomic.AddUint64(&w.s.gen, 1)
+}
+
+func (w *sessionWriter) AddQuad(q quad.Quad) error {
+	defer w.written()
+	return w.QuadWriter.AddQuad(q)
+}
+
+func (w *sessionWriter) AddQuadSet(quads []quad.Quad) error {
+	defer w.written()
+	return w.QuadWriter.AddQuadSet(quads)
+}
+
+func (w *sessionWriter) RemoveQuad(q quad.Quad) error {
+	defer w.written()
+	return w.QuadWriter.RemoveQuad(q)
+}
+
+func (w *sessionWriter) ApplyTransaction(tx *graph.Transaction) error {
+	defer w.written()
+	return w.QuadWriter.ApplyTransaction(tx)
+}
+
+func (w *sessionWriter) RemoveNode(v quad.Value) error {
+	defer w.written()
+	return w.QuadWriter.RemoveNode(v)
+}
+
 type Result struct {
 	Meta bool
 	Val  interface{}
@@ -296,6 +441,7 @@ func (s *Session) Execute(ctx context.Context, qu string, opt query.Options) (qu
 	s.limit = opt.Limit
 	s.count = 0
 	ctx, cancel := context.WithCancel(context.Background())
+	ctx = shape.WithStableOrder(ctx, opt.Stable)
 	s.ctx = ctx
 	s.col = opt.Collation
 	return &results{
@@ -349,6 +495,8 @@ func (it *results) Next(ctx context.Context) bool {
 		it.cur = r
 		return true
 	case err := <-it.errc:
+		// script finished; interrupting the VM on Close would abort the next script executed by the session
+		it.running = false
 		if err != nil {
 			it.err = err
 		}
@@ -381,7 +529,7 @@ func (it *results) jsonResult() interface{} {
 		return nil
 	}
 	if data.Val != nil {
-		return data.Val
+		return it.s.valueToNative(data.Val)
 	}
 	obj := make(map[string]interface{})
 	tags := data.Tags
@@ -391,6 +539,9 @@ func (it *results) jsonResult() interface{} {
 	}
 	sort.Strings(tagKeys)
 	for _, k := range tagKeys {
+		if k == quadsTag {
+			continue
+		}
 		name, err := it.s.qs.NameOf(tags[k])
 		if err != nil {
 			it.err = err
@@ -430,7 +581,7 @@ func (it *results) replResult() interface{} {
 		}
 		sort.Strings(tagKeys)
 		for _, k := range tagKeys {
-			if k == "$_" {
+			if k == "$_" || k == quadsTag {
 				continue
 			}
 			knv, err := it.s.qs.NameOf(tags[k])
@@ -447,16 +598,35 @@ func (it *results) replResult() interface{} {
 				out += fmt.Sprintf("%s : %s\n", k, v)
 			}
 		case map[string]interface{}:
-			for k, v := range export {
-				out += fmt.Sprintf("%s : %v\n", k, v)
+			keys := make([]string, 0, len(export))
+			for k := range export {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				out += fmt.Sprintf("%s : %s\n", k, it.replValue(export[k]))
 			}
 		default:
-			out += fmt.Sprintf("%s\n", data.Val)
+			out += fmt.Sprintf("%s\n", it.replValue(data.Val))
 		}
 	}
 	return out
 }
 
+// replValue formats an emitted value for the REPL. Nested objects and arrays are printed as JSON.
+func (it *results) replValue(v interface{}) string {
+	switch v.(type) {
+	case []interface{}, map[string]interface{}:
+		buf := new(bytes.Buffer)
+		enc := json.NewEncoder(buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(it.s.valueToNative(v)); err == nil {
+			return strings.TrimSuffix(buf.String(), "\n")
+		}
+	}
+	return fmt.Sprint(v)
+}
+
 func (it *results) Err() error {
 	return it.err
 }
@@ -19,8 +19,11 @@ import (
 	"fmt"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 
+	"github.com/dop251/goja"
+
 	"github.com/cayleygraph/cayley/graph"
 	"github.com/cayleygraph/cayley/graph/graphtest/testutil"
 	_ "github.com/cayleygraph/cayley/graph/memstore"
@@ -184,6 +187,20 @@ var testQueries = []struct {
 		`,
 		err: true,
 	},
+	{
+		message: "use .in() with .filter(case-insensitive regex)",
+		query: `
+			g.V("<bob>").in("<follows>").filter(regex("ALICE", true, true)).all()
+		`,
+		expect: []string{"<alice>"},
+	},
+	{
+		message: "use .in() with .filter(case-sensitive regex)",
+		query: `
+			g.V("<bob>").in("<follows>").filter(regex("ALICE", true)).all()
+		`,
+		expect: nil,
+	},
 	{
 		message: "use .in() with .filter(regex,gt)",
 		query: `
@@ -191,6 +208,429 @@ var testQueries = []struct {
 		`,
 		expect: []string{"<charlie>"},
 	},
+	{
+		message: "jaccard similarity of overlapping nodes",
+		query: `
+			g.emit(g.jaccard("<charlie>", "<dani>", "<follows>"))
+		`,
+		expect: []string{quad.Float(1.0 / 3).String()},
+	},
+	{
+		message: "jaccard similarity of nodes with the same neighbors",
+		query: `
+			g.emit(g.jaccard("<bob>", "<emily>", "<follows>"))
+		`,
+		expect: []string{quad.Float(1).String()},
+	},
+	{
+		message: "jaccard similarity of nodes without neighbors",
+		query: `
+			g.emit(g.jaccard("<greg>", "cool_person", "<follows>"))
+		`,
+		expect: []string{quad.Float(0).String()},
+	},
+	{
+		message: "export quads as nquads",
+		query: `
+			g.emit(g.V("<bob>").outQuads("<status>").toNQuads())
+		`,
+		expect: []string{`<bob> <status> "cool_person" .` + "\n"},
+	},
+	{
+		message: "export quads as nquads with escaping",
+		data: []quad.Quad{
+			quad.MakeIRI("alice", "name", "alice", "people"),
+			{
+				Subject:   quad.IRI("alice"),
+				Predicate: quad.IRI("says"),
+				Object:    quad.String("\"hello\"\n"),
+				Label:     quad.IRI("people"),
+			},
+		},
+		query: `
+			g.emit(g.V("<alice>").outQuads("<says>").toNQuads())
+		`,
+		expect: []string{`<alice> <says> "\"hello\"\n" <people> .` + "\n"},
+	},
+	{
+		message: "export quads traversed multiple times",
+		query: `
+			g.emit(g.V("<alice>", "<charlie>").out("<follows>").is("<bob>").outQuads("<status>").toNQuads())
+		`,
+		expect: []string{`<bob> <status> "cool_person" .` + "\n"},
+	},
+	{
+		message: "do not show quad tags in results",
+		query: `
+			g.V("<bob>").outQuads("<status>").all()
+		`,
+		expect: []string{"cool_person"},
+	},
+	{
+		message: "show incoming edges (sources)",
+		query: `
+			g.V("<bob>").inEdges().all()
+		`,
+		tag:    "source",
+		expect: []string{"<alice>", "<charlie>", "<dani>"},
+	},
+	{
+		message: "show incoming edges (predicates)",
+		query: `
+			g.V("<bob>").inEdges().all()
+		`,
+		tag:    "predicate",
+		expect: []string{"<follows>", "<follows>", "<follows>"},
+	},
+	{
+		message: "show incoming edges as pairs",
+		query: `
+			g.V("<bob>").inEdges().forEach(function(d) {
+				g.emit(d.source + " " + d.predicate)
+			})
+		`,
+		expect: []string{"<alice> <follows>", "<charlie> <follows>", "<dani> <follows>"},
+	},
+	{
+		message: "check for cycles in acyclic graph",
+		query: `
+			g.emit(g.hasCycle("<alice>", "<follows>"))
+		`,
+		expect: []string{"false"},
+	},
+	{
+		message: "check for cycles in cyclic graph",
+		data: []quad.Quad{
+			quad.MakeIRI("a", "parent", "b", ""),
+			quad.MakeIRI("b", "parent", "c", ""),
+			quad.MakeIRI("c", "parent", "d", ""),
+			quad.MakeIRI("d", "parent", "b", ""),
+		},
+		query: `
+			g.emit(g.hasCycle("<a>", "<parent>"))
+			g.emit(g.hasCycle("<d>", "<parent>"))
+		`,
+		expect: []string{"true", "true"},
+	},
+	{
+		message: "check for cycles in a diamond graph",
+		data: []quad.Quad{
+			quad.MakeIRI("a", "parent", "b", ""),
+			quad.MakeIRI("a", "parent", "c", ""),
+			quad.MakeIRI("b", "parent", "d", ""),
+			quad.MakeIRI("c", "parent", "d", ""),
+		},
+		query: `
+			g.emit(g.hasCycle("<a>", "<parent>"))
+		`,
+		expect: []string{"false"},
+	},
+	{
+		message: "find transitive reduction",
+		data: []quad.Quad{
+			quad.MakeIRI("a", "parent", "b", ""),
+			quad.MakeIRI("b", "parent", "c", ""),
+			quad.MakeIRI("a", "parent", "c", ""),
+			quad.MakeIRI("c", "parent", "d", ""),
+			quad.MakeIRI("a", "parent", "d", ""),
+			quad.MakeIRI("a", "knows", "c", ""),
+		},
+		query: `
+			g.emit(g.transitiveReduction("<parent>").toNQuads())
+		`,
+		expect: []string{
+			"<a> <parent> <b> .\n" +
+				"<b> <parent> <c> .\n" +
+				"<c> <parent> <d> .\n",
+		},
+	},
+	{
+		message: "find transitive reduction of a cyclic graph",
+		data: []quad.Quad{
+			quad.MakeIRI("a", "parent", "b", ""),
+			quad.MakeIRI("b", "parent", "a", ""),
+		},
+		query: `
+			g.transitiveReduction("<parent>").all()
+		`,
+		err: true,
+	},
+	{
+		message: "compute degree histogram",
+		query: `
+			var hist = g.degreeHistogram("<follows>")
+			for (var d in hist) {
+				g.emit(d + " " + hist[d])
+			}
+		`,
+		expect: []string{"1 4", "2 2"},
+	},
+	{
+		message: "find top nodes by degree",
+		query: `
+			g.topByDegree("<follows>", 2).forEach(function(d) {
+				g.emit(d.id + " " + d.degree)
+			})
+		`,
+		expect: []string{"<bob> 3", "<fred> 2"},
+	},
+	{
+		message: "find top nodes by degree with ties",
+		query: `
+			g.topByDegree("<follows>", 10).forEach(function(d) {
+				g.emit(d.id + " " + d.degree)
+			})
+		`,
+		expect: []string{"<bob> 3", "<fred> 2", "<greg> 2", "<dani> 1"},
+	},
+	{
+		message: "find roots",
+		query: `
+			g.roots("<follows>").all()
+		`,
+		expect: []string{"<alice>", "<charlie>", "<emily>"},
+	},
+	{
+		message: "find leaves",
+		query: `
+			g.leaves("<follows>").all()
+		`,
+		expect: []string{"<greg>"},
+	},
+	{
+		message: "find connected components",
+		query: `
+			var groups = {}
+			g.components("<follows>").forEach(function(d) {
+				var c = d.component
+				groups[c] = (groups[c] || []).concat(d.id)
+			})
+			for (var c in groups) {
+				g.emit(groups[c].sort().join(" "))
+			}
+		`,
+		expect: []string{"<alice> <bob> <charlie> <dani> <emily> <fred> <greg>"},
+	},
+	{
+		message: "find multiple connected components",
+		data: []quad.Quad{
+			quad.MakeIRI("a", "parent", "b", ""),
+			quad.MakeIRI("c", "parent", "b", ""),
+			quad.MakeIRI("d", "parent", "e", ""),
+			quad.MakeIRI("e", "knows", "c", ""),
+		},
+		query: `
+			var groups = {}
+			g.components("<parent>").forEach(function(d) {
+				var c = d.component
+				groups[c] = (groups[c] || []).concat(d.id)
+			})
+			for (var c in groups) {
+				g.emit(groups[c].sort().join(" "))
+			}
+		`,
+		expect: []string{"<a> <b> <c>", "<d> <e>"},
+	},
+	{
+		message: "compute pagerank",
+		data: []quad.Quad{
+			quad.MakeIRI("a", "links", "b", ""),
+			quad.MakeIRI("b", "links", "a", ""),
+			quad.MakeIRI("c", "links", "a", ""),
+			quad.MakeIRI("c", "knows", "b", ""),
+		},
+		query: `
+			g.pagerank("<links>", 100).forEach(function(d) {
+				g.emit(d.id + " " + d.score.toFixed(3))
+			})
+		`,
+		expect: []string{"<a> 0.486", "<b> 0.464", "<c> 0.050"},
+	},
+	{
+		message: "compute pagerank with dangling nodes",
+		data: []quad.Quad{
+			quad.MakeIRI("a", "links", "b", ""),
+		},
+		query: `
+			g.pagerank("<links>", 100).forEach(function(d) {
+				g.emit(d.id + " " + d.score.toFixed(3))
+			})
+		`,
+		expect: []string{"<a> 0.351", "<b> 0.649"},
+	},
+	{
+		message: "save and reuse a view",
+		query: `
+			g.V().has("<status>", "cool_person").saveView("cool")
+			g.view("cool").out("<follows>").all()
+			g.view("cool").in("<follows>").all()
+		`,
+		expect: []string{
+			"<fred>", "<bob>", "<greg>",
+			"<alice>", "<charlie>", "<dani>", "<charlie>", "<dani>", "<fred>",
+		},
+	},
+	{
+		message: "use an empty view",
+		query: `
+			g.V("<alice>").in("<follows>").saveView("empty")
+			g.view("empty").all()
+		`,
+		expect: nil,
+	},
+	{
+		message: "diff two views",
+		query: `
+			g.V("<bob>", "<charlie>").out("<follows>").saveView("a")
+			g.V("<dani>").out("<follows>").saveView("b")
+			g.diffViews("a", "b").all()
+		`,
+		expect: []string{"<fred>", "<dani>"},
+	},
+	{
+		message: "diff two views in both directions",
+		query: `
+			g.V("<bob>", "<charlie>").out("<follows>").saveView("a")
+			g.V("<dani>").out("<follows>").saveView("b")
+			g.diffViews("a", "b", true).all()
+		`,
+		expect: []string{"<fred>", "<dani>", "<greg>"},
+	},
+	{
+		message: "diff with an empty view",
+		query: `
+			g.V("<bob>").out("<follows>").saveView("a")
+			g.V("<alice>").in("<follows>").saveView("empty")
+			g.diffViews("a", "empty").all()
+			g.diffViews("empty", "a").all()
+		`,
+		expect: []string{"<fred>"},
+	},
+	{
+		message: "use .hasLiteral()",
+		query: `
+			g.V().hasLiteral("<status>").all()
+		`,
+		expect: []string{"<bob>", "<dani>", "<emily>", "<greg>", "<greg>"},
+	},
+	{
+		message: "use .hasLiteral() with IRI objects",
+		query: `
+			g.V().hasLiteral("<follows>").all()
+		`,
+		expect: nil,
+	},
+	{
+		message: "use .toMap()",
+		query: `
+			var m = g.V().save("<status>", "status").toMap("status")
+			for (var k in m) {
+				g.emit(k + " " + m[k])
+			}
+		`,
+		data: []quad.Quad{
+			quad.Make(quad.IRI("bob"), quad.IRI("status"), quad.String("cool_person"), nil),
+			quad.Make(quad.IRI("dani"), quad.IRI("status"), quad.String("cool_person"), nil),
+			quad.Make(quad.IRI("greg"), quad.IRI("status"), quad.String("smart_person"), nil),
+			quad.Make(quad.IRI("greg"), quad.IRI("follows"), quad.IRI("bob"), nil),
+		},
+		expect: []string{"<bob> cool_person", "<dani> cool_person", "<greg> smart_person"},
+	},
+	{
+		message: "use .toMap() with all values",
+		query: `
+			var m = g.V().save("<status>", "status").toMap("status", true)
+			for (var k in m) {
+				g.emit(k + " " + m[k].sort().join(","))
+			}
+		`,
+		expect: []string{
+			"<bob> cool_person",
+			"<dani> cool_person",
+			"<emily> smart_person",
+			"<greg> cool_person,smart_person",
+		},
+	},
+	{
+		message: "use an undefined view",
+		query: `
+			g.view("none").all()
+		`,
+		err: true,
+	},
+	{
+		message: "use .distinct() on a tag",
+		query: `
+			g.V().save("<status>", "status").distinct("status").all()
+		`,
+		tag:    "status",
+		expect: []string{"cool_person", "smart_person"},
+	},
+	{
+		message: "use .countDistinct() on a tag",
+		query: `
+			g.emit(g.V().save("<status>", "status").countDistinct("status"))
+		`,
+		expect: []string{"2"},
+	},
+	{
+		message: "use .countDistinct() on an optional tag",
+		query: `
+			g.emit(g.V().saveOpt("<status>", "status").countDistinct("status"))
+		`,
+		expect: []string{"2"},
+	},
+	{
+		message: "use .countDistinct() on a missing tag",
+		query: `
+			g.emit(g.V().countDistinct("status"))
+		`,
+		expect: []string{"0"},
+	},
+	{
+		message: "use .distinct() without tags",
+		query: `
+			g.V().distinct().all()
+		`,
+		err: true,
+	},
+	{
+		message: "use .exceptBy() on a tag",
+		query: `
+			g.V().save("<status>", "status").exceptBy("status", g.V("cool_person")).all()
+		`,
+		tag:    "status",
+		expect: []string{"smart_person", "smart_person"},
+	},
+	{
+		message: "use .exceptBy() with a missing tag",
+		query: `
+			g.V("<bob>", "<dani>").exceptBy("status", g.V("cool_person")).all()
+		`,
+		expect: []string{"<bob>", "<dani>"},
+	},
+	{
+		message: "use .outMatching() with a regexp",
+		query: `
+			g.V("<dani>").outMatching(/fol.*/).all()
+		`,
+		expect: []string{"<bob>", "<greg>"},
+	},
+	{
+		message: "use .outMatching() with a string and a tag",
+		query: `
+			g.V("<dani>").outMatching("^st", "pred").all()
+		`,
+		tag:    "pred",
+		expect: []string{"<status>"},
+	},
+	{
+		message: "use .inMatching() with a regexp",
+		query: `
+			g.V("<bob>").inMatching(/FOL.*/i).all()
+		`,
+		expect: []string{"<alice>", "<charlie>", "<dani>"},
+	},
 	{
 		message: "filter with a wrong type",
 		query: `
@@ -213,6 +653,23 @@ var testQueries = []struct {
 		tag:    "pred",
 		expect: []string{"<follows>", "<follows>", "<follows>"},
 	},
+	{
+		message: "use .both() with side tag",
+		query: `
+			g.V("<fred>").both("<follows>", {neighborTag: "who", sideTag: "side"}).all()
+		`,
+		tag:    "side",
+		expect: []string{"in", "in", "out"},
+	},
+	{
+		message: "use .both() to distinguish inbound and outbound follows",
+		query: `
+			g.V("<fred>").both("<follows>", {neighborTag: "who", sideTag: "side"}).forEach(function(d) {
+				g.emit(d.side + " " + d.who)
+			})
+		`,
+		expect: []string{"in <bob>", "in <emily>", "out <greg>"},
+	},
 	{
 		message: "use .tag()-.is()-.back()",
 		query: `
@@ -343,6 +800,20 @@ var testQueries = []struct {
 		`,
 		expect: []string{"cool_person"},
 	},
+	{
+		message: "show HasR with filter",
+		query: `
+				g.V().hasR("<status>", gt("<f>")).all()
+		`,
+		expect: []string{"cool_person", "smart_person"},
+	},
+	{
+		message: "show HasR with multiple filters",
+		query: `
+				g.V().hasR("<status>", gt("<c>"), lt("<e>")).all()
+		`,
+		expect: []string{"cool_person"},
+	},
 	{
 		message: "show a double Has",
 		query: `
@@ -395,6 +866,13 @@ var testQueries = []struct {
 		`,
 		expect: []string{"6"},
 	},
+	{
+		message: "use Count value on empty set",
+		query: `
+				g.emit(g.V("<alice>").in("<follows>").count()+1)
+		`,
+		expect: []string{"1"},
+	},
 
 	// Tag tests.
 	{
@@ -405,6 +883,62 @@ var testQueries = []struct {
 		tag:    "somecool",
 		expect: []string{"cool_person", "cool_person", "cool_person", "smart_person", "smart_person"},
 	},
+	{
+		message: "select a subset of tags",
+		query: `
+			g.V("<bob>").as("name").in("<follows>").save("<status>", "status").select(["name"]).all()
+		`,
+		tag:    "name",
+		expect: []string{"<bob>"},
+	},
+	{
+		message: "select drops other tags",
+		query: `
+			g.V("<bob>").as("name").in("<follows>").save("<status>", "status").select(["name"]).all()
+		`,
+		tag:    "status",
+		expect: nil,
+	},
+	{
+		message: "select with forEach",
+		query: `
+			g.V("<bob>", "<dani>").save("<status>", "status").select(["status"]).forEach(function(d) {
+				g.emit(d.id === undefined ? d.status : "id")
+			})
+		`,
+		expect: []string{"cool_person", "cool_person"},
+	},
+	{
+		message: "select skips results without selected tags",
+		query: `
+			g.V("<alice>", "<bob>").saveOpt("<status>", "status").select("status").all()
+		`,
+		tag:    "status",
+		expect: []string{"cool_person"},
+	},
+	{
+		message: "select skips results without selected tags in toArray",
+		query: `
+			g.emit(g.V("<alice>", "<bob>").saveOpt("<status>", "status").select("status").toArray().join(","))
+		`,
+		expect: []string{"<bob>"},
+	},
+	{
+		message: "select skips results without selected tags in tagArray",
+		query: `
+			var arr = g.V("<alice>", "<bob>").saveOpt("<status>", "status").select("status").tagArray()
+			g.emit(arr.map(function(d) { return d.status }).join(","))
+		`,
+		expect: []string{"cool_person"},
+	},
+	{
+		message: "select with tagArray",
+		query: `
+			var arr = g.V("<bob>").as("name").out("<follows>").as("target").select("target").tagArray()
+			g.emit(Object.keys(arr[0]).join(","))
+		`,
+		expect: []string{"target"},
+	},
 	{
 		message: "show a simple save optional",
 		query: `
@@ -421,6 +955,13 @@ var testQueries = []struct {
 		tag:    "<status>",
 		expect: []string{"cool_person", "cool_person", "cool_person", "smart_person", "smart_person"},
 	},
+	{
+		message: "save path no tag",
+		query: `
+			g.V().save(g.M().out("<status>")).all()
+		`,
+		err: true,
+	},
 	{
 		message: "show a simple saveR",
 		query: `
@@ -562,6 +1103,33 @@ var testQueries = []struct {
 		`,
 		expect: []string{"<alice>", "<dani>"},
 	},
+	{
+		message: "show ToArray with skip and limit",
+		query: `
+			arr = g.V("<bob>").in("<follows>").toArray(1, 1)
+			for (i in arr) g.emit(arr[i]);
+			arr = g.V("<bob>").in("<follows>").toArray(2, 2)
+			for (i in arr) g.emit(arr[i]);
+		`,
+		expect: []string{"<dani>", "<charlie>"},
+	},
+	{
+		message: "show ForEach with skip and limit",
+		query: `
+			var p = g.V("<bob>").in("<follows>")
+			for (var i = 0; i < 3; i += 2) {
+				p.forEach(i, 2, function(o){g.emit(o.id)});
+			}
+		`,
+		expect: []string{"<alice>", "<dani>", "<charlie>"},
+	},
+	{
+		message: "show ForEach with skip and no limit",
+		query: `
+			g.V("<bob>").in("<follows>").forEach(1, -1, function(o){g.emit(o.id)});
+		`,
+		expect: []string{"<dani>", "<charlie>"},
+	},
 	{
 		message: "clone paths",
 		query: `
@@ -645,6 +1213,47 @@ var testQueries = []struct {
 		file:   multiGraphTestFile,
 		expect: []string{"<fred>"},
 	},
+	{
+		message: "use quadLabels",
+		query: `
+			var labels = g.quadLabels("<emily>", "<status>", "smart_person")
+			for (i in labels) g.emit(labels[i])
+		`,
+		file:   multiGraphTestFile,
+		expect: []string{"<smart_graph>"},
+	},
+	{
+		message: "use quadLabels on a quad without a label",
+		query: `
+			g.emit(g.quadLabels("<bob>", "<status>", "cool_person").length)
+		`,
+		file:   multiGraphTestFile,
+		expect: []string{"0"},
+	},
+	{
+		message: "use quadLabels on a missing quad",
+		query: `
+			g.emit(g.quadLabels("<emily>", "<status>", "cool_person").length)
+		`,
+		file:   multiGraphTestFile,
+		expect: []string{"0"},
+	},
+	{
+		message: "issue #758. Verify follow respects label context",
+		query: `
+			g.V("<greg>").labelContext("<smart_graph>").follow(g.M().out("<status>")).all()
+		`,
+		file:   multiGraphTestFile,
+		expect: []string{"smart_person"},
+	},
+	{
+		message: "issue #758. Verify followR respects label context",
+		query: `
+			g.V("smart_person").labelContext("<other_graph>").followR(g.M().out("<status>")).all()
+		`,
+		file:   multiGraphTestFile,
+		expect: []string{"<fred>"},
+	},
 	{
 		message: "use order",
 		query: `
@@ -825,6 +1434,66 @@ func TestIssue160(t *testing.T) {
 	}
 }
 
+func TestEmitNested(t *testing.T) {
+	const qu = `g.V("<bob>").forEach(function(d) {
+		g.emit({id: d.id, friends: g.V(d.id).in("<follows>").toArray(), tags: [g.IRI("cool")]})
+	})`
+	ses := makeTestSession(testutil.LoadGraph(t, "../../data/testdata.nq"))
+	ctx := context.TODO()
+
+	run := func(col query.Collation) interface{} {
+		it, err := ses.Execute(ctx, qu, query.Options{
+			Collation: col,
+			Limit:     -1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer it.Close()
+		var got []interface{}
+		for it.Next(ctx) {
+			got = append(got, it.Result())
+		}
+		if err = it.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 {
+			t.Fatalf("expected one result, got: %v", got)
+		}
+		return got[0]
+	}
+
+	raw := run(query.Raw).(*Result)
+	obj, ok := raw.Val.(map[string]interface{})
+	if !ok {
+		t.Fatalf("unexpected raw result: %#v", raw.Val)
+	}
+	friends, ok := obj["friends"].([]interface{})
+	if !ok || len(friends) != 3 {
+		t.Fatalf("unexpected nested array: %#v", obj["friends"])
+	}
+	if tags := obj["tags"].([]interface{}); !reflect.DeepEqual(tags, []interface{}{quad.IRI("cool")}) {
+		t.Fatalf("unexpected nested values: %#v", tags)
+	}
+
+	expJSON := map[string]interface{}{
+		"id":      "<bob>",
+		"friends": []interface{}{"<alice>", "<dani>", "<charlie>"},
+		"tags":    []interface{}{"<cool>"},
+	}
+	if got := run(query.JSON); !reflect.DeepEqual(got, expJSON) {
+		t.Fatalf("unexpected json result: %#v expected: %#v", got, expJSON)
+	}
+
+	const expREPL = "****\n" +
+		`friends : ["<alice>","<dani>","<charlie>"]` + "\n" +
+		"id : <bob>\n" +
+		`tags : ["<cool>"]` + "\n"
+	if got := run(query.REPL); got != expREPL {
+		t.Fatalf("unexpected repl result:\n%q\nexpected:\n%q", got, expREPL)
+	}
+}
+
 const issue718Limit = 5
 
 func issue718Graph() []quad.Quad {
@@ -845,3 +1514,169 @@ func issue718Nodes() []string {
 	}
 	return nodes
 }
+
+func TestViewInvalidation(t *testing.T) {
+	qs, _ := graph.NewQuadStore("memstore", "", nil)
+	w, _ := graph.NewQuadWriter("single", qs, nil)
+	for _, q := range testutil.LoadGraph(t, "../../data/testdata.nq") {
+		w.AddQuad(q)
+	}
+	ses := NewSession(qs)
+	w = ses.TrackWrites(w)
+	ctx := context.TODO()
+
+	run := func(qu string) []string {
+		it, err := ses.Execute(ctx, qu, query.Options{
+			Collation: query.Raw,
+			Limit:     -1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer it.Close()
+		var got []string
+		for it.Next(ctx) {
+			data := it.Result().(*Result)
+			if data.Val == nil {
+				if val := data.Tags[TopResultTag]; val != nil {
+					nv, err := qs.NameOf(val)
+					if err != nil {
+						t.Fatal(err)
+					}
+					got = append(got, quadValueToString(nv))
+				}
+			}
+		}
+		if err = it.Err(); err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(got)
+		return got
+	}
+
+	_ = run(`g.V().has("<status>", "cool_person").saveView("cool")`)
+	exp := []string{"<bob>", "<dani>", "<greg>"}
+	for i := 0; i < 2; i++ {
+		if got := run(`g.view("cool").all()`); !reflect.DeepEqual(got, exp) {
+			t.Fatalf("unexpected view contents, got: %v expected: %v", got, exp)
+		}
+	}
+
+	w.AddQuad(quad.Quad{
+		Subject:   quad.IRI("alice"),
+		Predicate: quad.IRI("status"),
+		Object:    quad.String("cool_person"),
+	})
+	exp = []string{"<alice>", "<bob>", "<dani>", "<greg>"}
+	if got := run(`g.view("cool").all()`); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("view was not refreshed after write, got: %v expected: %v", got, exp)
+	}
+
+	// replace a quad; the number of nodes and quads stays the same
+	w.RemoveQuad(quad.Quad{
+		Subject:   quad.IRI("alice"),
+		Predicate: quad.IRI("status"),
+		Object:    quad.String("cool_person"),
+	})
+	w.AddQuad(quad.Quad{
+		Subject:   quad.IRI("charlie"),
+		Predicate: quad.IRI("status"),
+		Object:    quad.String("cool_person"),
+	})
+	exp = []string{"<bob>", "<charlie>", "<dani>", "<greg>"}
+	if got := run(`g.view("cool").all()`); !reflect.DeepEqual(got, exp) {
+		t.Fatalf("view was not refreshed after quad replacement, got: %v expected: %v", got, exp)
+	}
+}
+
+func TestMatchingNilPath(t *testing.T) {
+	ses := makeTestSession(nil)
+	p := &pathObject{s: ses}
+	call := goja.FunctionCall{Arguments: []goja.Value{ses.vm.ToValue("^fol")}}
+	for _, v := range []goja.Value{p.InMatching(call), p.OutMatching(call)} {
+		if np, ok := v.Export().(*pathObject); !ok || np.path != nil {
+			t.Fatalf("unexpected result: %#v", v.Export())
+		}
+	}
+}
+
+func TestSessionReuse(t *testing.T) {
+	ses := makeTestSession(testutil.LoadGraph(t, "../../data/testdata.nq"))
+	ctx := context.TODO()
+	// closing the iterator of a finished script should not break the next one
+	for i := 0; i < 2; i++ {
+		it, err := ses.Execute(ctx, `g.V("<alice>").out("<follows>").all()`, query.Options{
+			Collation: query.Raw,
+			Limit:     -1,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for it.Next(ctx) {
+			n++
+		}
+		if err = it.Err(); err != nil {
+			t.Fatalf("run %d: %v", i, err)
+		}
+		it.Close()
+		if n != 1 {
+			t.Fatalf("run %d: unexpected number of results: %d", i, n)
+		}
+	}
+}
+
+func TestStableOrder(t *testing.T) {
+	ses := makeTestSession(testutil.LoadGraph(t, "../../data/testdata.nq"))
+	ctx := context.TODO()
+
+	run := func() []string {
+		it, err := ses.Execute(ctx, `g.V().out("<follows>").all()`, query.Options{
+			Collation: query.Raw,
+			Limit:     -1,
+			Stable:    true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer it.Close()
+		var got []string
+		for it.Next(ctx) {
+			data := it.Result().(*Result)
+			nv, err := ses.qs.NameOf(data.Tags[TopResultTag])
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, quadValueToString(nv))
+		}
+		if err := it.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	first := run()
+	if !sort.StringsAreSorted(first) {
+		t.Fatalf("expected sorted results, got: %v", first)
+	}
+	for i := 0; i < 3; i++ {
+		if got := run(); !reflect.DeepEqual(got, first) {
+			t.Fatalf("unstable order of results:\n%v\nvs\n%v", got, first)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	got, err := runQueryGetTag(func() {}, testutil.LoadGraph(t, "../../data/testdata.nq"),
+		`g.V().has("<status>", "cool_person").out("<follows>").explain()`, TopResultTag, -1)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(got) != 1 {
+		t.Fatalf("expected a single result, got: %v", got)
+	}
+	for _, name := range []string{"NodesFrom", "QuadFilter", "QuadsAction"} {
+		if !strings.Contains(got[0], name) {
+			t.Errorf("expected %s in the plan:\n%s", name, got[0])
+		}
+	}
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/dop251/goja"
 
+	"github.com/cayleygraph/cayley/graph"
 	"github.com/cayleygraph/cayley/graph/iterator"
 	"github.com/cayleygraph/cayley/query"
 	"github.com/cayleygraph/cayley/query/path"
@@ -59,6 +60,7 @@ type pathObject struct {
 	s      *Session
 	finals bool
 	path   *path.Path
+	sel    []string // tags to include into results; all tags are included if empty
 }
 
 func (p *pathObject) new(np *path.Path) *pathObject {
@@ -66,6 +68,7 @@ func (p *pathObject) new(np *path.Path) *pathObject {
 		s:      p.s,
 		finals: p.finals,
 		path:   np,
+		sel:    p.sel,
 	}
 }
 
@@ -86,6 +89,20 @@ func (p *pathObject) buildIteratorTree() iterator.Shape {
 	return p.path.BuildIteratorOn(p.s.ctx, p.s.qs)
 }
 
+// buildPageIterator is the same as buildIteratorTree, but wraps the path into a page that
+// skips the first skip results and returns at most limit results. Negative limit means no limit.
+func (p *pathObject) buildPageIterator(skip, limit int) iterator.Shape {
+	if p.path == nil || skip <= 0 {
+		// limit alone is applied when iterating results
+		return p.buildIteratorTree()
+	}
+	np := p.clonePath().Skip(int64(skip))
+	if limit > 0 {
+		np = np.Limit(int64(limit))
+	}
+	return p.new(np).buildIteratorTree()
+}
+
 // Filter all paths to ones which, at this point, are on the given node.
 // Signature: (node, [node..])
 //
@@ -184,21 +201,64 @@ func (p *pathObject) Out(call goja.FunctionCall) goja.Value {
 	return p.inout(call, false)
 }
 
+// quadsTag is an internal tag used to save quads that can be exported with ToNQuads.
+const quadsTag = "$quad"
+
+// OutQuads is the same as Out, but additionally remembers traversed quads, so they can be exported with `path.toNQuads`.
+// Signature: ([predicatePath], [tags])
+//
+// Example:
+//
+//	// javascript
+//	// Export all quads about who bob follows
+//	// Returns: <bob> <follows> <fred> .
+//	g.emit(g.V("<bob>").outQuads("<follows>").toNQuads())
+func (p *pathObject) OutQuads(call goja.FunctionCall) goja.Value {
+	preds, tags, ok := toViaData(exportArgs(call.Arguments))
+	if !ok {
+		return throwErr(p.s.vm, errNoVia)
+	}
+	np := p.clonePath().OutWithTags(tags, preds...).SaveQuadRef(quadsTag)
+	return p.newVal(np)
+}
+
 // Both follow the predicate in either direction. Same as Out or In.
 // Signature: ([predicatePath], [tags])
 //
+// Instead of tags, an object with neighborTag and sideTag fields can be passed. Each neighbor will be
+// saved to neighborTag, and sideTag will be set to "in" or "out", depending on the direction it was reached in.
+//
 // Example:
 //	// javascript
 //	// Find all followers/followees of fred. Returns bob, emily and greg
 //	g.V("<fred>").both("<follows>").all()
+//	// Find the same nodes, but tag followers with "in", and followees with "out"
+//	g.V("<fred>").both("<follows>", {neighborTag: "who", sideTag: "side"}).all()
 func (p *pathObject) Both(call goja.FunctionCall) goja.Value {
-	preds, tags, ok := toViaData(exportArgs(call.Arguments))
+	args := exportArgs(call.Arguments)
+	if len(args) == 2 {
+		if opts, ok := args[1].(map[string]interface{}); ok {
+			return p.bothSides(args[0], opts)
+		}
+	}
+	preds, tags, ok := toViaData(args)
 	if !ok {
 		return throwErr(p.s.vm, errNoVia)
 	}
 	np := p.clonePath().BothWithTags(tags, preds...)
 	return p.newVal(np)
 }
+
+// bothSides is the same as Both, but saves the neighbor and the side of each edge into tags from opts.
+func (p *pathObject) bothSides(via interface{}, opts map[string]interface{}) goja.Value {
+	neighborTag, _ := opts["neighborTag"].(string)
+	sideTag, _ := opts["sideTag"].(string)
+	np := p.clonePath().BothWithSide(sideTag, toVia([]interface{}{via})...)
+	if neighborTag != "" {
+		np = np.Tag(neighborTag)
+	}
+	return p.newVal(np)
+}
 func (p *pathObject) follow(ep *pathObject, rev bool) *pathObject {
 	if ep == nil {
 		return p
@@ -369,6 +429,39 @@ func (p *pathObject) As(tags ...string) *pathObject {
 	return p.Tag(tags...)
 }
 
+// Select restricts tags emitted by all, forEach, tagArray and tagValue to a given set.
+// Results that have none of the selected tags are skipped, including results of toArray and toValue.
+//
+// Signature: (tags)
+//
+// Arguments:
+//
+// * `tags`: An array of tag names to keep in the results.
+//
+// Example:
+// 	// javascript
+//	// Returns only the "name" tag for each follower of bob.
+//	g.V("<bob>").as("name").in("<follows>").save("<status>", "status").select(["name"]).all()
+func (p *pathObject) Select(call goja.FunctionCall) goja.Value {
+	np := p.new(p.clonePath())
+	np.sel = toStrings(exportArgs(call.Arguments))
+	return p.s.vm.ToValue(np)
+}
+
+// selectTags removes tags that were not selected from the results.
+func (p *pathObject) selectTags(tags map[string]graph.Ref) map[string]graph.Ref {
+	if len(p.sel) == 0 {
+		return tags
+	}
+	out := make(map[string]graph.Ref, len(p.sel))
+	for _, t := range p.sel {
+		if v, ok := tags[t]; ok {
+			out[t] = v
+		}
+	}
+	return out
+}
+
 // Has filters all paths which are, at this point, on the subject for the given predicate and object,
 // but do not follow the path, merely filter the possible paths.
 //
@@ -394,9 +487,48 @@ func (p *pathObject) Has(call goja.FunctionCall) goja.Value {
 }
 
 // HasR is the same as Has, but sets constraint in reverse direction.
+// Filters can be used for subjects in the same way as for objects in Has.
+//
+// Example:
+// 	// javascript
+//	// Statuses of people with names sorting higher than "f" -- results in cool_person and smart_person
+//	g.V().hasR("<status>", gt("<f>")).all()
 func (p *pathObject) HasR(call goja.FunctionCall) goja.Value {
 	return p.has(call, true)
 }
+
+// HasLiteral filters all paths which are, at this point, on the subject for the given predicate
+// with a literal value as an object. Edges to IRIs and blank nodes are ignored.
+//
+// Signature: (predicate)
+//
+// Arguments:
+//
+// * `predicate`: A string for a predicate node.
+//
+// Example:
+// 	// javascript
+//	// Start from all nodes that have a literal status -- results in bob, dani, emily and greg
+//	g.V().hasLiteral("<status>").all()
+func (p *pathObject) HasLiteral(call goja.FunctionCall) goja.Value {
+	args := exportArgs(call.Arguments)
+	if len(args) != 1 {
+		return throwErr(p.s.vm, errArgCount2{Expected: 1, Got: len(args)})
+	}
+	var via interface{}
+	if vp, ok := args[0].(*pathObject); ok {
+		via = vp.path
+	} else {
+		var err error
+		via, err = toQuadValue(args[0])
+		if err != nil {
+			return throwErr(p.s.vm, err)
+		}
+	}
+	np := p.clonePath().HasKind(via, shape.KindLiteral)
+	return p.newVal(np)
+}
+
 func (p *pathObject) has(call goja.FunctionCall, rev bool) goja.Value {
 	args := exportArgs(call.Arguments)
 	if len(args) == 0 {
@@ -564,11 +696,96 @@ func (p *pathObject) Unique() *pathObject {
 	return p.new(np)
 }
 
+// Distinct removes paths with the same combination of values of given tags. Value of the node itself is not considered.
+// Tags must be set before this call.
+// Signature: (tag, [tag..])
+//
+// Example:
+//	// javascript
+//	// Find all distinct statuses of all nodes, namely, cool_person and smart_person
+//	g.V().save("<status>", "status").distinct("status").all()
+func (p *pathObject) Distinct(tags ...string) (*pathObject, error) {
+	if len(tags) == 0 {
+		return nil, errArgCount{Got: len(tags)}
+	}
+	np := p.clonePath().UniqueBy(tags...)
+	return p.new(np), nil
+}
+
+// ExceptBy removes paths with a value of a given tag that matches the results of another query.
+// Value of the node itself is not considered. Tag must be set before this call.
+// Signature: (tag, path)
+//
+// Example:
+//	// javascript
+//	// Find statuses of all nodes, except the ones of cool people
+//	g.V().save("<status>", "status").exceptBy("status", g.V("cool_person")).all()
+func (p *pathObject) ExceptBy(tag string, path *pathObject) *pathObject {
+	if path == nil {
+		return p
+	}
+	np := p.clonePath().ExceptBy(tag, path.path)
+	return p.new(np)
+}
+
 // Difference is an alias for Except.
 func (p *pathObject) Difference(path *pathObject) *pathObject {
 	return p.Except(path)
 }
 
+func (p *pathObject) inoutMatching(call goja.FunctionCall, in bool) goja.Value {
+	if len(call.Arguments) == 0 {
+		return throwErr(p.s.vm, errArgCount{Got: 0})
+	}
+	re, err := toRegexp(call.Arguments[0])
+	if err != nil {
+		return throwErr(p.s.vm, err)
+	}
+	if p.path == nil {
+		return p.s.vm.ToValue(p)
+	}
+	tags := toStrings(exportArgs(call.Arguments[1:]))
+	np := p.clonePath()
+	if in {
+		np = np.InRegexpWithTags(tags, re)
+	} else {
+		np = np.OutRegexpWithTags(tags, re)
+	}
+	return p.newVal(np)
+}
+
+// InMatching is the same as In, but follows all predicates with names matching a regular expression.
+// Signature: (regexp, [tags])
+//
+// Arguments:
+//
+// * `regexp`: A JavaScript regular expression or a string with a pattern to match predicate names against.
+// * `tags` (Optional): Tags to save the predicate used to the output set, same as for In.
+//
+// Example:
+//	// javascript
+//	// Find who follows bob, in this case, alice, charlie, and dani
+//	g.V("<bob>").inMatching(/fol.*/).all()
+func (p *pathObject) InMatching(call goja.FunctionCall) goja.Value {
+	return p.inoutMatching(call, true)
+}
+
+// OutMatching is the same as Out, but follows all predicates with names matching a regular expression.
+// Signature: (regexp, [tags])
+//
+// Arguments:
+//
+// * `regexp`: A JavaScript regular expression or a string with a pattern to match predicate names against.
+// * `tags` (Optional): Tags to save the predicate used to the output set, same as for Out.
+//
+// Example:
+//	// javascript
+//	// Find all people dani follows, in this case, bob and greg
+//	g.V("<dani>").outMatching(/fol.*/).all()
+func (p *pathObject) OutMatching(call goja.FunctionCall) goja.Value {
+	return p.inoutMatching(call, false)
+}
+
 // Labels gets the list of inbound and outbound quad labels
 func (p *pathObject) Labels() *pathObject {
 	np := p.clonePath().Labels()
@@ -623,6 +840,25 @@ func (p *pathObject) SaveOutPredicates(tag string) *pathObject {
 	return p.new(np)
 }
 
+// sourceTag is a tag used to save sources of edges.
+const sourceTag = "source"
+
+// InEdges tags all incoming edges of a node: a source node of each edge is saved into "source" tag,
+// and a predicate is saved into "predicate" tag. Each edge becomes a separate result.
+//
+// Example:
+// 	// javascript
+//	// alice, charlie and dani follow bob
+//	// returns:
+//	//   {"id":"<bob>", "source":"<alice>", "predicate":"<follows>"}
+//	//   {"id":"<bob>", "source":"<charlie>", "predicate":"<follows>"}
+//	//   {"id":"<bob>", "source":"<dani>", "predicate":"<follows>"}
+//	g.V("<bob>").inEdges().all()
+func (p *pathObject) InEdges() *pathObject {
+	np := p.clonePath().SaveEdges(true, "predicate", sourceTag)
+	return p.new(np)
+}
+
 // LabelContext sets (or removes) the subgraph context to consider in the following traversals.
 // Affects all In(), Out(), and Both() calls that follow it. The default LabelContext is null (all subgraphs).
 // Signature: ([labelPath], [tags])
@@ -19,6 +19,7 @@ import (
 	"github.com/cayleygraph/cayley/graph/refs"
 	"github.com/cayleygraph/cayley/query"
 	"github.com/cayleygraph/cayley/query/path"
+	"github.com/cayleygraph/cayley/query/shape"
 	"github.com/cayleygraph/quad"
 )
 
@@ -67,25 +68,27 @@ func (s *Session) Execute(ctx context.Context, qu string, opt query.Options) (qu
 		return nil, err
 	}
 	return &results{
-		s:   s,
-		q:   q,
-		col: opt.Collation,
+		s:      s,
+		q:      q,
+		col:    opt.Collation,
+		stable: opt.Stable,
 	}, nil
 }
 
 type results struct {
-	s   *Session
-	q   *Query
-	col query.Collation
-	res map[string]interface{}
-	err error
+	s      *Session
+	q      *Query
+	col    query.Collation
+	stable bool
+	res    map[string]interface{}
+	err    error
 }
 
 func (it *results) Next(ctx context.Context) bool {
 	if it.q == nil {
 		return false
 	}
-	it.res, it.err = it.q.Execute(ctx, it.s.qs)
+	it.res, it.err = it.q.Execute(shape.WithStableOrder(ctx, it.stable), it.s.qs)
 	it.q = nil
 	return it.err == nil && len(it.res) != 0
 }
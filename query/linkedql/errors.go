@@ -1,6 +1,13 @@
 package linkedql
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrMorphism is returned when a morphism (a path starting with Placeholder) is executed
+// on its own, instead of being passed to Follow or a similar step.
+var ErrMorphism = errors.New("morphism can not be executed on its own, use it in Follow or FollowReverse")
 
 func formatMultiError(errors []error) error {
 	joinedErr := ""
@@ -29,11 +29,13 @@ func NewValueIterator(p *path.Path, namer refs.Namer) *ValueIterator {
 }
 
 // NewValueIteratorFromPathStep attempts to build a path from PathStep and return a new ValueIterator of it.
-// If BuildPath fails returns error.
+// If BuildPath fails or the path is a morphism returns error.
 func NewValueIteratorFromPathStep(step PathStep, qs graph.QuadStore, ns *voc.Namespaces) (*ValueIterator, error) {
 	p, err := step.BuildPath(qs, ns)
 	if err != nil {
 		return nil, err
+	} else if p.IsMorphism() {
+		return nil, ErrMorphism
 	}
 	return NewValueIterator(p, qs), nil
 }
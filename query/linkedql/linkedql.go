@@ -6,6 +6,8 @@ import (
 
 	"github.com/cayleygraph/cayley/graph"
 	"github.com/cayleygraph/cayley/query"
+	"github.com/cayleygraph/cayley/query/path"
+	"github.com/cayleygraph/cayley/query/shape"
 	"github.com/cayleygraph/quad/voc"
 )
 
@@ -45,17 +47,33 @@ func NewSession(qs graph.QuadStore) *Session {
 }
 
 // Execute for a given context, query and options return an iterator of results.
-func (s *Session) Execute(ctx context.Context, query string, opt query.Options) (query.Iterator, error) {
-	item, err := Unmarshal([]byte(query))
+func (s *Session) Execute(ctx context.Context, qu string, opt query.Options) (query.Iterator, error) {
+	item, err := Unmarshal([]byte(qu))
 	if err != nil {
 		return nil, err
 	}
 	ns := voc.Namespaces{}
+	if err = RegisterContextNamespaces([]byte(qu), &ns); err != nil {
+		return nil, err
+	}
 	step, ok := item.(Step)
 	if !ok {
 		return nil, errors.New("must execute a Step")
 	}
-	return BuildIterator(step, s.qs, &ns)
+	step, err = Bind(step, opt.Params)
+	if err != nil {
+		return nil, err
+	}
+	var it query.Iterator
+	if opt.Limit > 0 {
+		it, err = buildIteratorLimit(step, s.qs, &ns, opt.Limit)
+	} else {
+		it, err = BuildIterator(step, s.qs, &ns)
+	}
+	if err != nil || !opt.Stable {
+		return it, err
+	}
+	return stableIterator{Iterator: it}, nil
 }
 
 // BuildIterator for given Step returns a query.Iterator
@@ -68,3 +86,59 @@ func BuildIterator(step Step, qs graph.QuadStore, ns *voc.Namespaces) (query.Ite
 	}
 	return nil, errors.New("must execute a IteratorStep or PathStep")
 }
+
+// buildIteratorLimit is the same as BuildIterator, but returns at most limit results.
+// Results of a PathStep are limited on the shape level, while results of an IteratorStep are truncated.
+func buildIteratorLimit(step Step, qs graph.QuadStore, ns *voc.Namespaces, limit int) (query.Iterator, error) {
+	if s, ok := step.(PathStep); ok {
+		if _, ok := step.(IteratorStep); !ok {
+			return BuildIterator(limitPath{PathStep: s, Limit: int64(limit)}, qs, ns)
+		}
+	}
+	it, err := BuildIterator(step, qs, ns)
+	if err != nil {
+		return nil, err
+	}
+	return &limitIterator{Iterator: it, limit: limit}, nil
+}
+
+// limitPath limits the number of results of a PathStep.
+type limitPath struct {
+	PathStep
+	Limit int64
+}
+
+// BuildPath implements PathStep.
+func (s limitPath) BuildPath(qs graph.QuadStore, ns *voc.Namespaces) (*path.Path, error) {
+	p, err := s.PathStep.BuildPath(qs, ns)
+	if err != nil || p.IsMorphism() {
+		return p, err
+	}
+	return p.Limit(s.Limit), nil
+}
+
+// limitIterator stops the iteration after a given number of results.
+type limitIterator struct {
+	query.Iterator
+	limit int
+	n     int
+}
+
+// Next implements query.Iterator.
+func (it *limitIterator) Next(ctx context.Context) bool {
+	if it.n >= it.limit || !it.Iterator.Next(ctx) {
+		return false
+	}
+	it.n++
+	return true
+}
+
+// stableIterator builds all underlying iterators with a stable ordering of results.
+type stableIterator struct {
+	query.Iterator
+}
+
+// Next implements query.Iterator.
+func (it stableIterator) Next(ctx context.Context) bool {
+	return it.Iterator.Next(shape.WithStableOrder(ctx, true))
+}
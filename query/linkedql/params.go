@@ -0,0 +1,135 @@
+package linkedql
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/cayleygraph/quad"
+)
+
+const (
+	// paramKey is a JSON key of a parameter placeholder, e.g. {"@param": "startNode"}.
+	paramKey = "@param"
+	// paramNamespace is used to keep parameters as IRIs while the query is normalized.
+	paramNamespace = "http://cayley.io/linkedql/param#"
+)
+
+var _ quad.Value = Parameter("")
+
+// Parameter is a placeholder for a value that is provided when the query is executed.
+// In JSON it is written as {"@param": "name"}. Parameters must be replaced with Bind
+// before building the query. Session.Execute binds them to query.Options.Params.
+type Parameter string
+
+func (p Parameter) String() string {
+	return "$" + string(p)
+}
+
+// Native implements quad.Value.
+func (p Parameter) Native() interface{} {
+	return p
+}
+
+// ErrUnboundParameter is returned when no value is provided for a query parameter.
+type ErrUnboundParameter struct {
+	Name string
+}
+
+func (e ErrUnboundParameter) Error() string {
+	return fmt.Sprintf("no value for query parameter %q", e.Name)
+}
+
+// replaceParams replaces parameter placeholders with IRIs in a decoded JSON, since
+// JSON-LD normalization drops unknown keywords.
+func replaceParams(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if name, ok := v[paramKey].(string); ok && len(v) == 1 {
+			return map[string]interface{}{"@id": paramNamespace + name}
+		}
+		for k, sv := range v {
+			v[k] = replaceParams(sv)
+		}
+	case []interface{}:
+		for i, sv := range v {
+			v[i] = replaceParams(sv)
+		}
+	}
+	return v
+}
+
+// Bind returns a copy of the step with all parameters replaced by values from params.
+// The original step is not modified, thus it can be bound multiple times.
+// It returns ErrUnboundParameter if there is no value for one of the parameters.
+func Bind(step Step, params map[string]quad.Value) (Step, error) {
+	v, err := bindValue(reflect.ValueOf(step), params)
+	if err != nil {
+		return nil, err
+	}
+	return v.Interface().(Step), nil
+}
+
+var parameterType = reflect.TypeOf(Parameter(""))
+
+func bindValue(v reflect.Value, params map[string]quad.Value) (reflect.Value, error) {
+	if v.Type() == parameterType {
+		name := string(v.Interface().(Parameter))
+		val, ok := params[name]
+		if !ok || val == nil {
+			return v, ErrUnboundParameter{Name: name}
+		}
+		return reflect.ValueOf(val), nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || v.Elem().Kind() != reflect.Struct {
+			return v, nil
+		}
+		elem, err := bindValue(v.Elem(), params)
+		if err != nil {
+			return v, err
+		}
+		nv := reflect.New(v.Type().Elem())
+		nv.Elem().Set(elem)
+		return nv, nil
+	case reflect.Interface:
+		if v.IsNil() {
+			return v, nil
+		}
+		elem, err := bindValue(v.Elem(), params)
+		if err != nil {
+			return v, err
+		}
+		nv := reflect.New(v.Type()).Elem()
+		nv.Set(elem)
+		return nv, nil
+	case reflect.Struct:
+		nv := reflect.New(v.Type()).Elem()
+		nv.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath != "" {
+				continue // unexported
+			}
+			f, err := bindValue(v.Field(i), params)
+			if err != nil {
+				return v, err
+			}
+			nv.Field(i).Set(f)
+		}
+		return nv, nil
+	case reflect.Slice:
+		if v.IsNil() {
+			return v, nil
+		}
+		nv := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			e, err := bindValue(v.Index(i), params)
+			if err != nil {
+				return v, err
+			}
+			nv.Index(i).Set(e)
+		}
+		return nv, nil
+	}
+	return v, nil
+}
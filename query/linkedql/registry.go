@@ -229,6 +229,7 @@ func Unmarshal(data []byte) (RegistryItem, error) {
 func normalizeQuery(data []byte) ([]byte, error) {
 	var query interface{}
 	json.Unmarshal(data, &query)
+	query = replaceParams(query)
 	processor := ld.NewJsonLdProcessor()
 	opts := ld.NewJsonLdOptions("")
 	compact, err := processor.Compact(query, nil, opts)
@@ -252,6 +253,9 @@ func parseIRI(s string) (quad.IRI, error) {
 }
 
 func parseIdentifier(s string) (quad.Value, error) {
+	if strings.HasPrefix(s, paramNamespace) {
+		return Parameter(strings.TrimPrefix(s, paramNamespace)), nil
+	}
 	bnode, err := parseBNode(s)
 	if err == nil {
 		return bnode, nil
@@ -4,6 +4,7 @@ import (
 	"github.com/cayleygraph/cayley/graph"
 	"github.com/cayleygraph/cayley/query"
 	"github.com/cayleygraph/cayley/query/path"
+	"github.com/cayleygraph/cayley/query/shape"
 	"github.com/cayleygraph/quad/voc"
 )
 
@@ -23,3 +24,9 @@ type PathStep interface {
 	Step
 	BuildPath(qs graph.QuadStore, ns *voc.Namespaces) (*path.Path, error)
 }
+
+// Operator is a Step that can be used as a value filter, for example in Has.
+type Operator interface {
+	Step
+	BuildFilter(ns *voc.Namespaces) (shape.ValueFilter, error)
+}
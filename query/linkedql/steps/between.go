@@ -0,0 +1,86 @@
+package steps
+
+import (
+	"errors"
+
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/graph/iterator"
+	"github.com/cayleygraph/cayley/query/linkedql"
+	"github.com/cayleygraph/cayley/query/path"
+	"github.com/cayleygraph/cayley/query/shape"
+	"github.com/cayleygraph/quad"
+	"github.com/cayleygraph/quad/voc"
+)
+
+func init() {
+	linkedql.Register(&Between{})
+}
+
+var _ linkedql.PathStep = (*Between)(nil)
+var _ linkedql.Operator = (*Between)(nil)
+
+var errInvertedRange = errors.New("min value of the range is greater than max value")
+
+// Between corresponds to a combination of gte() and lte().
+type Between struct {
+	From       linkedql.PathStep `json:"from"`
+	Min        quad.Value        `json:"min"`
+	Max        quad.Value        `json:"max"`
+	ExcludeMin bool              `json:"excludeMin,omitempty"`
+	ExcludeMax bool              `json:"excludeMax,omitempty"`
+}
+
+// Description implements Step.
+func (s *Between) Description() string {
+	return "Between filters out values that are not in the range between min and max values. Both bounds are included unless excludeMin or excludeMax is set to true."
+}
+
+// BuildPath implements linkedql.PathStep.
+func (s *Between) BuildPath(qs graph.QuadStore, ns *voc.Namespaces) (*path.Path, error) {
+	fromPath, err := s.From.BuildPath(qs, ns)
+	if err != nil {
+		return nil, err
+	}
+	filters, err := s.filters(ns)
+	if err != nil {
+		return nil, err
+	}
+	return fromPath.Filters(filters...), nil
+}
+
+// BuildFilter implements linkedql.Operator.
+func (s *Between) BuildFilter(ns *voc.Namespaces) (shape.ValueFilter, error) {
+	filters, err := s.filters(ns)
+	if err != nil {
+		return nil, err
+	}
+	return valueFilters(filters), nil
+}
+
+func (s *Between) filters(ns *voc.Namespaces) ([]shape.ValueFilter, error) {
+	min, max := linkedql.AbsoluteValue(s.Min, ns), linkedql.AbsoluteValue(s.Max, ns)
+	if iterator.CompareValues(min, iterator.CompareGT, max) {
+		return nil, errInvertedRange
+	}
+	minOp, maxOp := iterator.CompareGTE, iterator.CompareLTE
+	if s.ExcludeMin {
+		minOp = iterator.CompareGT
+	}
+	if s.ExcludeMax {
+		maxOp = iterator.CompareLT
+	}
+	return []shape.ValueFilter{
+		shape.Comparison{Op: minOp, Val: min},
+		shape.Comparison{Op: maxOp, Val: max},
+	}, nil
+}
+
+// valueFilters applies all filters to the same values.
+type valueFilters []shape.ValueFilter
+
+func (f valueFilters) BuildIterator(qs graph.QuadStore, it iterator.Shape) iterator.Shape {
+	for _, vf := range f {
+		it = vf.BuildIterator(qs, it)
+	}
+	return it
+}
@@ -0,0 +1,42 @@
+package steps
+
+import (
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/query/linkedql"
+	"github.com/cayleygraph/cayley/query/path"
+	"github.com/cayleygraph/cayley/query/shape"
+	"github.com/cayleygraph/quad"
+	"github.com/cayleygraph/quad/voc"
+)
+
+func init() {
+	linkedql.Register(&Equals{})
+}
+
+var _ linkedql.PathStep = (*Equals)(nil)
+var _ linkedql.Operator = (*Equals)(nil)
+
+// Equals corresponds to eq().
+type Equals struct {
+	From  linkedql.PathStep `json:"from"`
+	Value quad.Value        `json:"value"`
+}
+
+// Description implements Step.
+func (s *Equals) Description() string {
+	return "Equals filters out values that are not equal to given value"
+}
+
+// BuildPath implements linkedql.PathStep.
+func (s *Equals) BuildPath(qs graph.QuadStore, ns *voc.Namespaces) (*path.Path, error) {
+	fromPath, err := s.From.BuildPath(qs, ns)
+	if err != nil {
+		return nil, err
+	}
+	return fromPath.Is(linkedql.AbsoluteValue(s.Value, ns)), nil
+}
+
+// BuildFilter implements linkedql.Operator.
+func (s *Equals) BuildFilter(ns *voc.Namespaces) (shape.ValueFilter, error) {
+	return shape.ValueSet{Values: []quad.Value{linkedql.AbsoluteValue(s.Value, ns)}}, nil
+}
@@ -0,0 +1,38 @@
+package steps
+
+import (
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/query/linkedql"
+	"github.com/cayleygraph/cayley/query/path"
+	"github.com/cayleygraph/quad/voc"
+)
+
+func init() {
+	linkedql.Register(&FollowReverse{})
+}
+
+var _ linkedql.PathStep = (*FollowReverse)(nil)
+
+// FollowReverse corresponds to .followR().
+type FollowReverse struct {
+	From     linkedql.PathStep `json:"from"`
+	Followed linkedql.PathStep `json:"followed"`
+}
+
+// Description implements Step.
+func (s *FollowReverse) Description() string {
+	return "is the same as Follow, except it applies the morphism backwards, starting at the end of the morphism path."
+}
+
+// BuildPath implements linkedql.PathStep.
+func (s *FollowReverse) BuildPath(qs graph.QuadStore, ns *voc.Namespaces) (*path.Path, error) {
+	fromPath, err := s.From.BuildPath(qs, ns)
+	if err != nil {
+		return nil, err
+	}
+	followedPath, err := s.Followed.BuildPath(qs, ns)
+	if err != nil {
+		return nil, err
+	}
+	return fromPath.FollowReverse(followedPath), nil
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/cayleygraph/cayley/graph/iterator"
 	"github.com/cayleygraph/cayley/query/linkedql"
 	"github.com/cayleygraph/cayley/query/path"
+	"github.com/cayleygraph/cayley/query/shape"
 	"github.com/cayleygraph/quad"
 	"github.com/cayleygraph/quad/voc"
 )
@@ -14,6 +15,7 @@ func init() {
 }
 
 var _ linkedql.PathStep = (*GreaterThan)(nil)
+var _ linkedql.Operator = (*GreaterThan)(nil)
 
 // GreaterThan corresponds to gt().
 type GreaterThan struct {
@@ -34,3 +36,8 @@ func (s *GreaterThan) BuildPath(qs graph.QuadStore, ns *voc.Namespaces) (*path.P
 	}
 	return fromPath.Filter(iterator.CompareGT, linkedql.AbsoluteValue(s.Value, ns)), nil
 }
+
+// BuildFilter implements linkedql.Operator.
+func (s *GreaterThan) BuildFilter(ns *voc.Namespaces) (shape.ValueFilter, error) {
+	return shape.Comparison{Op: iterator.CompareGT, Val: linkedql.AbsoluteValue(s.Value, ns)}, nil
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/cayleygraph/cayley/graph/iterator"
 	"github.com/cayleygraph/cayley/query/linkedql"
 	"github.com/cayleygraph/cayley/query/path"
+	"github.com/cayleygraph/cayley/query/shape"
 	"github.com/cayleygraph/quad"
 	"github.com/cayleygraph/quad/voc"
 )
@@ -14,6 +15,7 @@ func init() {
 }
 
 var _ linkedql.PathStep = (*GreaterThanEquals)(nil)
+var _ linkedql.Operator = (*GreaterThanEquals)(nil)
 
 // GreaterThanEquals corresponds to gte().
 type GreaterThanEquals struct {
@@ -34,3 +36,8 @@ func (s *GreaterThanEquals) BuildPath(qs graph.QuadStore, ns *voc.Namespaces) (*
 	}
 	return fromPath.Filter(iterator.CompareGTE, linkedql.AbsoluteValue(s.Value, ns)), nil
 }
+
+// BuildFilter implements linkedql.Operator.
+func (s *GreaterThanEquals) BuildFilter(ns *voc.Namespaces) (shape.ValueFilter, error) {
+	return shape.Comparison{Op: iterator.CompareGTE, Val: linkedql.AbsoluteValue(s.Value, ns)}, nil
+}
@@ -1,6 +1,8 @@
 package steps
 
 import (
+	"errors"
+
 	"github.com/cayleygraph/cayley/graph"
 	"github.com/cayleygraph/cayley/query/linkedql"
 	"github.com/cayleygraph/cayley/query/path"
@@ -14,16 +16,19 @@ func init() {
 
 var _ linkedql.PathStep = (*Has)(nil)
 
+var errHasValuesAndFilter = errors.New("values and filter can not be used together")
+
 // Has corresponds to .has().
 type Has struct {
 	From     linkedql.PathStep      `json:"from"`
 	Property *linkedql.PropertyPath `json:"property"`
 	Values   []quad.Value           `json:"values"`
+	Filter   linkedql.Operator      `json:"filter" minCardinality:"0"`
 }
 
 // Description implements Step.
 func (s *Has) Description() string {
-	return "filters all paths which are, at this point, on the subject for the given predicate and object, but do not follow the path, merely filter the possible paths. Usually useful for starting with all nodes, or limiting to a subset depending on some predicate/value pair."
+	return "filters all paths which are, at this point, on the subject for the given predicate and object, but do not follow the path, merely filter the possible paths. Usually useful for starting with all nodes, or limiting to a subset depending on some predicate/value pair. Instead of values, a filter can be provided to match objects of the predicate."
 }
 
 // BuildPath implements linkedql.PathStep.
@@ -36,5 +41,15 @@ func (s *Has) BuildPath(qs graph.QuadStore, ns *voc.Namespaces) (*path.Path, err
 	if err != nil {
 		return nil, err
 	}
+	if s.Filter != nil {
+		if len(s.Values) != 0 {
+			return nil, errHasValuesAndFilter
+		}
+		filter, err := s.Filter.BuildFilter(ns)
+		if err != nil {
+			return nil, err
+		}
+		return fromPath.HasFilter(viaPath, false, filter), nil
+	}
 	return fromPath.Has(viaPath, linkedql.AbsoluteValues(s.Values, ns)...), nil
 }
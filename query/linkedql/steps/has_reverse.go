@@ -19,6 +19,7 @@ type HasReverse struct {
 	From     linkedql.PathStep      `json:"from"`
 	Property *linkedql.PropertyPath `json:"property"`
 	Values   []quad.Value           `json:"values"`
+	Filter   linkedql.Operator      `json:"filter" minCardinality:"0"`
 }
 
 // Description implements Step.
@@ -36,5 +37,15 @@ func (s *HasReverse) BuildPath(qs graph.QuadStore, ns *voc.Namespaces) (*path.Pa
 	if err != nil {
 		return nil, err
 	}
+	if s.Filter != nil {
+		if len(s.Values) != 0 {
+			return nil, errHasValuesAndFilter
+		}
+		filter, err := s.Filter.BuildFilter(ns)
+		if err != nil {
+			return nil, err
+		}
+		return fromPath.HasFilter(viaPath, true, filter), nil
+	}
 	return fromPath.HasReverse(viaPath, linkedql.AbsoluteValues(s.Values, ns)...), nil
 }
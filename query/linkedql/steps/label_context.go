@@ -0,0 +1,43 @@
+package steps
+
+import (
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/query/linkedql"
+	"github.com/cayleygraph/cayley/query/path"
+	"github.com/cayleygraph/quad"
+	"github.com/cayleygraph/quad/voc"
+)
+
+func init() {
+	linkedql.Register(&LabelContext{})
+}
+
+var _ linkedql.PathStep = (*LabelContext)(nil)
+
+// LabelContext corresponds to .labelContext().
+type LabelContext struct {
+	From   linkedql.PathStep `json:"from"`
+	Values []quad.Value      `json:"values"`
+}
+
+// Description implements Step.
+func (s *LabelContext) Description() string {
+	return "restricts the following steps to only traverse quads with one of the given labels. If no values are provided, the restriction is removed."
+}
+
+// BuildPath implements linkedql.PathStep.
+func (s *LabelContext) BuildPath(qs graph.QuadStore, ns *voc.Namespaces) (*path.Path, error) {
+	fromPath, err := s.From.BuildPath(qs, ns)
+	if err != nil {
+		return nil, err
+	}
+	values := linkedql.AbsoluteValues(s.Values, ns)
+	if len(values) == 0 {
+		return fromPath.LabelContext(), nil
+	}
+	via := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		via = append(via, v)
+	}
+	return fromPath.LabelContext(via...), nil
+}
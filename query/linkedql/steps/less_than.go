@@ -5,6 +5,7 @@ import (
 	"github.com/cayleygraph/cayley/graph/iterator"
 	"github.com/cayleygraph/cayley/query/linkedql"
 	"github.com/cayleygraph/cayley/query/path"
+	"github.com/cayleygraph/cayley/query/shape"
 	"github.com/cayleygraph/quad"
 	"github.com/cayleygraph/quad/voc"
 )
@@ -14,6 +15,7 @@ func init() {
 }
 
 var _ linkedql.PathStep = (*LessThan)(nil)
+var _ linkedql.Operator = (*LessThan)(nil)
 
 // LessThan corresponds to lt().
 type LessThan struct {
@@ -34,3 +36,8 @@ func (s *LessThan) BuildPath(qs graph.QuadStore, ns *voc.Namespaces) (*path.Path
 	}
 	return fromPath.Filter(iterator.CompareLT, s.Value), nil
 }
+
+// BuildFilter implements linkedql.Operator.
+func (s *LessThan) BuildFilter(ns *voc.Namespaces) (shape.ValueFilter, error) {
+	return shape.Comparison{Op: iterator.CompareLT, Val: linkedql.AbsoluteValue(s.Value, ns)}, nil
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/cayleygraph/cayley/graph/iterator"
 	"github.com/cayleygraph/cayley/query/linkedql"
 	"github.com/cayleygraph/cayley/query/path"
+	"github.com/cayleygraph/cayley/query/shape"
 	"github.com/cayleygraph/quad"
 	"github.com/cayleygraph/quad/voc"
 )
@@ -14,6 +15,7 @@ func init() {
 }
 
 var _ linkedql.PathStep = (*LessThanEquals)(nil)
+var _ linkedql.Operator = (*LessThanEquals)(nil)
 
 // LessThanEquals corresponds to lte().
 type LessThanEquals struct {
@@ -34,3 +36,8 @@ func (s *LessThanEquals) BuildPath(qs graph.QuadStore, ns *voc.Namespaces) (*pat
 	}
 	return fromPath.Filter(iterator.CompareLTE, linkedql.AbsoluteValue(s.Value, ns)), nil
 }
+
+// BuildFilter implements linkedql.Operator.
+func (s *LessThanEquals) BuildFilter(ns *voc.Namespaces) (shape.ValueFilter, error) {
+	return shape.Comparison{Op: iterator.CompareLTE, Val: linkedql.AbsoluteValue(s.Value, ns)}, nil
+}
@@ -13,6 +13,7 @@ func init() {
 }
 
 var _ linkedql.PathStep = (*Like)(nil)
+var _ linkedql.Operator = (*Like)(nil)
 
 // Like corresponds to like().
 type Like struct {
@@ -33,3 +34,8 @@ func (s *Like) BuildPath(qs graph.QuadStore, ns *voc.Namespaces) (*path.Path, er
 	}
 	return fromPath.Filters(shape.Wildcard{Pattern: s.Pattern}), nil
 }
+
+// BuildFilter implements linkedql.Operator.
+func (s *Like) BuildFilter(ns *voc.Namespaces) (shape.ValueFilter, error) {
+	return shape.Wildcard{Pattern: s.Pattern}, nil
+}
@@ -0,0 +1,48 @@
+package steps
+
+import (
+	"context"
+
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/graph/iterator"
+	"github.com/cayleygraph/cayley/query/linkedql"
+	"github.com/cayleygraph/cayley/query/path"
+	"github.com/cayleygraph/quad/voc"
+)
+
+func init() {
+	linkedql.Register(&Materialize{})
+}
+
+var _ linkedql.PathStep = (*Materialize)(nil)
+
+// Materialize corresponds to .toArray().
+type Materialize struct {
+	From  linkedql.PathStep `json:"from"`
+	Limit int64             `json:"limit,omitempty"`
+}
+
+// Description implements Step.
+func (s *Materialize) Description() string {
+	return "Materialize loads up to limit values of the from step into memory, so the following steps can use them without traversing the graph again. If limit is not set, all values are loaded. Tags of the from step are not preserved."
+}
+
+// BuildPath implements linkedql.PathStep.
+func (s *Materialize) BuildPath(qs graph.QuadStore, ns *voc.Namespaces) (*path.Path, error) {
+	fromPath, err := s.From.BuildPath(qs, ns)
+	if err != nil {
+		return nil, err
+	}
+	if fromPath.IsMorphism() {
+		return nil, linkedql.ErrMorphism
+	}
+	it := fromPath.Iterate(context.TODO())
+	if s.Limit > 0 {
+		it = it.Limit(int(s.Limit))
+	}
+	values, err := it.All()
+	if err != nil {
+		return nil, err
+	}
+	return path.PathFromIterator(qs, iterator.NewFixed(values...)), nil
+}
@@ -0,0 +1,42 @@
+package steps
+
+import (
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/query/linkedql"
+	"github.com/cayleygraph/cayley/query/path"
+	"github.com/cayleygraph/cayley/query/shape"
+	"github.com/cayleygraph/quad"
+	"github.com/cayleygraph/quad/voc"
+)
+
+func init() {
+	linkedql.Register(&NotEqual{})
+}
+
+var _ linkedql.PathStep = (*NotEqual)(nil)
+var _ linkedql.Operator = (*NotEqual)(nil)
+
+// NotEqual corresponds to neq().
+type NotEqual struct {
+	From  linkedql.PathStep `json:"from"`
+	Value quad.Value        `json:"value"`
+}
+
+// Description implements Step.
+func (s *NotEqual) Description() string {
+	return "NotEqual filters out values that are equal to given value"
+}
+
+// BuildPath implements linkedql.PathStep.
+func (s *NotEqual) BuildPath(qs graph.QuadStore, ns *voc.Namespaces) (*path.Path, error) {
+	fromPath, err := s.From.BuildPath(qs, ns)
+	if err != nil {
+		return nil, err
+	}
+	return fromPath.Except(path.StartPath(qs, linkedql.AbsoluteValue(s.Value, ns))), nil
+}
+
+// BuildFilter implements linkedql.Operator.
+func (s *NotEqual) BuildFilter(ns *voc.Namespaces) (shape.ValueFilter, error) {
+	return shape.ValueSet{Values: []quad.Value{linkedql.AbsoluteValue(s.Value, ns)}, Exclude: true}, nil
+}
@@ -0,0 +1,47 @@
+package steps
+
+import (
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/query/linkedql"
+	"github.com/cayleygraph/cayley/query/path"
+	"github.com/cayleygraph/cayley/query/shape"
+	"github.com/cayleygraph/quad"
+	"github.com/cayleygraph/quad/voc"
+)
+
+func init() {
+	linkedql.Register(&OneOf{})
+}
+
+var _ linkedql.PathStep = (*OneOf)(nil)
+var _ linkedql.Operator = (*OneOf)(nil)
+
+// OneOf corresponds to a set membership filter.
+type OneOf struct {
+	From   linkedql.PathStep `json:"from"`
+	Values []quad.Value      `json:"values"`
+}
+
+// Description implements Step.
+func (s *OneOf) Description() string {
+	return "OneOf filters out values that are not equal to any of the given values"
+}
+
+// BuildPath implements linkedql.PathStep.
+func (s *OneOf) BuildPath(qs graph.QuadStore, ns *voc.Namespaces) (*path.Path, error) {
+	fromPath, err := s.From.BuildPath(qs, ns)
+	if err != nil {
+		return nil, err
+	}
+	values := linkedql.AbsoluteValues(s.Values, ns)
+	if len(values) == 0 {
+		// Is with no values doesn't filter anything
+		return fromPath.Filters(shape.ValueSet{}), nil
+	}
+	return fromPath.Is(values...), nil
+}
+
+// BuildFilter implements linkedql.Operator.
+func (s *OneOf) BuildFilter(ns *voc.Namespaces) (shape.ValueFilter, error) {
+	return shape.ValueSet{Values: linkedql.AbsoluteValues(s.Values, ns)}, nil
+}
@@ -15,12 +15,13 @@ var _ linkedql.PathStep = (*Order)(nil)
 
 // Order corresponds to .order().
 type Order struct {
-	From linkedql.PathStep `json:"from"`
+	From       linkedql.PathStep `json:"from"`
+	Descending bool              `json:"descending,omitempty"`
 }
 
 // Description implements Step.
 func (s *Order) Description() string {
-	return "sorts the results in ascending order according to the current entity / value"
+	return "sorts the results in ascending order according to the current entity / value. If descending is set to true, sorts the results in descending order."
 }
 
 // BuildPath implements linkedql.PathStep.
@@ -29,5 +30,8 @@ func (s *Order) BuildPath(qs graph.QuadStore, ns *voc.Namespaces) (*path.Path, e
 	if err != nil {
 		return nil, err
 	}
+	if s.Descending {
+		return fromPath.OrderDesc(), nil
+	}
 	return fromPath.Order(), nil
 }
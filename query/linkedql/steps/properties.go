@@ -1,7 +1,9 @@
 package steps
 
 import (
+	"context"
 	"fmt"
+	"sort"
 
 	"github.com/cayleygraph/cayley/graph"
 	"github.com/cayleygraph/cayley/query/linkedql"
@@ -19,12 +21,12 @@ var _ linkedql.PathStep = (*Properties)(nil)
 // Properties corresponds to .properties().
 type Properties struct {
 	From  linkedql.PathStep      `json:"from"`
-	Names *linkedql.PropertyPath `json:"names"`
+	Names *linkedql.PropertyPath `json:"names" minCardinality:"0"`
 }
 
 // Description implements Step.
 func (s *Properties) Description() string {
-	return "adds tags for all properties of the current entity"
+	return "adds tags for all properties of the current entity. If names are not provided, all properties of the entity are tagged."
 }
 
 func resolveNames(names *linkedql.PropertyPath) (linkedql.PropertyIRIs, error) {
@@ -47,6 +49,21 @@ func resolveNames(names *linkedql.PropertyPath) (linkedql.PropertyIRIs, error) {
 	}
 }
 
+// allPropertyNames returns names of all properties of nodes in the path.
+func allPropertyNames(qs graph.QuadStore, from *path.Path) ([]quad.Value, error) {
+	p := from
+	if p.IsMorphism() {
+		// nodes are not known in advance, use all properties in the graph
+		p = path.StartPath(qs)
+	}
+	names, err := p.OutPredicates().Unique().Iterate(context.TODO()).AllValues(qs)
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(quad.ByValueString(names))
+	return names, nil
+}
+
 // BuildPath implements linkedql.PathStep.
 func (s *Properties) BuildPath(qs graph.QuadStore, ns *voc.Namespaces) (*path.Path, error) {
 	fromPath, err := s.From.BuildPath(qs, ns)
@@ -54,6 +71,21 @@ func (s *Properties) BuildPath(qs graph.QuadStore, ns *voc.Namespaces) (*path.Pa
 		return nil, err
 	}
 	p := fromPath
+	if s.Names == nil {
+		names, err := allPropertyNames(qs, fromPath)
+		if err != nil {
+			return nil, err
+		}
+		// entities may not have all of the properties
+		for _, name := range names {
+			tag := quad.StringOf(name)
+			if iri, ok := name.(quad.IRI); ok {
+				tag = string(iri)
+			}
+			p = p.SaveOptional(name, tag)
+		}
+		return p, nil
+	}
 	names, err := resolveNames(s.Names)
 	if err != nil {
 		return nil, err
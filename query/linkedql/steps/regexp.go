@@ -4,8 +4,10 @@ import (
 	"regexp"
 
 	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/graph/iterator"
 	"github.com/cayleygraph/cayley/query/linkedql"
 	"github.com/cayleygraph/cayley/query/path"
+	"github.com/cayleygraph/cayley/query/shape"
 	"github.com/cayleygraph/quad/voc"
 )
 
@@ -14,17 +16,27 @@ func init() {
 }
 
 var _ linkedql.PathStep = (*RegExp)(nil)
+var _ linkedql.Operator = (*RegExp)(nil)
 
 // RegExp corresponds to regex().
 type RegExp struct {
-	From        linkedql.PathStep `json:"from"`
-	Expression  string            `json:"expression"`
-	IncludeIRIs bool              `json:"includeIRIs,omitempty"`
+	From            linkedql.PathStep `json:"from"`
+	Expression      string            `json:"expression"`
+	IncludeIRIs     bool              `json:"includeIRIs,omitempty"`
+	CaseInsensitive bool              `json:"caseInsensitive,omitempty"`
+}
+
+func (s *RegExp) compile() (*regexp.Regexp, error) {
+	expr := s.Expression
+	if s.CaseInsensitive {
+		expr = "(?i)" + expr
+	}
+	return iterator.CompileRegexp(expr)
 }
 
 // Description implements Step.
 func (s *RegExp) Description() string {
-	return "RegExp filters out values that do not match given pattern. If includeIRIs is set to true it matches IRIs in addition to literals."
+	return "RegExp filters out values that do not match given pattern. If includeIRIs is set to true it matches IRIs in addition to literals. If caseInsensitive is set to true the letter case is ignored."
 }
 
 // BuildPath implements PathStep.
@@ -33,12 +45,21 @@ func (s *RegExp) BuildPath(qs graph.QuadStore, ns *voc.Namespaces) (*path.Path,
 	if err != nil {
 		return nil, err
 	}
-	pattern, err := regexp.Compile(s.Expression)
+	pattern, err := s.compile()
 	if err != nil {
 		return nil, err
 	}
 	if s.IncludeIRIs {
 		return fromPath.RegexWithRefs(pattern), nil
 	}
-	return fromPath.RegexWithRefs(pattern), nil
+	return fromPath.Regex(pattern), nil
+}
+
+// BuildFilter implements linkedql.Operator.
+func (s *RegExp) BuildFilter(ns *voc.Namespaces) (shape.ValueFilter, error) {
+	pattern, err := s.compile()
+	if err != nil {
+		return nil, err
+	}
+	return shape.Regexp{Re: pattern, Refs: s.IncludeIRIs}, nil
 }
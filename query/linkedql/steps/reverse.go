@@ -0,0 +1,33 @@
+package steps
+
+import (
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/query/linkedql"
+	"github.com/cayleygraph/cayley/query/path"
+	"github.com/cayleygraph/quad/voc"
+)
+
+func init() {
+	linkedql.Register(&Reverse{})
+}
+
+var _ linkedql.PathStep = (*Reverse)(nil)
+
+// Reverse corresponds to .reverse().
+type Reverse struct {
+	From linkedql.PathStep `json:"from"`
+}
+
+// Description implements Step.
+func (s *Reverse) Description() string {
+	return "Reverse flips the direction of all traversals of the from step, so it can be used with Follow to walk a morphism backwards. Properties, filters and label context are preserved, only the direction of traversal changes."
+}
+
+// BuildPath implements linkedql.PathStep.
+func (s *Reverse) BuildPath(qs graph.QuadStore, ns *voc.Namespaces) (*path.Path, error) {
+	fromPath, err := s.From.BuildPath(qs, ns)
+	if err != nil {
+		return nil, err
+	}
+	return fromPath.Reverse(), nil
+}
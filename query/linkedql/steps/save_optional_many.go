@@ -0,0 +1,47 @@
+package steps
+
+import (
+	"errors"
+
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/query/linkedql"
+	"github.com/cayleygraph/cayley/query/path"
+	"github.com/cayleygraph/quad"
+	"github.com/cayleygraph/quad/voc"
+)
+
+func init() {
+	linkedql.Register(&SaveOptionalMany{})
+}
+
+var _ linkedql.PathStep = (*SaveOptionalMany)(nil)
+
+var errViasAndTagsLength = errors.New("number of vias and tags must be the same")
+
+// SaveOptionalMany corresponds to a chain of .saveOpt() calls.
+type SaveOptionalMany struct {
+	From linkedql.PathStep `json:"from"`
+	Vias []quad.Value      `json:"vias"`
+	Tags []string          `json:"tags"`
+}
+
+// Description implements Step.
+func (s *SaveOptionalMany) Description() string {
+	return "SaveOptionalMany saves objects of each of the given properties into a matching tag. Entities that lack some of the properties are kept, with corresponding tags left unset."
+}
+
+// BuildPath implements linkedql.PathStep.
+func (s *SaveOptionalMany) BuildPath(qs graph.QuadStore, ns *voc.Namespaces) (*path.Path, error) {
+	if len(s.Vias) != len(s.Tags) {
+		return nil, errViasAndTagsLength
+	}
+	fromPath, err := s.From.BuildPath(qs, ns)
+	if err != nil {
+		return nil, err
+	}
+	p := fromPath
+	for i, via := range linkedql.AbsoluteValues(s.Vias, ns) {
+		p = p.SaveOptional(via, s.Tags[i])
+	}
+	return p, nil
+}
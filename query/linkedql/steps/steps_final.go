@@ -9,6 +9,7 @@ import (
 
 func init() {
 	linkedql.Register(&Select{})
+	linkedql.Register(&SelectFirst{})
 	linkedql.Register(&Documents{})
 }
 
@@ -36,6 +37,30 @@ func (s *Select) BuildIterator(qs graph.QuadStore, ns *voc.Namespaces) (query.It
 	return &it, nil
 }
 
+var _ linkedql.IteratorStep = (*SelectFirst)(nil)
+
+// SelectFirst corresponds to .select() with a limit of 1.
+type SelectFirst struct {
+	Properties []string          `json:"properties"`
+	From       linkedql.PathStep `json:"from"`
+	ExcludeID  bool              `json:"excludeID"`
+}
+
+// Description implements Step.
+func (s *SelectFirst) Description() string {
+	return "Like Select but only returns the first result"
+}
+
+// BuildIterator implements IteratorStep
+func (s *SelectFirst) BuildIterator(qs graph.QuadStore, ns *voc.Namespaces) (query.Iterator, error) {
+	sel := &Select{
+		Properties: s.Properties,
+		From:       &Limit{From: s.From, Limit: 1},
+		ExcludeID:  s.ExcludeID,
+	}
+	return sel.BuildIterator(qs, ns)
+}
+
 var _ linkedql.IteratorStep = (*Documents)(nil)
 
 // Documents corresponds to .documents().
@@ -50,11 +75,7 @@ func (s *Documents) Description() string {
 
 // BuildIterator implements IteratorStep
 func (s *Documents) BuildIterator(qs graph.QuadStore, ns *voc.Namespaces) (query.Iterator, error) {
-	p, err := s.From.BuildPath(qs, ns)
-	if err != nil {
-		return nil, err
-	}
-	it, err := linkedql.NewValueIterator(p, qs), nil
+	it, err := linkedql.NewValueIteratorFromPathStep(s.From, qs, ns)
 	if err != nil {
 		return nil, err
 	}
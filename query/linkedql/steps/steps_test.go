@@ -11,6 +11,7 @@ import (
 	"testing"
 
 	"github.com/cayleygraph/cayley/graph/memstore"
+	"github.com/cayleygraph/cayley/query"
 	"github.com/cayleygraph/cayley/query/linkedql"
 	"github.com/cayleygraph/quad"
 	"github.com/cayleygraph/quad/jsonld"
@@ -100,3 +101,403 @@ func TestLinkedQL(t *testing.T) {
 		})
 	}
 }
+
+func TestMorphismAtTopLevel(t *testing.T) {
+	store := memstore.New()
+	query := &Visit{
+		From:       &Placeholder{},
+		Properties: linkedql.NewPropertyPath(linkedql.PropertyIRI("http://example.com/likes")),
+	}
+	_, err := linkedql.BuildIterator(query, store, &voc.Namespaces{})
+	require.Equal(t, linkedql.ErrMorphism, err)
+}
+
+func TestOrderDirection(t *testing.T) {
+	data, err := readData(map[string]interface{}{
+		"@context": map[string]interface{}{
+			"@base":  "http://example.com/",
+			"@vocab": "http://example.com/",
+		},
+		"@id":   "alice",
+		"likes": map[string]interface{}{"@id": "bob"},
+	})
+	require.NoError(t, err)
+	store := memstore.New(data...)
+	ctx := context.TODO()
+	for _, c := range []struct {
+		name   string
+		query  string
+		expect []string
+	}{
+		{
+			name:   "default",
+			query:  `{"@type": "http://cayley.io/linkedql#Order", "http://cayley.io/linkedql#from": {"@type": "http://cayley.io/linkedql#Vertex"}}`,
+			expect: []string{"alice", "bob", "likes"},
+		},
+		{
+			name:   "descending",
+			query:  `{"@type": "http://cayley.io/linkedql#Order", "http://cayley.io/linkedql#from": {"@type": "http://cayley.io/linkedql#Vertex"}, "http://cayley.io/linkedql#descending": true}`,
+			expect: []string{"likes", "bob", "alice"},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			var raw interface{}
+			require.NoError(t, json.Unmarshal([]byte(c.query), &raw))
+			query, err := readQuery(raw)
+			require.NoError(t, err)
+			it, err := linkedql.BuildIterator(query, store, &voc.Namespaces{})
+			require.NoError(t, err)
+			defer it.Close()
+			var got []string
+			for it.Next(ctx) {
+				v := it.(*linkedql.ValueIterator).Value()
+				got = append(got, strings.TrimPrefix(string(v.(quad.IRI)), "http://example.com/"))
+			}
+			require.NoError(t, it.Err())
+			require.Equal(t, c.expect, got)
+		})
+	}
+}
+
+func TestBindParameters(t *testing.T) {
+	data, err := readData(map[string]interface{}{
+		"@context": map[string]interface{}{
+			"@base":  "http://example.com/",
+			"@vocab": "http://example.com/",
+		},
+		"@id": "alice",
+		"likes": map[string]interface{}{
+			"@id":   "bob",
+			"likes": map[string]interface{}{"@id": "charlie"},
+		},
+	})
+	require.NoError(t, err)
+	store := memstore.New(data...)
+	ctx := context.TODO()
+
+	template, err := linkedql.Unmarshal([]byte(`{
+		"@context": {"@vocab": "http://cayley.io/linkedql#"},
+		"@type": "Visit",
+		"from": {"@type": "Vertex", "values": [{"@param": "start"}]},
+		"properties": "http://example.com/likes"
+	}`))
+	require.NoError(t, err)
+
+	_, err = linkedql.Bind(template.(linkedql.Step), nil)
+	require.Equal(t, linkedql.ErrUnboundParameter{Name: "start"}, err)
+
+	for _, c := range []struct {
+		start  quad.IRI
+		expect quad.Value
+	}{
+		{start: "http://example.com/alice", expect: quad.IRI("http://example.com/bob")},
+		{start: "http://example.com/bob", expect: quad.IRI("http://example.com/charlie")},
+	} {
+		query, err := linkedql.Bind(template.(linkedql.Step), map[string]quad.Value{"start": c.start})
+		require.NoError(t, err)
+		it, err := linkedql.BuildIterator(query, store, &voc.Namespaces{})
+		require.NoError(t, err)
+		var got []quad.Value
+		for it.Next(ctx) {
+			got = append(got, it.(*linkedql.ValueIterator).Value())
+		}
+		require.NoError(t, it.Err())
+		require.Equal(t, []quad.Value{c.expect}, got)
+		it.Close()
+	}
+
+	// the same template can be executed by the session with different parameters
+	const raw = `{
+		"@context": {"@vocab": "http://cayley.io/linkedql#"},
+		"@type": "Visit",
+		"from": {"@type": "Vertex", "values": [{"@param": "start"}]},
+		"properties": "http://example.com/likes"
+	}`
+	ses := linkedql.NewSession(store)
+	_, err = ses.Execute(ctx, raw, query.Options{})
+	require.Equal(t, linkedql.ErrUnboundParameter{Name: "start"}, err)
+	for _, c := range []struct {
+		start  quad.IRI
+		expect quad.Value
+	}{
+		{start: "http://example.com/alice", expect: quad.IRI("http://example.com/bob")},
+		{start: "http://example.com/bob", expect: quad.IRI("http://example.com/charlie")},
+	} {
+		it, err := ses.Execute(ctx, raw, query.Options{
+			Params: map[string]quad.Value{"start": c.start},
+		})
+		require.NoError(t, err)
+		var got []quad.Value
+		for it.Next(ctx) {
+			got = append(got, it.(*linkedql.ValueIterator).Value())
+		}
+		require.NoError(t, it.Err())
+		require.Equal(t, []quad.Value{c.expect}, got)
+		it.Close()
+	}
+}
+
+func TestStableOrder(t *testing.T) {
+	const ex = "http://example.com/"
+	store := memstore.New(
+		quad.MakeIRI(ex+"alice", ex+"likes", ex+"dani", ""),
+		quad.MakeIRI(ex+"alice", ex+"likes", ex+"bob", ""),
+		quad.MakeIRI(ex+"alice", ex+"likes", ex+"charlie", ""),
+	)
+	ctx := context.TODO()
+	ses := linkedql.NewSession(store)
+	const raw = `{
+		"@context": {"@vocab": "http://cayley.io/linkedql#"},
+		"@type": "Visit",
+		"from": {"@type": "Vertex", "values": [{"@id": "http://example.com/alice"}]},
+		"properties": "http://example.com/likes"
+	}`
+	var expect []interface{}
+	for _, name := range []string{"bob", "charlie", "dani"} {
+		expect = append(expect, jsonld.FromValue(quad.IRI(ex+name)))
+	}
+	for _, limit := range []int{0, 2} {
+		it, err := ses.Execute(ctx, raw, query.Options{Limit: limit, Stable: true})
+		require.NoError(t, err)
+		var got []interface{}
+		for it.Next(ctx) {
+			got = append(got, it.Result())
+		}
+		require.NoError(t, it.Err())
+		exp := expect
+		if limit > 0 {
+			exp = exp[:limit]
+		}
+		require.Equal(t, exp, got)
+		it.Close()
+	}
+}
+
+func TestHasValuesAndFilter(t *testing.T) {
+	store := memstore.New()
+	query := &Has{
+		From:     &Vertex{},
+		Property: linkedql.NewPropertyPath(linkedql.PropertyIRI("http://example.com/age")),
+		Values:   []quad.Value{quad.Int(20)},
+		Filter:   &GreaterThan{Value: quad.Int(25)},
+	}
+	_, err := linkedql.BuildIterator(query, store, &voc.Namespaces{})
+	require.Equal(t, errHasValuesAndFilter, err)
+}
+
+func TestBetweenInvertedRange(t *testing.T) {
+	store := memstore.New()
+	query := &Between{
+		From: &Vertex{},
+		Min:  quad.Int(50),
+		Max:  quad.Int(30),
+	}
+	_, err := linkedql.BuildIterator(query, store, &voc.Namespaces{})
+	require.Equal(t, errInvertedRange, err)
+}
+
+func TestSaveOptionalManyLength(t *testing.T) {
+	store := memstore.New()
+	query := &SaveOptionalMany{
+		From: &Vertex{},
+		Vias: []quad.Value{quad.IRI("http://example.com/name"), quad.IRI("http://example.com/age")},
+		Tags: []string{"name"},
+	}
+	_, err := linkedql.BuildIterator(query, store, &voc.Namespaces{})
+	require.Equal(t, errViasAndTagsLength, err)
+}
+
+func TestMaterialize(t *testing.T) {
+	alice := quad.IRI("http://example.com/alice")
+	bob := quad.IRI("http://example.com/bob")
+	charlie := quad.IRI("http://example.com/charlie")
+	dani := quad.IRI("http://example.com/dani")
+	follows := quad.IRI("http://example.com/follows")
+	store := memstore.New(
+		quad.Make(alice, follows, bob, nil),
+		quad.Make(bob, follows, charlie, nil),
+		quad.Make(charlie, follows, dani, nil),
+	)
+	ctx := context.TODO()
+	materialized := &Materialize{
+		From:  &Vertex{Values: []quad.Value{alice, bob, charlie}},
+		Limit: 2,
+	}
+	for _, c := range []struct {
+		name   string
+		query  linkedql.PathStep
+		expect []quad.Value
+	}{
+		{
+			name:   "limit",
+			query:  materialized,
+			expect: []quad.Value{alice, bob},
+		},
+		{
+			name: "union",
+			query: &Union{
+				From:  materialized,
+				Steps: []linkedql.PathStep{&Vertex{Values: []quad.Value{dani}}},
+			},
+			expect: []quad.Value{alice, bob, dani},
+		},
+		{
+			name: "except",
+			query: &Difference{
+				From:  &Vertex{Values: []quad.Value{alice, bob, charlie, dani}},
+				Steps: []linkedql.PathStep{materialized},
+			},
+			expect: []quad.Value{charlie, dani},
+		},
+		{
+			name: "union and except",
+			query: &Union{
+				From: &Visit{
+					From:       materialized,
+					Properties: linkedql.NewPropertyPath(linkedql.PropertyIRI(follows)),
+				},
+				Steps: []linkedql.PathStep{&Difference{
+					From:  &Vertex{Values: []quad.Value{alice, bob, charlie, dani}},
+					Steps: []linkedql.PathStep{materialized},
+				}},
+			},
+			expect: []quad.Value{bob, charlie, charlie, dani},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			p, err := c.query.BuildPath(store, &voc.Namespaces{})
+			require.NoError(t, err)
+			got, err := p.Iterate(ctx).AllValues(store)
+			require.NoError(t, err)
+			require.ElementsMatch(t, c.expect, got)
+		})
+	}
+}
+
+func TestExecuteLimit(t *testing.T) {
+	data, err := readData(map[string]interface{}{
+		"@context": map[string]interface{}{
+			"@base":  "http://example.com/",
+			"@vocab": "http://example.com/",
+		},
+		"@id":   "alice",
+		"likes": map[string]interface{}{"@id": "bob"},
+	})
+	require.NoError(t, err)
+	store := memstore.New(data...)
+	ctx := context.TODO()
+	for _, c := range []struct {
+		name   string
+		query  string
+		expect int
+	}{
+		{
+			name:   "path",
+			query:  `{"@type": "http://cayley.io/linkedql#Vertex"}`,
+			expect: 2,
+		},
+		{
+			name:   "select",
+			query:  `{"@type": "http://cayley.io/linkedql#Select", "http://cayley.io/linkedql#from": {"@type": "http://cayley.io/linkedql#As", "http://cayley.io/linkedql#name": "node", "http://cayley.io/linkedql#from": {"@type": "http://cayley.io/linkedql#Vertex", "http://cayley.io/linkedql#values": [{"@id": "http://example.com/alice"}, {"@id": "http://example.com/bob"}, {"@id": "http://example.com/likes"}]}}}`,
+			expect: 2,
+		},
+		{
+			name:   "count",
+			query:  `{"@type": "http://cayley.io/linkedql#Count", "http://cayley.io/linkedql#from": {"@type": "http://cayley.io/linkedql#Vertex", "http://cayley.io/linkedql#values": [{"@id": "http://example.com/alice"}, {"@id": "http://example.com/bob"}, {"@id": "http://example.com/likes"}]}}`,
+			expect: 1,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			it, err := linkedql.NewSession(store).Execute(ctx, c.query, query.Options{Limit: 2})
+			require.NoError(t, err)
+			defer it.Close()
+			var results []interface{}
+			for it.Next(ctx) {
+				results = append(results, it.Result())
+				if c.name == "count" {
+					// count is not affected by the limit
+					require.Equal(t, quad.Int(3), it.(*linkedql.ValueIterator).Value())
+				}
+			}
+			require.NoError(t, it.Err())
+			require.Len(t, results, c.expect)
+		})
+	}
+}
+
+func TestReverse(t *testing.T) {
+	alice := quad.IRI("http://example.com/alice")
+	bob := quad.IRI("http://example.com/bob")
+	charlie := quad.IRI("http://example.com/charlie")
+	follows := quad.IRI("http://example.com/follows")
+	store := memstore.New(
+		quad.Make(alice, follows, bob, nil),
+		quad.Make(bob, follows, charlie, nil),
+		quad.Make(charlie, follows, bob, nil),
+	)
+	ctx := context.TODO()
+	props := linkedql.NewPropertyPath(linkedql.PropertyIRI(follows))
+	for _, node := range []quad.Value{alice, bob, charlie} {
+		from := &Vertex{Values: []quad.Value{node}}
+		reversed := &Follow{
+			From:     from,
+			Followed: &Reverse{From: &Visit{From: &Placeholder{}, Properties: props}},
+		}
+		in := &VisitReverse{From: from, Properties: props}
+
+		p, err := reversed.BuildPath(store, &voc.Namespaces{})
+		require.NoError(t, err)
+		got, err := p.Iterate(ctx).AllValues(store)
+		require.NoError(t, err)
+
+		p, err = in.BuildPath(store, &voc.Namespaces{})
+		require.NoError(t, err)
+		expect, err := p.Iterate(ctx).AllValues(store)
+		require.NoError(t, err)
+
+		require.ElementsMatch(t, expect, got, "%v", node)
+	}
+}
+
+func TestExecuteContextNamespaces(t *testing.T) {
+	data, err := readData(map[string]interface{}{
+		"@context": map[string]interface{}{
+			"@base":  "http://example.com/",
+			"@vocab": "http://example.com/",
+		},
+		"@id":   "alice",
+		"likes": map[string]interface{}{"@id": "bob"},
+	})
+	require.NoError(t, err)
+	store := memstore.New(data...)
+	ctx := context.TODO()
+	for _, c := range []struct {
+		name  string
+		query string
+	}{
+		{
+			name:  "expanded",
+			query: `{"@type": "http://cayley.io/linkedql#Visit", "http://cayley.io/linkedql#from": {"@type": "http://cayley.io/linkedql#Vertex", "http://cayley.io/linkedql#values": [{"@id": "http://example.com/alice"}]}, "http://cayley.io/linkedql#properties": "http://example.com/likes"}`,
+		},
+		{
+			name:  "prefixed",
+			query: `{"@context": {"ex": "http://example.com/"}, "@type": "http://cayley.io/linkedql#Visit", "http://cayley.io/linkedql#from": {"@type": "http://cayley.io/linkedql#Vertex", "http://cayley.io/linkedql#values": [{"@id": "ex:alice"}]}, "http://cayley.io/linkedql#properties": "ex:likes"}`,
+		},
+		{
+			name:  "prefixed list",
+			query: `{"@context": [{"@vocab": "http://cayley.io/linkedql#"}, {"ex": "http://example.com/"}], "@type": "Visit", "from": {"@type": "Vertex", "values": [{"@id": "ex:alice"}]}, "properties": "ex:likes"}`,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			it, err := linkedql.NewSession(store).Execute(ctx, c.query, query.Options{})
+			require.NoError(t, err)
+			defer it.Close()
+			var got []quad.Value
+			for it.Next(ctx) {
+				got = append(got, it.(*linkedql.ValueIterator).Value())
+			}
+			require.NoError(t, it.Err())
+			require.Equal(t, []quad.Value{quad.IRI("http://example.com/bob")}, got)
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package linkedql
 
 import (
+	"encoding/json"
+	"strings"
+
 	"github.com/cayleygraph/quad"
 	"github.com/cayleygraph/quad/voc"
 )
@@ -25,3 +28,34 @@ func AbsoluteValues(values []quad.Value, ns *voc.Namespaces) []quad.Value {
 	}
 	return absoluteValues
 }
+
+// RegisterContextNamespaces registers IRI prefixes defined in the JSON-LD "@context" of the query in ns.
+// Only terms that map to an IRI ending with '/' or '#' are considered prefixes. Remote contexts are ignored.
+func RegisterContextNamespaces(data []byte, ns *voc.Namespaces) error {
+	var q struct {
+		Context interface{} `json:"@context"`
+	}
+	if err := json.Unmarshal(data, &q); err != nil {
+		return err
+	}
+	contexts, ok := q.Context.([]interface{})
+	if !ok {
+		contexts = []interface{}{q.Context}
+	}
+	for _, c := range contexts {
+		ctx, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for term, v := range ctx {
+			full, ok := v.(string)
+			if !ok || strings.HasPrefix(term, "@") {
+				continue
+			}
+			if strings.HasSuffix(full, "/") || strings.HasSuffix(full, "#") {
+				ns.Register(voc.Namespace{Full: full, Prefix: term + ":"})
+			}
+		}
+	}
+	return nil
+}
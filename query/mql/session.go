@@ -21,6 +21,7 @@ import (
 	"github.com/cayleygraph/cayley/graph"
 	"github.com/cayleygraph/cayley/graph/iterator"
 	"github.com/cayleygraph/cayley/query"
+	"github.com/cayleygraph/cayley/query/shape"
 )
 
 const Name = "mql"
@@ -102,7 +103,7 @@ func (s *Session) Execute(ctx context.Context, input string, opt query.Options)
 		return nil, err
 	}
 	q := NewQuery(s)
-	q.BuildIteratorTree(ctx, mqlQuery)
+	q.BuildIteratorTree(shape.WithStableOrder(ctx, opt.Stable), mqlQuery)
 	if q.isError() {
 		return nil, q.err
 	}
@@ -17,6 +17,7 @@ package path
 import (
 	"context"
 	"fmt"
+	"regexp"
 
 	"github.com/cayleygraph/cayley/graph"
 	"github.com/cayleygraph/cayley/graph/iterator"
@@ -125,6 +126,16 @@ func hasShapeMorphism(via interface{}, rev bool, nodes shape.Shape) morphism {
 	}
 }
 
+// hasDegreeMorphism is the set of nodes that have a number of edges via a given predicate in a given range.
+func hasDegreeMorphism(via interface{}, min, max int64) morphism {
+	return morphism{
+		Reversal: func(ctx *pathContext) (morphism, *pathContext) { return hasDegreeMorphism(via, min, max), ctx },
+		Apply: func(in shape.Shape, ctx *pathContext) (shape.Shape, *pathContext) {
+			return shape.HasDegree(in, buildVia(via), ctx.labelSet, min, max), ctx
+		},
+	}
+}
+
 // hasFilterMorphism is the set of nodes that is reachable via either a *Path, a
 // single node.(string) or a list of nodes.([]string) and that passes provided filters.
 func hasFilterMorphism(via interface{}, rev bool, filt []shape.ValueFilter) morphism {
@@ -156,6 +167,28 @@ func outMorphism(tags []string, via ...interface{}) morphism {
 	}
 }
 
+// outFilteredMorphism iterates forward one RDF triple or via an entire path, but only from nodes
+// that match the filter path.
+func outFilteredMorphism(filter *Path, via ...interface{}) morphism {
+	return morphism{
+		Reversal: func(ctx *pathContext) (morphism, *pathContext) { return inFilteredMorphism(filter, via...), ctx },
+		Apply: func(in shape.Shape, ctx *pathContext) (shape.Shape, *pathContext) {
+			return shape.OutFiltered(in, filter.Shape(), buildVia(via...), ctx.labelSet), ctx
+		},
+	}
+}
+
+// inFilteredMorphism is a reversal of outFilteredMorphism: it iterates backwards and keeps
+// only nodes that match the filter path.
+func inFilteredMorphism(filter *Path, via ...interface{}) morphism {
+	return morphism{
+		Reversal: func(ctx *pathContext) (morphism, *pathContext) { return outFilteredMorphism(filter, via...), ctx },
+		Apply: func(in shape.Shape, ctx *pathContext) (shape.Shape, *pathContext) {
+			return join(shape.In(in, buildVia(via...), ctx.labelSet), filter.Shape()), ctx
+		},
+	}
+}
+
 // inMorphism iterates backwards one RDF triple or via an entire path.
 func inMorphism(tags []string, via ...interface{}) morphism {
 	return morphism{
@@ -167,15 +200,44 @@ func inMorphism(tags []string, via ...interface{}) morphism {
 	}
 }
 
+// outRegexpMorphism iterates forward one RDF triple via all predicates matching a regexp.
+func outRegexpMorphism(tags []string, re *regexp.Regexp) morphism {
+	return morphism{
+		Reversal: func(ctx *pathContext) (morphism, *pathContext) { return inRegexpMorphism(tags, re), ctx },
+		Apply: func(in shape.Shape, ctx *pathContext) (shape.Shape, *pathContext) {
+			return shape.OutRegexp(in, re, ctx.labelSet, tags...), ctx
+		},
+		tags: tags,
+	}
+}
+
+// inRegexpMorphism iterates backwards one RDF triple via all predicates matching a regexp.
+func inRegexpMorphism(tags []string, re *regexp.Regexp) morphism {
+	return morphism{
+		Reversal: func(ctx *pathContext) (morphism, *pathContext) { return outRegexpMorphism(tags, re), ctx },
+		Apply: func(in shape.Shape, ctx *pathContext) (shape.Shape, *pathContext) {
+			return shape.InRegexp(in, re, ctx.labelSet, tags...), ctx
+		},
+		tags: tags,
+	}
+}
+
 func bothMorphism(tags []string, via ...interface{}) morphism {
 	return morphism{
 		Reversal: func(ctx *pathContext) (morphism, *pathContext) { return bothMorphism(tags, via...), ctx },
 		Apply: func(in shape.Shape, ctx *pathContext) (shape.Shape, *pathContext) {
-			via := buildVia(via...)
-			return shape.Union{
-				shape.In(in, via, ctx.labelSet, tags...),
-				shape.Out(in, via, ctx.labelSet, tags...),
-			}, ctx
+			return shape.Both(in, buildVia(via...), ctx.labelSet, tags...), ctx
+		},
+		tags: tags,
+	}
+}
+
+// bothSideMorphism is like bothMorphism, but also tags the side of each traversed quad.
+func bothSideMorphism(side string, tags []string, via ...interface{}) morphism {
+	return morphism{
+		Reversal: func(ctx *pathContext) (morphism, *pathContext) { return bothSideMorphism(side, tags, via...), ctx },
+		Apply: func(in shape.Shape, ctx *pathContext) (shape.Shape, *pathContext) {
+			return shape.BothSides(in, buildVia(via...), ctx.labelSet, side, tags...), ctx
 		},
 		tags: tags,
 	}
@@ -242,6 +304,43 @@ func savePredicatesMorphism(isIn bool, tag string) morphism {
 	}
 }
 
+// predicateObjectTypesMorphism finds types of objects used with each predicate from current nodes.
+func predicateObjectTypesMorphism() morphism {
+	return morphism{
+		Reversal: func(ctx *pathContext) (morphism, *pathContext) {
+			panic("not implemented: need a function from types to their associated edges")
+		},
+		Apply: func(in shape.Shape, ctx *pathContext) (shape.Shape, *pathContext) {
+			return shape.PredicateObjectTypes(in, PredicateTag, ObjectTypeTag), ctx
+		},
+	}
+}
+
+// saveEdgesMorphism tags predicates and nodes of either forward or reverse edges from current node
+// without affecting path.
+func saveEdgesMorphism(isIn bool, predTag, nodeTag string) morphism {
+	return morphism{
+		Reversal: func(ctx *pathContext) (morphism, *pathContext) {
+			return saveEdgesMorphism(isIn, predTag, nodeTag), ctx
+		},
+		Apply: func(in shape.Shape, ctx *pathContext) (shape.Shape, *pathContext) {
+			return shape.SaveEdges(in, isIn, predTag, nodeTag), ctx
+		},
+	}
+}
+
+// componentsMorphism labels current nodes with ids of connected components they belong to.
+func componentsMorphism(tag string, via ...interface{}) morphism {
+	return morphism{
+		Reversal: func(ctx *pathContext) (morphism, *pathContext) {
+			return componentsMorphism(tag, via...), ctx
+		},
+		Apply: func(in shape.Shape, ctx *pathContext) (shape.Shape, *pathContext) {
+			return shape.ConnectedComponents(in, buildVia(via...), ctx.labelSet, tag), ctx
+		},
+	}
+}
+
 type iteratorShape struct {
 	it   iterator.Shape
 	sent bool
@@ -303,7 +402,8 @@ func followMorphism(p *Path) morphism {
 	return morphism{
 		Reversal: func(ctx *pathContext) (morphism, *pathContext) { return followMorphism(p.Reverse()), ctx },
 		Apply: func(in shape.Shape, ctx *pathContext) (shape.Shape, *pathContext) {
-			return p.ShapeFrom(in), ctx
+			// followed edges are constrained by the current label context
+			return p.shapeFromContext(in, ctx), ctx
 		},
 	}
 }
@@ -335,6 +435,31 @@ func followRecursiveMorphism(p *Path, maxDepth int, depthTags []string) morphism
 	}
 }
 
+// closureMorphism follows a single predicate from the current nodes until no new nodes can be reached.
+func closureMorphism(via quad.Value, rev bool, maxDepth int, depthTags []string) morphism {
+	return morphism{
+		Reversal: func(ctx *pathContext) (morphism, *pathContext) {
+			return closureMorphism(via, !rev, maxDepth, depthTags), ctx
+		},
+		Apply: func(in shape.Shape, ctx *pathContext) (shape.Shape, *pathContext) {
+			return iteratorBuilder(func(qs graph.QuadStore) iterator.Shape {
+				pred, err := qs.ValueOf(via)
+				if err != nil {
+					return iterator.NewError(err)
+				} else if pred == nil {
+					return iterator.NewNull()
+				}
+				it := iterator.NewClosure(qs, in.BuildIterator(qs), pred, maxDepth)
+				it.SetReverse(rev)
+				for _, s := range depthTags {
+					it.AddDepthTag(s)
+				}
+				return it
+			}), ctx
+		},
+	}
+}
+
 // exceptMorphism removes all results on p.(*Path) from the current iterators.
 func exceptMorphism(p *Path) morphism {
 	return morphism{
@@ -345,13 +470,54 @@ func exceptMorphism(p *Path) morphism {
 	}
 }
 
+// exceptApproxMorphism removes all results on p.(*Path) from the current iterators,
+// using an approximate set with a given false positive rate.
+func exceptApproxMorphism(p *Path, rate float64) morphism {
+	return morphism{
+		Reversal: func(ctx *pathContext) (morphism, *pathContext) { return exceptApproxMorphism(p, rate), ctx },
+		Apply: func(in shape.Shape, ctx *pathContext) (shape.Shape, *pathContext) {
+			return join(in, shape.Except{From: shape.AllNodes{}, Exclude: p.Shape(), FalsePositiveRate: rate}), ctx
+		},
+	}
+}
+
 // uniqueMorphism removes duplicate values from current path.
 func uniqueMorphism() morphism {
 	return morphism{
 		Reversal: func(ctx *pathContext) (morphism, *pathContext) { return uniqueMorphism(), ctx },
 		Apply: func(in shape.Shape, ctx *pathContext) (shape.Shape, *pathContext) {
-			return shape.Unique{in}, ctx
+			return shape.Unique{From: in}, ctx
+		},
+	}
+}
+
+// uniqueByMorphism removes paths with duplicate values of given tags.
+func uniqueByMorphism(tags []string) morphism {
+	return morphism{
+		Reversal: func(ctx *pathContext) (morphism, *pathContext) { return uniqueByMorphism(tags), ctx },
+		Apply: func(in shape.Shape, ctx *pathContext) (shape.Shape, *pathContext) {
+			return shape.UniqueBy{From: in, Tags: tags}, ctx
+		},
+	}
+}
+
+func exceptByMorphism(tag string, p *Path) morphism {
+	return morphism{
+		Reversal: func(ctx *pathContext) (morphism, *pathContext) { return exceptByMorphism(tag, p), ctx },
+		Apply: func(in shape.Shape, ctx *pathContext) (shape.Shape, *pathContext) {
+			return shape.ExceptBy{From: in, Tag: tag, Exclude: p.Shape()}, ctx
+		},
+	}
+}
+
+// saveLabelMorphism saves labels of quads followed by the previous traversal into a tag.
+func saveLabelMorphism(tag string) morphism {
+	return morphism{
+		Reversal: func(ctx *pathContext) (morphism, *pathContext) { return saveLabelMorphism(tag), ctx },
+		Apply: func(in shape.Shape, ctx *pathContext) (shape.Shape, *pathContext) {
+			return shape.SaveLabel(in, tag), ctx
 		},
+		tags: []string{tag},
 	}
 }
 
@@ -395,6 +561,36 @@ func saveOptionalReverseMorphism(via interface{}, tag string) morphism {
 	}
 }
 
+// saveQuadRefMorphism tags quads traversed by a previous morphism with their references.
+func saveQuadRefMorphism(tag string) morphism {
+	return morphism{
+		Reversal: func(ctx *pathContext) (morphism, *pathContext) { return saveQuadRefMorphism(tag), ctx },
+		Apply: func(in shape.Shape, ctx *pathContext) (shape.Shape, *pathContext) {
+			return shape.SaveQuads(in, tag), ctx
+		},
+		tags: []string{tag},
+	}
+}
+
+func quadCompareMorphism(a quad.Direction, op iterator.Operator, b quad.Direction) morphism {
+	return morphism{
+		Reversal: func(ctx *pathContext) (morphism, *pathContext) { return quadCompareMorphism(a, op, b), ctx },
+		Apply: func(in shape.Shape, ctx *pathContext) (shape.Shape, *pathContext) {
+			return shape.CompareQuads(in, a, op, b), ctx
+		},
+	}
+}
+
+func transformMorphism(fnc, tag string, args []string) morphism {
+	return morphism{
+		Reversal: func(ctx *pathContext) (morphism, *pathContext) { return transformMorphism(fnc, tag, args), ctx },
+		Apply: func(in shape.Shape, ctx *pathContext) (shape.Shape, *pathContext) {
+			return shape.Transform{From: in, Func: fnc, Args: args, Tag: tag}, ctx
+		},
+		tags: []string{tag},
+	}
+}
+
 func buildVia(via ...interface{}) shape.Shape {
 	if len(via) == 0 {
 		return shape.AllNodes{}
@@ -437,11 +633,27 @@ func skipMorphism(v int64) morphism {
 	}
 }
 
-func orderMorphism() morphism {
+func orderMorphism(desc bool) morphism {
 	return morphism{
-		Reversal: func(ctx *pathContext) (morphism, *pathContext) { return orderMorphism(), ctx },
+		Reversal: func(ctx *pathContext) (morphism, *pathContext) { return orderMorphism(desc), ctx },
 		Apply: func(in shape.Shape, ctx *pathContext) (shape.Shape, *pathContext) {
-			return shape.Sort{From: in}, ctx
+			return shape.Sort{From: in, Desc: desc}, ctx
+		},
+	}
+}
+
+// rowNumberMorphism tags each result with its position in the ordered output.
+// Results will be ordered if it was not done already.
+func rowNumberMorphism(tag string) morphism {
+	return morphism{
+		Reversal: func(ctx *pathContext) (morphism, *pathContext) { return rowNumberMorphism(tag), ctx },
+		Apply: func(in shape.Shape, ctx *pathContext) (shape.Shape, *pathContext) {
+			s, ok := in.(shape.Sort)
+			if !ok {
+				s = shape.Sort{From: in}
+			}
+			s.RowTags = append(s.RowTags[:len(s.RowTags):len(s.RowTags)], tag)
+			return s, ctx
 		},
 	}
 }
@@ -470,3 +682,12 @@ func countMorphism() morphism {
 		},
 	}
 }
+
+func pathCountMorphism() morphism {
+	return morphism{
+		Reversal: func(ctx *pathContext) (morphism, *pathContext) { return pathCountMorphism(), ctx },
+		Apply: func(in shape.Shape, ctx *pathContext) (shape.Shape, *pathContext) {
+			return shape.PathCount{Values: in}, ctx
+		},
+	}
+}
@@ -241,6 +241,53 @@ func (p *Path) OutWithTags(tags []string, via ...interface{}) *Path {
 	return np
 }
 
+// OutFiltered is the same as Out, but only follows edges from current nodes that match the filter path.
+// It's equivalent to And(filter).Out(via...), but the filter is applied to the traversed quads directly.
+//
+// For example:
+//  // Will return []string{"B"} if "A" has an edge labelled "follows" to "B",
+//  // while "C" is not following anyone.
+//  StartPath(qs, "A", "C").OutFiltered(StartPath(qs, "A"), "follows")
+func (p *Path) OutFiltered(filter *Path, via ...interface{}) *Path {
+	np := p.clone()
+	np.stack = append(np.stack, outFilteredMorphism(filter, via...))
+	return np
+}
+
+// OutRegexp is the same as Out, but follows all predicates matching a given regexp.
+//
+// For example:
+//  // Will return []string{"B"} if "A" has an edge labelled "follows" to "B".
+//  StartPath(qs, "A").OutRegexp(regexp.MustCompile("follow"))
+func (p *Path) OutRegexp(pattern *regexp.Regexp) *Path {
+	np := p.clone()
+	np.stack = append(np.stack, outRegexpMorphism(nil, pattern))
+	return np
+}
+
+// InRegexp is the same as In, but follows all predicates matching a given regexp.
+func (p *Path) InRegexp(pattern *regexp.Regexp) *Path {
+	np := p.clone()
+	np.stack = append(np.stack, inRegexpMorphism(nil, pattern))
+	return np
+}
+
+// OutRegexpWithTags is exactly like OutRegexp, except it tags the value of the predicate
+// traversed with the tags provided.
+func (p *Path) OutRegexpWithTags(tags []string, pattern *regexp.Regexp) *Path {
+	np := p.clone()
+	np.stack = append(np.stack, outRegexpMorphism(tags, pattern))
+	return np
+}
+
+// InRegexpWithTags is exactly like InRegexp, except it tags the value of the predicate
+// traversed with the tags provided.
+func (p *Path) InRegexpWithTags(tags []string, pattern *regexp.Regexp) *Path {
+	np := p.clone()
+	np.stack = append(np.stack, inRegexpMorphism(tags, pattern))
+	return np
+}
+
 // Both updates this path following both inbound and outbound predicates.
 //
 // For example:
@@ -263,6 +310,14 @@ func (p *Path) BothWithTags(tags []string, via ...interface{}) *Path {
 	return np
 }
 
+// BothWithSide is exactly like Both, except it tags each result with "in" or "out",
+// depending on the direction the predicate was traversed in.
+func (p *Path) BothWithSide(side string, via ...interface{}) *Path {
+	np := p.clone()
+	np.stack = append(np.stack, bothSideMorphism(side, nil, via...))
+	return np
+}
+
 // Labels updates this path to represent the nodes of the labels
 // of inbound and outbound quads.
 func (p *Path) Labels() *Path {
@@ -308,6 +363,45 @@ func (p *Path) SavePredicates(rev bool, tag string) *Path {
 	return np
 }
 
+// SaveEdges saves predicates and nodes on the other end of either forward or reverse
+// edges of current node without changing path location. Each result corresponds to a single edge.
+func (p *Path) SaveEdges(rev bool, predTag, nodeTag string) *Path {
+	np := p.clone()
+	np.stack = append(np.stack, saveEdgesMorphism(rev, predTag, nodeTag))
+	return np
+}
+
+// ConnectedComponents saves an id of a connected component for each of current nodes into a given tag.
+// Components are found by following given predicates in both directions. Nodes that have no such
+// edges are removed from the path. Ids are quad.Int values and are only meaningful within a single query.
+func (p *Path) ConnectedComponents(tag string, via ...interface{}) *Path {
+	np := p.clone()
+	np.stack = append(np.stack, componentsMorphism(tag, via...))
+	return np
+}
+
+const (
+	// PredicateTag is a tag used by PredicateObjectTypes to save predicates.
+	PredicateTag = "predicate"
+	// ObjectTypeTag is a tag used by PredicateObjectTypes to save types of objects.
+	ObjectTypeTag = "type"
+)
+
+// PredicateObjectTypes updates this path to represent distinct types of objects
+// used with each outbound predicate from the current nodes. IRIs and blank nodes
+// have rdfs:Resource type, while literals are represented by their datatype.
+//
+// Each result is tagged with a predicate (PredicateTag) and its object type (ObjectTypeTag).
+//
+// For example:
+//  // Will return pairs of {"follows", rdfs:Resource} and {"status", xsd:string}
+//  StartPath(qs).PredicateObjectTypes()
+func (p *Path) PredicateObjectTypes() *Path {
+	np := p.clone()
+	np.stack = append(np.stack, predicateObjectTypesMorphism())
+	return np
+}
+
 // And updates the current Path to represent the nodes that match both the
 // current Path so far, and the given Path.
 func (p *Path) And(path *Path) *Path {
@@ -343,6 +437,15 @@ func (p *Path) Except(path *Path) *Path {
 	return np
 }
 
+// ExceptApprox is the same as Except, but uses an approximate set with a given false positive
+// rate to exclude nodes. It never returns nodes from the supplied Path, but may also exclude
+// other nodes. Should only be used when the excluded set is too large to fit in memory.
+func (p *Path) ExceptApprox(path *Path, rate float64) *Path {
+	np := p.clone()
+	np.stack = append(np.stack, exceptApproxMorphism(path, rate))
+	return np
+}
+
 // Unique updates the current Path to contain only unique nodes.
 func (p *Path) Unique() *Path {
 	np := p.clone()
@@ -350,6 +453,22 @@ func (p *Path) Unique() *Path {
 	return np
 }
 
+// UniqueBy removes paths with the same combination of values of given tags.
+// Tags must be saved before this call, otherwise they won't be considered.
+func (p *Path) UniqueBy(tags ...string) *Path {
+	np := p.clone()
+	np.stack = append(np.stack, uniqueByMorphism(tags))
+	return np
+}
+
+// ExceptBy removes paths with a value of a given tag that is found in the results of the supplied Path.
+// Tag must be saved before this call, otherwise no paths will be removed.
+func (p *Path) ExceptBy(tag string, path *Path) *Path {
+	np := p.clone()
+	np.stack = append(np.stack, exceptByMorphism(tag, path))
+	return np
+}
+
 // Follow allows you to stitch two paths together. The resulting path will start
 // from where the first path left off and continue iterating down the path given.
 func (p *Path) Follow(path *Path) *Path {
@@ -400,6 +519,17 @@ func (p *Path) FollowRecursive(via interface{}, maxDepth int, depthTags []string
 	return np
 }
 
+// Closure is the same as FollowRecursive, but only follows a single predicate. It computes
+// all reachable nodes in a single breadth-first pass that uses the predicate index, and
+// expands each node only once.
+//
+// The maxDepth and depthTags arguments work the same way as in FollowRecursive.
+func (p *Path) Closure(via quad.Value, maxDepth int, depthTags []string) *Path {
+	np := p.clone()
+	np.stack = append(np.stack, closureMorphism(via, false, maxDepth, depthTags))
+	return np
+}
+
 // Save will, from the current nodes in the path, retrieve the node
 // one linkage away (given by either a path or a predicate), add the given
 // tag, and propagate that to the result set.
@@ -413,6 +543,19 @@ func (p *Path) Save(via interface{}, tag string) *Path {
 	return np
 }
 
+// SaveLabel saves the label (named graph) of quads that were followed by the previous Out, In or Both
+// into the given tag. It is intended for quads with labels, for example in combination with LabelContext.
+// Depending on the backend, paths that were followed via quads without a label are either removed or not tagged.
+//
+// For example:
+//  // Will return []map[string]string{{"graph": "ctx"}} if "A" -> "B" link is stored in "ctx" graph.
+//  StartPath(qs, "A").Out("follows").SaveLabel("graph")
+func (p *Path) SaveLabel(tag string) *Path {
+	np := p.clone()
+	np.stack = append(np.stack, saveLabelMorphism(tag))
+	return np
+}
+
 // SaveReverse is the same as Save, only in the reverse direction
 // (the subject of the linkage should be tagged, instead of the object).
 func (p *Path) SaveReverse(via interface{}, tag string) *Path {
@@ -428,6 +571,25 @@ func (p *Path) SaveOptional(via interface{}, tag string) *Path {
 	return np
 }
 
+// QuadCompare filters quads that were traversed by the previous Out, In or Both call,
+// by comparing values in two directions of each quad.
+//
+// For example, QuadCompare(quad.Subject, iterator.CompareEQ, quad.Object) after Out
+// keeps only self-loops.
+func (p *Path) QuadCompare(a quad.Direction, op iterator.Operator, b quad.Direction) *Path {
+	np := p.clone()
+	np.stack = append(np.stack, quadCompareMorphism(a, op, b))
+	return np
+}
+
+// SaveTransform applies a registered transform function to values of tags from args,
+// and saves the result into a given tag. See shape.RegisterTransform for details.
+func (p *Path) SaveTransform(fnc string, tag string, args ...string) *Path {
+	np := p.clone()
+	np.stack = append(np.stack, transformMorphism(fnc, tag, args))
+	return np
+}
+
 // SaveOptionalReverse is the same as SaveReverse, but does not require linkage to exist.
 func (p *Path) SaveOptionalReverse(via interface{}, tag string) *Path {
 	np := p.clone()
@@ -435,6 +597,22 @@ func (p *Path) SaveOptionalReverse(via interface{}, tag string) *Path {
 	return np
 }
 
+// SaveQuadRef tags quads that were traversed by the previous Out, In or Both call with a given tag.
+//
+// Unlike Save, the tag holds a reference to the quad itself, instead of a node. It can be
+// resolved to a full quad with QuadStore.Quad.
+//
+// Iterating the path returns an error if there is no traversal to take quads from.
+//
+// For example:
+//  // Will tag the <bob> <status> "cool_person" quad as "link"
+//  StartPath(qs, "bob").Out("status").SaveQuadRef("link")
+func (p *Path) SaveQuadRef(tag string) *Path {
+	np := p.clone()
+	np.stack = append(np.stack, saveQuadRefMorphism(tag))
+	return np
+}
+
 // HasPath limits the paths to be ones where the current nodes have a given subpath.
 func (p *Path) HasPath(p2 *Path) *Path {
 	np := p.clone()
@@ -466,6 +644,31 @@ func (p *Path) HasFilter(via interface{}, rev bool, filt ...shape.ValueFilter) *
 	return np
 }
 
+// HasKind limits the paths to be ones where the current nodes have some linkage
+// to nodes of a given kind (IRI, blank node or literal).
+//
+// For example:
+//  // Will return []string{"B"} if "B" has a "name" with a literal value,
+//  // while "A" only has an edge labelled "name" to an IRI.
+//  StartPath(qs, "A", "B").HasKind("name", shape.KindLiteral)
+func (p *Path) HasKind(via interface{}, kind shape.ValueKind) *Path {
+	np := p.clone()
+	np.stack = append(np.stack, hasFilterMorphism(via, false, []shape.ValueFilter{shape.Kind{Kind: kind}}))
+	return np
+}
+
+// HasDegree limits the paths to be ones where the current nodes have at least min and at most max
+// outgoing edges via a given predicate. Zero max means there is no upper limit.
+//
+// For example:
+//  // Will return []string{"B"} if "B" follows at least two nodes, while "A" follows only one.
+//  StartPath(qs, "A", "B").HasDegree("follows", 2, 0)
+func (p *Path) HasDegree(via interface{}, min, max int64) *Path {
+	np := p.clone()
+	np.stack = append(np.stack, hasDegreeMorphism(via, min, max))
+	return np
+}
+
 // LabelContext restricts the following operations (such as In, Out) to only
 // traverse edges that match the given set of labels.
 func (p *Path) LabelContext(via ...interface{}) *Path {
@@ -474,6 +677,23 @@ func (p *Path) LabelContext(via ...interface{}) *Path {
 	return np
 }
 
+// InLabels restricts the following operations (such as In, Out) to only traverse edges
+// that belong to any of the given named graphs. It's the same as LabelContext, but accepts label values directly.
+//
+// For example:
+//  // Will return []string{"B", "C"} if "A" follows "B" in graph "G1",
+//  // follows "C" in graph "G2", and follows "D" in graph "G3".
+//  StartPath(qs, "A").InLabels("G1", "G2").Out("follows")
+func (p *Path) InLabels(labels ...quad.Value) *Path {
+	via := make([]interface{}, 0, len(labels))
+	for _, l := range labels {
+		via = append(via, l)
+	}
+	np := p.clone()
+	np.stack = append(np.stack, labelContextMorphism(nil, via...))
+	return np
+}
+
 // LabelContextWithTags is exactly like LabelContext, except it tags the value
 // of the label used in the traversal with the tags provided.
 func (p *Path) LabelContextWithTags(tags []string, via ...interface{}) *Path {
@@ -544,7 +764,20 @@ func (p *Path) Skip(v int64) *Path {
 }
 
 func (p *Path) Order() *Path {
-	p.stack = append(p.stack, orderMorphism())
+	p.stack = append(p.stack, orderMorphism(false))
+	return p
+}
+
+// OrderDesc is the same as Order, but sorts results in descending order.
+func (p *Path) OrderDesc() *Path {
+	p.stack = append(p.stack, orderMorphism(true))
+	return p
+}
+
+// WithRowNumber stores a 1-based position of each result in the ordered output into a given tag.
+// It should be used after Order; unordered paths will be ordered implicitly.
+func (p *Path) WithRowNumber(tag string) *Path {
+	p.stack = append(p.stack, rowNumberMorphism(tag))
 	return p
 }
 
@@ -560,6 +793,13 @@ func (p *Path) Count() *Path {
 	return p
 }
 
+// PathCount will count a number of paths that lead to the results as it's own result set.
+// Unlike Count, each node is counted once for every path that reaches it.
+func (p *Path) PathCount() *Path {
+	p.stack = append(p.stack, pathCountMorphism())
+	return p
+}
+
 // Iterate is an shortcut for graph.Iterate.
 func (p *Path) Iterate(ctx context.Context) *iterator.Chain {
 	return shape.Iterate(ctx, p.qs, p.Shape())
@@ -568,8 +808,18 @@ func (p *Path) Shape() shape.Shape {
 	return p.ShapeFrom(shape.AllNodes{})
 }
 func (p *Path) ShapeFrom(from shape.Shape) shape.Shape {
+	return p.shapeFromContext(from, nil)
+}
+
+// shapeFromContext is the same as ShapeFrom, but inherits the label context of the outer path,
+// unless the path sets its own.
+func (p *Path) shapeFromContext(from shape.Shape, outer *pathContext) shape.Shape {
 	s := from
-	ctx := &p.baseContext
+	base := p.baseContext.copy()
+	if base.labelSet == nil && outer != nil {
+		base.labelSet = outer.labelSet
+	}
+	ctx := &base
 	for _, m := range p.stack {
 		s, ctx = m.Apply(s, ctx)
 	}
@@ -15,12 +15,42 @@
 package path_test
 
 import (
+	"context"
 	"testing"
 
-	_ "github.com/cayleygraph/cayley/graph/memstore"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cayleygraph/cayley/graph/memstore"
+	"github.com/cayleygraph/cayley/query/path"
 	"github.com/cayleygraph/cayley/query/path/pathtest"
+	"github.com/cayleygraph/quad"
 )
 
 func TestMorphisms(t *testing.T) {
 	pathtest.RunTestMorphisms(t, nil)
 }
+
+func TestCountPaths(t *testing.T) {
+	ctx := context.TODO()
+	qs := memstore.New(
+		quad.MakeIRI("dani", "follows", "bob", ""),
+		quad.MakeIRI("dani", "follows", "greg", ""),
+	)
+	// dani is a single result with two paths - one for each follows quad
+	start := func() *path.Path {
+		return path.StartPath(qs).Has(quad.IRI("follows"), quad.IRI("bob")).Save(quad.IRI("follows"), "target")
+	}
+	for _, opt := range []bool{true, false} {
+		run := func(p *path.Path) []quad.Value {
+			it := p.Iterate(ctx)
+			if !opt {
+				it = it.UnOptimized()
+			}
+			out, err := it.AllValues(qs)
+			require.NoError(t, err)
+			return out
+		}
+		require.Equal(t, []quad.Value{quad.Int(1)}, run(start().Count()), "optimized: %v", opt)
+		require.Equal(t, []quad.Value{quad.Int(2)}, run(start().PathCount()), "optimized: %v", opt)
+	}
+}
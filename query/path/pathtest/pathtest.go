@@ -29,6 +29,8 @@ import (
 	"github.com/cayleygraph/cayley/query/shape"
 	_ "github.com/cayleygraph/cayley/writer"
 	"github.com/cayleygraph/quad"
+	"github.com/cayleygraph/quad/voc/rdfs"
+	"github.com/cayleygraph/quad/voc/xsd"
 	"github.com/stretchr/testify/require"
 )
 
@@ -170,6 +172,23 @@ func testSet(qs graph.QuadStore) []test {
 			path:    path.StartPath(qs, vBob).In(),
 			expect:  []quad.Value{vAlice, vCharlie, vDani},
 		},
+		{
+			message: "both",
+			path:    path.StartPath(qs, vFred).Both(vFollows),
+			expect:  []quad.Value{vBob, vGreg, vEmily},
+		},
+		{
+			message: "both with tags",
+			path:    path.StartPath(qs, vFred).BothWithTags([]string{"pred"}),
+			tag:     "pred",
+			expect:  []quad.Value{vFollows, vFollows, vFollows},
+		},
+		{
+			message: "both with side",
+			path:    path.StartPath(qs, vFred).BothWithSide("side", vFollows),
+			tag:     "side",
+			expect:  []quad.Value{quad.String("in"), quad.String("in"), quad.String("out")},
+		},
 		{
 			message: "filter nodes",
 			path:    path.StartPath(qs).Filter(iterator.CompareGT, quad.IRI("p")),
@@ -243,6 +262,11 @@ func testSet(qs graph.QuadStore) []test {
 			path:    path.StartPath(qs, vAlice, vBob, vCharlie).Except(path.StartPath(qs, vBob)).Except(path.StartPath(qs, vAlice)),
 			expect:  []quad.Value{vCharlie},
 		},
+		{
+			message: "approximate Except",
+			path:    path.StartPath(qs, vAlice, vBob, vCharlie).ExceptApprox(path.StartPath(qs, vBob).In(vFollows), 0.01),
+			expect:  []quad.Value{vBob},
+		},
 		{
 			message: "Unique",
 			path:    path.StartPath(qs, vAlice, vBob, vCharlie).Out(vFollows).Unique(),
@@ -345,11 +369,71 @@ func testSet(qs graph.QuadStore) []test {
 			path:    path.StartPath(qs).Has(vStatus).Count(),
 			expect:  []quad.Value{quad.Int(5)},
 		},
+		{
+			message: "Count with Has values",
+			path:    path.StartPath(qs).Has(vStatus, vCool).Count(),
+			expect:  []quad.Value{quad.Int(3)},
+		},
+		{
+			message: "PathCount",
+			path:    path.StartPath(qs, vDani).Save(vFollows, "target").PathCount(),
+			expect:  []quad.Value{quad.Int(2)},
+		},
+		{
+			message: "Count nodes with multiple paths",
+			path:    path.StartPath(qs, vDani).Save(vFollows, "target").Count(),
+			expect:  []quad.Value{quad.Int(1)},
+		},
+		{
+			message: "PathCount empty traversal",
+			path:    path.StartPath(qs, vAlice).In(vFollows).PathCount(),
+			expect:  []quad.Value{quad.Int(0)},
+		},
+		{
+			message: "Count empty traversal",
+			path:    path.StartPath(qs, vAlice).In(vFollows).Count(),
+			expect:  []quad.Value{quad.Int(0)},
+		},
 		{
 			message: "double Has",
 			path:    path.StartPath(qs).Has(vStatus, vCool).Has(vFollows, vFred),
 			expect:  []quad.Value{vBob},
 		},
+		{
+			message: "has with IRI objects",
+			path:    path.StartPath(qs).HasKind(vFollows, shape.KindIRI).Unique(),
+			expect:  []quad.Value{vAlice, vBob, vCharlie, vDani, vEmily, vFred},
+		},
+		{
+			message: "has with literal objects",
+			path:    path.StartPath(qs).HasKind(vStatus, shape.KindLiteral).Unique(),
+			expect:  []quad.Value{vBob, vDani, vEmily, vGreg},
+		},
+		{
+			message: "has with literal objects on IRI predicate",
+			path:    path.StartPath(qs).HasKind(vFollows, shape.KindLiteral),
+			expect:  nil,
+		},
+		{
+			message: "has degree of at least two",
+			path:    path.StartPath(qs).HasDegree(vFollows, 2, 0),
+			expect:  []quad.Value{vCharlie, vDani},
+		},
+		{
+			message: "has degree in range",
+			path:    path.StartPath(qs).HasDegree(vFollows, 1, 1),
+			expect:  []quad.Value{vAlice, vBob, vEmily, vFred},
+		},
+		{
+			message: "has any degree",
+			path:    path.StartPath(qs).HasDegree(vFollows, 0, 0),
+			expect:  []quad.Value{vAlice, vBob, vCharlie, vDani, vEmily, vFred},
+		},
+		{
+			message: "has degree on a subset",
+			path:    path.StartPath(qs, vAlice, vCharlie).HasDegree(vFollows, 2, 3),
+			expect:  []quad.Value{vCharlie},
+		},
 		{
 			message: "simple HasReverse",
 			path:    path.StartPath(qs).HasReverse(vStatus, vBob),
@@ -404,6 +488,49 @@ func testSet(qs graph.QuadStore) []test {
 			path:    path.StartPath(qs, vFred).FollowReverse(grandfollows),
 			expect:  []quad.Value{vAlice, vCharlie, vDani},
 		},
+		{
+			message: "predicate object types of strings",
+			path:    path.StartPath(qs, vGreg).PredicateObjectTypes(),
+			expect:  []quad.Value{quad.IRI(xsd.String)},
+		},
+		{
+			message: "predicate object types of strings (predicates)",
+			path:    path.StartPath(qs, vGreg).PredicateObjectTypes(),
+			tag:     path.PredicateTag,
+			expect:  []quad.Value{vStatus},
+		},
+		{
+			message: "predicate object types of IRIs",
+			path:    path.StartPath(qs, vAlice).PredicateObjectTypes(),
+			expect:  []quad.Value{quad.IRI(rdfs.Resource)},
+		},
+		{
+			message: "predicate object types of IRIs (predicates)",
+			path:    path.StartPath(qs, vAlice).PredicateObjectTypes(),
+			tag:     path.PredicateTag,
+			expect:  []quad.Value{vFollows},
+		},
+		{
+			message: "predicate object types of all nodes",
+			path:    path.StartPath(qs).PredicateObjectTypes(),
+			tag:     path.PredicateTag,
+			expect:  []quad.Value{vAre, vFollows, vStatus},
+		},
+		{
+			message: "use out with a regexp on predicates",
+			path:    path.StartPath(qs, vBob).OutRegexp(regexp.MustCompile(`foll`)),
+			expect:  []quad.Value{vFred},
+		},
+		{
+			message: "use out with a regexp matching multiple predicates",
+			path:    path.StartPath(qs, vBob).OutRegexp(regexp.MustCompile(`(follows|status)`)),
+			expect:  []quad.Value{vFred, vCool},
+		},
+		{
+			message: "use in with a regexp on predicates",
+			path:    path.StartPath(qs, vBob).InRegexp(regexp.MustCompile(`follows`)),
+			expect:  []quad.Value{vAlice, vCharlie, vDani},
+		},
 		// Context tests
 		{
 			message: "query without label limitation",
@@ -415,11 +542,78 @@ func testSet(qs graph.QuadStore) []test {
 			path:    path.StartPath(qs, vGreg).LabelContext(vSmartGraph).Out(vStatus),
 			expect:  []quad.Value{vSmart},
 		},
+		{
+			message: "two hops with label limitation",
+			path:    path.StartPath(qs, vGreg).LabelContext(vSmartGraph).Out(vStatus).In(vStatus),
+			expect:  []quad.Value{vEmily, vGreg},
+		},
+		{
+			message: "follow with label limitation",
+			path:    path.StartPath(qs, vGreg).LabelContext(vSmartGraph).Follow(path.StartMorphism().Out(vStatus)),
+			expect:  []quad.Value{vSmart},
+		},
+		{
+			message: "follow reverse with label limitation",
+			path:    path.StartPath(qs, vSmart).LabelContext(vSmartGraph).FollowReverse(path.StartMorphism().Out(vStatus)),
+			expect:  []quad.Value{vEmily, vGreg},
+		},
+		{
+			message: "follow with own label limitation",
+			path:    path.StartPath(qs, vGreg).LabelContext(vSmartGraph).Follow(path.StartMorphism().LabelContext().Out(vStatus)),
+			expect:  []quad.Value{vCool, vSmart},
+		},
 		{
 			message: "reverse context",
 			path:    path.StartPath(qs, vGreg).Tag("base").LabelContext(vSmartGraph).Out(vStatus).Tag("status").Back("base"),
 			expect:  []quad.Value{vGreg},
 		},
+		{
+			message: "save label of out",
+			path:    path.StartPath(qs, vEmily).Out(vStatus).SaveLabel("graph"),
+			tag:     "graph",
+			expect:  []quad.Value{vSmartGraph},
+		},
+		{
+			message: "save label of in",
+			path:    path.StartPath(qs, vSmart).In(vStatus).SaveLabel("graph"),
+			tag:     "graph",
+			expect:  []quad.Value{vSmartGraph, vSmartGraph},
+		},
+		{
+			message: "save label with label limitation",
+			path:    path.StartPath(qs, vGreg).LabelContext(vSmartGraph).Out(vStatus).SaveLabel("graph"),
+			tag:     "graph",
+			expect:  []quad.Value{vSmartGraph},
+		},
+		{
+			message: "save label of both",
+			path:    path.StartPath(qs, vSmart).Both(vStatus).SaveLabel("graph"),
+			tag:     "graph",
+			expect:  []quad.Value{vSmartGraph, vSmartGraph},
+		},
+		{
+			message: "use out with a source filter",
+			path:    path.StartPath(qs).OutFiltered(path.StartMorphism().Filter(iterator.CompareGT, quad.IRI("c")), vFollows).Unique(),
+			expect:  []quad.Value{vBob, vDani, vFred, vGreg},
+		},
+		{
+			message: "use out with a source filter in reverse",
+			path: path.StartPath(qs, vBob).Follow(
+				path.StartMorphism().OutFiltered(path.StartPath(qs, vCharlie), vFollows).Reverse(),
+			),
+			expect: []quad.Value{vCharlie},
+		},
+		{
+			message: "connected components",
+			path:    path.StartPath(qs).ConnectedComponents("comp", vFollows),
+			expect:  []quad.Value{vAlice, vBob, vCharlie, vDani, vEmily, vFred, vGreg},
+		},
+		{
+			message: "connected components of given nodes",
+			path:    path.StartPath(qs, vAlice, vGreg, vCool).ConnectedComponents("comp", vFollows),
+			tag:     "comp",
+			expect:  []quad.Value{quad.Int(0), quad.Int(0)},
+		},
 		// Optional tests
 		{
 			message: "save limits top level",
@@ -457,6 +651,21 @@ func testSet(qs graph.QuadStore) []test {
 			path:    path.StartPath(qs, vCharlie).FollowRecursive(vFollows, 1, nil),
 			expect:  []quad.Value{vBob, vDani},
 		},
+		{
+			message: "follow closure",
+			path:    path.StartPath(qs, vCharlie).Closure(vFollows, 0, nil),
+			expect:  []quad.Value{vBob, vDani, vFred, vGreg},
+		},
+		{
+			message: "follow closure (limit depth)",
+			path:    path.StartPath(qs, vCharlie).Closure(vFollows, 1, nil),
+			expect:  []quad.Value{vBob, vDani},
+		},
+		{
+			message: "follow closure in reverse",
+			path:    path.StartPath(qs, vFred).FollowReverse(path.StartMorphism().Closure(vFollows, 0, nil)),
+			expect:  []quad.Value{vAlice, vBob, vCharlie, vDani, vEmily},
+		},
 		{
 			message: "find non-existent",
 			path:    path.StartPath(qs, quad.IRI("<not-existing>")),
@@ -509,12 +718,24 @@ func testSet(qs graph.QuadStore) []test {
 			tag:     "target",
 			expect:  []quad.Value{vBob, vFred, vGreg},
 		},
+		{
+			message:  "use order with row numbers",
+			path:     path.StartPath(qs, vDani, vAlice, vCharlie).Order().WithRowNumber("row"),
+			tag:      "row",
+			expect:   []quad.Value{quad.Int(1), quad.Int(2), quad.Int(3)},
+			unsorted: true,
+		},
+		{
+			message:  "use descending order",
+			path:     path.StartPath(qs, vDani, vAlice, vCharlie).OrderDesc(),
+			expect:   []quad.Value{vDani, vCharlie, vAlice},
+			unsorted: true,
+		},
 		{
 			message:  "order with a next path",
 			path:     path.StartPath(qs).Order().Has(vFollows, vBob),
 			expect:   []quad.Value{vAlice, vCharlie, vDani},
 			unsorted: true,
-			skip:     true, // TODO(dennwc): optimize Order in And properly
 		},
 		{
 			message: "optional path",
@@ -523,6 +744,13 @@ func testSet(qs graph.QuadStore) []test {
 			empty:   true,
 			expect:  []quad.Value{vEmpty, vCool, vCool},
 		},
+		{
+			message: "save concatenated tags",
+			path: path.StartPath(qs, vAlice, vDani).Tag("from").Out(vFollows).Tag("to").
+				SaveTransform("concat", "pair", "from", "to"),
+			tag:    "pair",
+			expect: []quad.Value{quad.String("alicebob"), quad.String("danibob"), quad.String("danigreg")},
+		},
 	}
 }
 
@@ -530,6 +758,9 @@ func RunTestMorphisms(t *testing.T, fnc testutil.DatabaseFunc) {
 	for _, ftest := range []func(*testing.T, testutil.DatabaseFunc){
 		testFollowRecursive,
 		testFollowRecursiveHas,
+		testSaveQuadRef,
+		testInLabels,
+		testQuadCompare,
 	} {
 		ftest(t, fnc)
 	}
@@ -706,3 +937,125 @@ func testFollowRecursiveHas(t *testing.T, fnc testutil.DatabaseFunc) {
 		})
 	}
 }
+
+func testInLabels(t *testing.T, fnc testutil.DatabaseFunc) {
+	mk := func(s, p, o, l string) quad.Quad {
+		return quad.Make(quad.IRI(s), quad.IRI(p), quad.IRI(o), quad.IRI(l))
+	}
+	qs, closer := makeTestStore(t, fnc, []quad.Quad{
+		mk("a", "follows", "b", "g1"),
+		mk("a", "follows", "c", "g2"),
+		mk("a", "follows", "d", "g3"),
+		quad.MakeIRI("a", "follows", "e", ""),
+		mk("b", "follows", "f", "g1"),
+		mk("b", "follows", "h", "g3"),
+		mk("c", "follows", "g", "g2"),
+		mk("c", "follows", "i", "g3"),
+	}...)
+	defer closer()
+
+	from := path.StartPath(qs, quad.IRI("a")).InLabels(quad.IRI("g1"), quad.IRI("g2"))
+	for _, c := range []struct {
+		message string
+		path    *path.Path
+		expect  []quad.Value
+	}{
+		{
+			message: "in labels",
+			path:    from.Out(quad.IRI("follows")),
+			expect:  []quad.Value{quad.IRI("b"), quad.IRI("c")},
+		},
+		{
+			message: "two hops in labels",
+			path:    from.Out(quad.IRI("follows")).Out(quad.IRI("follows")),
+			expect:  []quad.Value{quad.IRI("f"), quad.IRI("g")},
+		},
+	} {
+		for _, opt := range []bool{true, false} {
+			unopt := ""
+			if !opt {
+				unopt = " (unoptimized)"
+			}
+			t.Run(c.message+unopt, func(t *testing.T) {
+				got, err := runTopLevel(qs, c.path, opt)
+				require.NoError(t, err)
+				sort.Sort(quad.ByValueString(got))
+				require.Equal(t, c.expect, got)
+			})
+		}
+	}
+}
+
+func testQuadCompare(t *testing.T, fnc testutil.DatabaseFunc) {
+	qs, closer := makeTestStore(t, fnc, []quad.Quad{
+		quad.MakeIRI("a", "follows", "b", ""),
+		quad.MakeIRI("b", "follows", "b", ""),
+		quad.MakeIRI("b", "follows", "c", ""),
+		quad.MakeIRI("c", "follows", "a", ""),
+	}...)
+	defer closer()
+
+	const msg = "self loops"
+	p := path.StartPath(qs).Out(quad.IRI("follows")).
+		QuadCompare(quad.Subject, iterator.CompareEQ, quad.Object)
+	for _, opt := range []bool{true, false} {
+		unopt := ""
+		if !opt {
+			unopt = " (unoptimized)"
+		}
+		t.Run(msg+unopt, func(t *testing.T) {
+			got, err := runTopLevel(qs, p, opt)
+			require.NoError(t, err)
+			require.Equal(t, []quad.Value{quad.IRI("b")}, got)
+		})
+	}
+}
+
+func testSaveQuadRef(t *testing.T, fnc testutil.DatabaseFunc) {
+	qs, closer := makeTestStore(t, fnc)
+	defer closer()
+
+	qu := path.StartPath(qs, vBob, vDani).Out(vStatus).SaveQuadRef("link")
+
+	expect := []quad.Quad{
+		{Subject: vBob, Predicate: vStatus, Object: vCool},
+		{Subject: vDani, Predicate: vStatus, Object: vCool},
+	}
+
+	const msg = "save quad ref"
+
+	for _, opt := range []bool{true, false} {
+		unopt := ""
+		if !opt {
+			unopt = " (unoptimized)"
+		}
+		t.Run(msg+unopt, func(t *testing.T) {
+			pb := qu.Iterate(context.TODO())
+			if !opt {
+				pb = pb.UnOptimized()
+			}
+			var got []quad.Quad
+			err := pb.Paths(true).TagEach(func(tags map[string]graph.Ref) error {
+				ref, ok := tags["link"]
+				require.True(t, ok, "quad ref was not tagged")
+				q, err := qs.Quad(ref)
+				if err != nil {
+					return err
+				}
+				// labels are not relevant here
+				q.Label = nil
+				got = append(got, q)
+				return nil
+			})
+			require.NoError(t, err)
+			sort.Slice(got, func(i, j int) bool {
+				return got[i].String() < got[j].String()
+			})
+			require.Equal(t, expect, got)
+		})
+	}
+	t.Run(msg+" without traversal", func(t *testing.T) {
+		_, err := path.StartPath(qs, vBob).SaveQuadRef("link").Iterate(context.TODO()).All()
+		require.Error(t, err)
+	})
+}
@@ -22,6 +22,7 @@ import (
 	"io"
 
 	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/quad"
 )
 
 var ErrParseMore = errors.New("query: more input required")
@@ -67,6 +68,12 @@ const (
 type Options struct {
 	Limit     int
 	Collation Collation
+	// Stable forces a stable ordering of results: repeated execution of the same query on the same data
+	// will return results in the same order. It requires sorting all results, thus it's disabled by default.
+	Stable bool
+	// Params provides values for named query parameters. It's only used by query languages
+	// that support parameters, like LinkedQL.
+	Params map[string]quad.Value
 }
 
 type Session interface {
@@ -77,6 +84,12 @@ type Session interface {
 
 type REPLSession = Session
 
+// WriteTracker is an optional interface for sessions that cache query results between executions.
+// Writes done through the returned quad writer invalidate these caches.
+type WriteTracker interface {
+	TrackWrites(w graph.QuadWriter) graph.QuadWriter
+}
+
 // ResponseWriter is a subset of http.ResponseWriter
 type ResponseWriter interface {
 	Write([]byte) (int, error)
@@ -25,6 +25,7 @@ import (
 	"github.com/cayleygraph/cayley/graph"
 	"github.com/cayleygraph/cayley/graph/iterator"
 	"github.com/cayleygraph/cayley/query"
+	"github.com/cayleygraph/cayley/query/shape"
 )
 
 const Name = "sexp"
@@ -78,6 +79,7 @@ func (s *Session) Execute(ctx context.Context, input string, opt query.Options)
 	default:
 		return nil, &query.ErrUnsupportedCollation{Collation: opt.Collation}
 	}
+	ctx = shape.WithStableOrder(ctx, opt.Stable)
 	it := BuildIteratorTreeForQuery(ctx, s.qs, input).Iterate()
 	if err := it.Err(); err != nil {
 		return nil, err
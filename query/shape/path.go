@@ -2,9 +2,12 @@ package shape
 
 import (
 	"context"
+	"errors"
+	"regexp"
 
 	"github.com/cayleygraph/cayley/graph"
 	"github.com/cayleygraph/cayley/graph/iterator"
+	"github.com/cayleygraph/cayley/graph/refs"
 	"github.com/cayleygraph/quad"
 )
 
@@ -56,7 +59,7 @@ func UnionShapes(s1, s2 Shape) Union {
 	return Union{s1, s2}
 }
 
-func buildOut(from, via, labels Shape, tags []string, in bool) Shape {
+func buildOut(from, filter, via, labels Shape, tags []string, in bool) Shape {
 	start, goal := quad.Subject, quad.Object
 	if in {
 		start, goal = goal, start
@@ -71,6 +74,13 @@ func buildOut(from, via, labels Shape, tags []string, in bool) Shape {
 			Dir: start, Values: from,
 		})
 	}
+	if filter != nil {
+		if _, ok := filter.(AllNodes); !ok {
+			quads = append(quads, QuadFilter{
+				Dir: start, Values: filter,
+			})
+		}
+	}
 	if _, ok := via.(AllNodes); !ok {
 		quads = append(quads, QuadFilter{
 			Dir: quad.Predicate, Values: via,
@@ -87,21 +97,130 @@ func buildOut(from, via, labels Shape, tags []string, in bool) Shape {
 }
 
 func Out(from, via, labels Shape, tags ...string) Shape {
-	return buildOut(from, via, labels, tags, false)
+	return buildOut(from, nil, via, labels, tags, false)
+}
+
+// OutFiltered is the same as Out, but only follows edges from nodes that are also in the filter shape.
+// The filter is applied to the same quads as the traversal, thus it adds no additional shapes
+// around the source nodes.
+func OutFiltered(from, filter, via, labels Shape, tags ...string) Shape {
+	return buildOut(from, filter, via, labels, tags, false)
 }
 
 func In(from, via, labels Shape, tags ...string) Shape {
-	return buildOut(from, via, labels, tags, true)
+	return buildOut(from, nil, via, labels, tags, true)
 }
 
-// InWithTags, OutWithTags, Both, BothWithTags
+// predicatesMatching returns a set of predicates that match a given regexp.
+func predicatesMatching(re *regexp.Regexp) Shape {
+	return AddFilters(AllNodes{}, Regexp{Re: re, Refs: true})
+}
+
+// OutRegexp is the same as Out, but follows all predicates that match a given regexp.
+func OutRegexp(from Shape, re *regexp.Regexp, labels Shape, tags ...string) Shape {
+	return buildOut(from, nil, predicatesMatching(re), labels, tags, false)
+}
+
+// InRegexp is the same as In, but follows all predicates that match a given regexp.
+func InRegexp(from Shape, re *regexp.Regexp, labels Shape, tags ...string) Shape {
+	return buildOut(from, nil, predicatesMatching(re), labels, tags, true)
+}
+
+// Both follows edges in both directions. It is a union of In and Out traversals,
+// with predicates saved into the same tags.
+func Both(from, via, labels Shape, tags ...string) Shape {
+	if len(tags) != 0 {
+		via = Save{From: via, Tags: tags}
+	}
+	return Union{
+		buildOut(from, nil, via, labels, nil, true),
+		buildOut(from, nil, via, labels, nil, false),
+	}
+}
+
+// BothSides is like Both, but also sets sideTag to "in" for nodes reached by following quads in reverse,
+// and to "out" for nodes reached by following them forward.
+func BothSides(from, via, labels Shape, sideTag string, tags ...string) Shape {
+	if sideTag == "" {
+		return Both(from, via, labels, tags...)
+	}
+	if len(tags) != 0 {
+		via = Save{From: via, Tags: tags}
+	}
+	side := func(name string, in bool) Shape {
+		return FixedTags{
+			Tags: map[string]refs.Ref{sideTag: refs.PreFetched(quad.String(name))},
+			On:   buildOut(from, nil, via, labels, nil, in),
+		}
+	}
+	return Union{
+		side("in", true),
+		side("out", false),
+	}
+}
+
+// InWithTags, OutWithTags
+
+var errNoTraversal = errors.New("no traversal to save quads from")
+
+// SaveQuads tags quads matched by the last traversal in the shape, instead of nodes projected from them.
+// Values of these tags are quad references that can be resolved with QuadStore.Quad.
+//
+// Traversal is recognized as a NodesFrom shape, possibly wrapped into Union, Unique or Save.
+// An Error shape is returned if there is no traversal on top, since quads cannot be tagged.
+func SaveQuads(from Shape, tags ...string) Shape {
+	if len(tags) == 0 || IsNull(from) {
+		return from
+	}
+	switch s := from.(type) {
+	case NodesFrom:
+		s.Quads = Save{From: s.Quads, Tags: tags}
+		return s
+	case Save:
+		s.From = SaveQuads(s.From, tags...)
+		return s
+	case Unique:
+		s.From = SaveQuads(s.From, tags...)
+		return s
+	case Union:
+		arr := make(Union, 0, len(s))
+		for _, sub := range s {
+			arr = append(arr, SaveQuads(sub, tags...))
+		}
+		return arr
+	}
+	return Error{Err: errNoTraversal}
+}
+
+// CompareQuads filters quads traversed by the last Out, In or Both step, by comparing
+// values in two directions of each quad.
+func CompareQuads(from Shape, a quad.Direction, op iterator.Operator, b quad.Direction) Shape {
+	switch s := from.(type) {
+	case NodesFrom:
+		s.Quads = QuadCompare{Quads: s.Quads, A: a, Op: op, B: b}
+		return s
+	case Save:
+		s.From = CompareQuads(s.From, a, op, b)
+		return s
+	case Unique:
+		s.From = CompareQuads(s.From, a, op, b)
+		return s
+	case Union:
+		arr := make(Union, 0, len(s))
+		for _, sub := range s {
+			arr = append(arr, CompareQuads(sub, a, op, b))
+		}
+		return arr
+	}
+	return from
+}
 
 func Predicates(from Shape, in bool) Shape {
 	dir := quad.Subject
 	if in {
 		dir = quad.Object
 	}
-	return Unique{NodesFrom{
+	return Unique{From: NodesFrom{
 		Quads: Quads{
 			{Dir: dir, Values: from},
 		},
@@ -128,8 +247,52 @@ func SavePredicates(from Shape, in bool, tag string) Shape {
 	return IntersectShapes(from, save)
 }
 
+// PredicateObjectTypes returns distinct types of objects for each predicate used by quads with subjects from a given shape.
+// Predicates are saved into predTag and types are saved into typeTag. Results of the query are types.
+func PredicateObjectTypes(from Shape, predTag, typeTag string) Shape {
+	quads := make(Quads, 0, 2)
+	if _, ok := from.(AllNodes); !ok {
+		quads = append(quads, QuadFilter{
+			Dir: quad.Subject, Values: from,
+		})
+	}
+	quads = append(quads, QuadFilter{
+		Dir: quad.Predicate, Values: Save{From: AllNodes{}, Tags: []string{predTag}},
+	})
+	types := Save{
+		From: ValueType{From: NodesFrom{Quads: quads, Dir: quad.Object}},
+		Tags: []string{typeTag},
+	}
+	return UniqueBy{From: types, Tags: []string{predTag, typeTag}}
+}
+
+// SaveEdges tags predicates and nodes on the other end of either forward or reverse edges of current nodes.
+// Both tags are taken from the same quad.
+func SaveEdges(from Shape, in bool, predTag, nodeTag string) Shape {
+	start, goal := quad.Subject, quad.Object
+	if in {
+		start, goal = goal, start
+	}
+	var save Shape = NodesFrom{
+		Quads: Quads{
+			{Dir: quad.Predicate, Values: Save{From: AllNodes{}, Tags: []string{predTag}}},
+			{Dir: goal, Values: Save{From: AllNodes{}, Tags: []string{nodeTag}}},
+		},
+		Dir: start,
+	}
+	return IntersectShapes(from, save)
+}
+
+// ConnectedComponents labels current nodes with ids of connected components they belong to.
+// Components are found in a subgraph of edges with given predicates, regardless of edge direction.
+// Nodes without such edges are excluded. Component ids are saved as quad.Int values.
+func ConnectedComponents(from, via, labels Shape, tags ...string) Shape {
+	edges := Out(Save{From: AllNodes{}, Tags: []string{componentsSrcTag}}, via, labels)
+	return IntersectShapes(from, Components{Edges: edges, Tags: tags})
+}
+
 func Labels(from Shape) Shape {
-	return Unique{NodesFrom{
+	return Unique{From: NodesFrom{
 		Quads: Union{
 			Quads{
 				{Dir: quad.Subject, Values: from},
@@ -142,6 +305,41 @@ func Labels(from Shape) Shape {
 	}}
 }
 
+// SaveLabel saves labels of quads that were followed to get from nodes into given tags.
+// It only affects shapes produced by Out, In or Both, other shapes are returned unchanged.
+func SaveLabel(from Shape, tags ...string) Shape {
+	switch s := from.(type) {
+	case NodesFrom:
+		q, ok := s.Quads.(Quads)
+		if !ok {
+			return from
+		}
+		nq := make(Quads, 0, len(q)+1)
+		saved := false
+		for _, f := range q {
+			if f.Dir == quad.Label {
+				f.Values = Save{From: f.Values, Tags: tags}
+				saved = true
+			}
+			nq = append(nq, f)
+		}
+		if !saved {
+			nq = append(nq, QuadFilter{
+				Dir: quad.Label, Values: Save{From: AllNodes{}, Tags: tags},
+			})
+		}
+		s.Quads = nq
+		return s
+	case Union:
+		out := make(Union, 0, len(s))
+		for _, sub := range s {
+			out = append(out, SaveLabel(sub, tags...))
+		}
+		return out
+	}
+	return from
+}
+
 func SaveVia(from, via Shape, tag string, rev, opt bool) Shape {
 	return SaveViaLabels(from, via, AllNodes{}, tag, rev, opt)
 }
@@ -179,7 +377,7 @@ func SaveViaLabels(from, via, labels Shape, tag string, rev, opt bool) Shape {
 }
 
 func Has(from, via, nodes Shape, rev bool) Shape {
-	return HasLabels(from, via, AllNodes{}, nodes, rev)
+	return HasLabels(from, via, nodes, AllNodes{}, rev)
 }
 
 func HasLabels(from, via, nodes, labels Shape, rev bool) Shape {
@@ -214,6 +412,28 @@ func HasLabels(from, via, nodes, labels Shape, rev bool) Shape {
 	})
 }
 
+// HasDegree limits from to nodes that are subjects of at least min and at most max quads with a given predicate.
+// Zero max means there is no upper limit.
+func HasDegree(from, via, labels Shape, min, max int64) Shape {
+	quads := make(Quads, 0, 2)
+	if _, ok := via.(AllNodes); !ok {
+		quads = append(quads, QuadFilter{
+			Dir: quad.Predicate, Values: via,
+		})
+	}
+	if labels != nil {
+		if _, ok := labels.(AllNodes); !ok {
+			quads = append(quads, QuadFilter{
+				Dir: quad.Label, Values: labels,
+			})
+		}
+	}
+	return IntersectShapes(from, Degree{
+		Quads: quads, Dir: quad.Subject,
+		Min: min, Max: max,
+	})
+}
+
 func AddFilters(nodes Shape, filters ...ValueFilter) Shape {
 	if len(filters) == 0 {
 		return nodes
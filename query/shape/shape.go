@@ -2,9 +2,11 @@ package shape
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/cayleygraph/cayley/clog"
@@ -36,6 +38,24 @@ type Optimizer interface {
 	OptimizeShape(ctx context.Context, s Shape) (Shape, bool)
 }
 
+// Sizer is an optional interface for shapes that can estimate a number of results without building iterators.
+type Sizer interface {
+	// Size returns an estimated number of results and a flag indicating if the size is exact.
+	// QuadStore may be nil, in which case only static shapes can report their size.
+	Size(ctx context.Context, qs graph.QuadStore) (int64, bool)
+}
+
+// quadStoreOf returns a QuadStore the optimizer is bound to, or nil if it's unknown.
+func quadStoreOf(r Optimizer) graph.QuadStore {
+	switch r := r.(type) {
+	case resolveValues:
+		return r.qs
+	case graph.QuadStore:
+		return r
+	}
+	return nil
+}
+
 // Composite shape can be simplified to a tree of more basic shapes.
 type Composite interface {
 	Simplify() Shape
@@ -51,7 +71,7 @@ type resolveValues struct {
 
 func (r resolveValues) OptimizeShape(ctx context.Context, s Shape) (Shape, bool) {
 	if l, ok := s.(Lookup); ok {
-		lv, err := l.resolve(r.qs)
+		lv, err := l.resolve(ctx, r.qs)
 		if err == nil {
 			return lv, true
 		}
@@ -208,10 +228,28 @@ func IsNull(s Shape) bool {
 	return s == nil || ok
 }
 
+type stableOrderCtxKey struct{}
+
+// WithStableOrder returns a context that makes BuildIterator sort all results to get a stable ordering:
+// repeated execution of the same query on the same data will return results in the same order.
+func WithStableOrder(ctx context.Context, stable bool) context.Context {
+	return context.WithValue(ctx, stableOrderCtxKey{}, stable)
+}
+
+func isStableOrder(ctx context.Context) bool {
+	stable, _ := ctx.Value(stableOrderCtxKey{}).(bool)
+	return stable
+}
+
 // BuildIterator optimizes the shape and builds a corresponding iterator tree.
+//
+// If the context was created with WithStableOrder, results of the iterator are sorted.
 func BuildIterator(ctx context.Context, qs graph.QuadStore, s Shape) iterator.Shape {
 	qs = graph.Unwrap(qs)
 	if s != nil {
+		if isStableOrder(ctx) {
+			s = stableOrder(s)
+		}
 		if debugShapes || clog.V(2) {
 			clog.Infof("shape: %#v", s)
 		}
@@ -226,6 +264,104 @@ func BuildIterator(ctx context.Context, qs graph.QuadStore, s Shape) iterator.Sh
 	return s.BuildIterator(qs)
 }
 
+// stableOrder makes the order of results of a shape reproducible.
+// Existing Sort shapes are reused, and pages are taken from the sorted results.
+func stableOrder(s Shape) Shape {
+	switch s := s.(type) {
+	case Sort:
+		s.Stable = true
+		return s
+	case Page:
+		s.From = stableOrder(s.From)
+		return s
+	}
+	return Sort{From: s, Stable: true}
+}
+
+// Describe renders a shape tree as an indented text, one shape per line.
+//
+// Simple fields of each shape are printed on the same line, while nested shapes are
+// printed on separate lines with an additional indentation.
+func Describe(s Shape) string {
+	var buf strings.Builder
+	describe(&buf, "", reflect.ValueOf(s), 0)
+	return buf.String()
+}
+
+var (
+	shapeType    = reflect.TypeOf((*Shape)(nil)).Elem()
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// isNested checks if a value should be described on a separate line.
+func isNested(v reflect.Value) bool {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	if v.Type().Implements(shapeType) {
+		return true
+	} else if v.Type().Implements(stringerType) {
+		return false
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return true
+	case reflect.Slice, reflect.Array:
+		switch v.Type().Elem().Kind() {
+		case reflect.Struct, reflect.Interface, reflect.Ptr:
+			return true
+		}
+	}
+	return false
+}
+
+func describe(buf *strings.Builder, label string, v reflect.Value, depth int) {
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr) && !v.IsNil() {
+		v = v.Elem()
+	}
+	buf.WriteString(strings.Repeat("  ", depth))
+	buf.WriteString(label)
+	if !v.IsValid() || ((v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr) && v.IsNil()) {
+		buf.WriteString("nil\n")
+		return
+	}
+	if !isNested(v) {
+		fmt.Fprintf(buf, "%v\n", v.Interface())
+		return
+	}
+	buf.WriteString(v.Type().Name())
+	if v.Kind() != reflect.Struct {
+		buf.WriteString("\n")
+		for i := 0; i < v.Len(); i++ {
+			describe(buf, "", v.Index(i), depth+1)
+		}
+		return
+	}
+	t := v.Type()
+	var nested []int
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+		fv := v.Field(i)
+		if fv.IsZero() {
+			continue
+		} else if isNested(fv) {
+			nested = append(nested, i)
+			continue
+		}
+		fmt.Fprintf(buf, " %s=%v", f.Name, fv.Interface())
+	}
+	buf.WriteString("\n")
+	for _, i := range nested {
+		describe(buf, t.Field(i).Name+": ", v.Field(i), depth+1)
+	}
+}
+
 // Null represent an empty set. Mostly used as a safe alias for nil shape.
 type Null struct{}
 
@@ -239,6 +375,21 @@ func (s Null) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
 	return nil, true
 }
 
+// Error is a shape that fails with a given error when iterated.
+type Error struct {
+	Err error
+}
+
+func (s Error) BuildIterator(qs graph.QuadStore) iterator.Shape {
+	return iterator.NewError(s.Err)
+}
+func (s Error) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
+	if r != nil {
+		return r.OptimizeShape(ctx, s)
+	}
+	return s, false
+}
+
 // AllNodes represents all nodes in QuadStore.
 type AllNodes struct{}
 
@@ -251,11 +402,27 @@ func (s AllNodes) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
 	}
 	return s, false
 }
+func (s AllNodes) Size(ctx context.Context, qs graph.QuadStore) (int64, bool) {
+	if qs == nil {
+		return 0, false
+	}
+	st, err := qs.Stats(ctx, false)
+	if err != nil {
+		return 0, false
+	}
+	return st.Nodes.Value, st.Nodes.Exact
+}
 
 // Except excludes a set on nodes from a source. If source is nil, AllNodes is assumed.
+//
+// If FalsePositiveRate is set, the exclusion will be approximate: a bloom filter will be built from the
+// excluded set instead of keeping it in memory. In this case it may exclude more nodes than expected, but
+// it never returns nodes from the excluded set. This should only be used for very large exclusion sets.
 type Except struct {
 	Exclude Shape // nodes to exclude
 	From    Shape // a set of all nodes to exclude from; nil means AllNodes
+
+	FalsePositiveRate float64 // if set, exclusion is approximate; see iterator.NotApprox
 }
 
 func (s Except) BuildIterator(qs graph.QuadStore) iterator.Shape {
@@ -268,6 +435,9 @@ func (s Except) BuildIterator(qs graph.QuadStore) iterator.Shape {
 	if IsNull(s.Exclude) {
 		return all
 	}
+	if s.FalsePositiveRate > 0 {
+		return iterator.NewNotApprox(s.Exclude.BuildIterator(qs), all, s.FalsePositiveRate)
+	}
 	return iterator.NewNot(s.Exclude.BuildIterator(qs), all)
 }
 func (s Except) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
@@ -277,16 +447,24 @@ func (s Except) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
 		var opta bool
 		s.From, opta = s.From.Optimize(ctx, r)
 		opt = opt || opta
+		if IsNull(s.From) {
+			// nothing to exclude from
+			return nil, true
+		}
+	}
+	if _, ok := s.Exclude.(AllNodes); ok {
+		// everything is excluded
+		return nil, true
+	} else if IsNull(s.Exclude) {
+		if s.From == nil {
+			return AllNodes{}, true
+		}
+		return s.From, true
 	}
 	if r != nil {
 		ns, nopt := r.OptimizeShape(ctx, s)
 		return ns, opt || nopt
 	}
-	if IsNull(s.Exclude) {
-		return AllNodes{}, true
-	} else if _, ok := s.Exclude.(AllNodes); ok {
-		return nil, true
-	}
 	return s, opt
 }
 
@@ -405,6 +583,63 @@ func (f Wildcard) BuildIterator(qs graph.QuadStore, it iterator.Shape) iterator.
 	return iterator.NewRegexWithRefs(it, re, qs)
 }
 
+// ValueKind is a kind of node values: IRI, blank node or literal.
+type ValueKind int
+
+const (
+	KindIRI     = ValueKind(iota + 1) // quad.IRI values
+	KindBNode                         // quad.BNode values
+	KindLiteral                       // all other values
+)
+
+// KindOf returns a kind of the value.
+func KindOf(v quad.Value) ValueKind {
+	switch v.(type) {
+	case nil:
+		return 0
+	case quad.IRI:
+		return KindIRI
+	case quad.BNode:
+		return KindBNode
+	}
+	return KindLiteral
+}
+
+var _ ValueFilter = Kind{}
+
+// Kind filters values of a specific kind.
+type Kind struct {
+	Kind ValueKind
+}
+
+func (f Kind) BuildIterator(qs graph.QuadStore, it iterator.Shape) iterator.Shape {
+	return iterator.NewValueFilter(qs, it, func(v quad.Value) (bool, error) {
+		return KindOf(v) == f.Kind, nil
+	})
+}
+
+var _ ValueFilter = ValueSet{}
+
+// ValueSet filters values that are equal to one of the given values.
+type ValueSet struct {
+	Values  []quad.Value
+	Exclude bool // keep only values that are not in the set
+}
+
+func (f ValueSet) BuildIterator(qs graph.QuadStore, it iterator.Shape) iterator.Shape {
+	if len(f.Values) == 0 {
+		if f.Exclude {
+			return it
+		}
+		return iterator.NewNull()
+	}
+	set := Lookup(f.Values).BuildIterator(qs)
+	if f.Exclude {
+		return iterator.NewNot(set, it)
+	}
+	return iterator.NewAnd(it, set)
+}
+
 // Count returns a count of objects in source as a single value. It always returns exactly one value.
 type Count struct {
 	Values Shape
@@ -420,6 +655,44 @@ func (s Count) BuildIterator(qs graph.QuadStore) iterator.Shape {
 	return iterator.NewCount(it, qs)
 }
 func (s Count) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
+	if IsNull(s.Values) {
+		return Fixed{refs.PreFetched(quad.Int(0))}, true
+	}
+	var opt bool
+	s.Values, opt = s.Values.Optimize(ctx, r)
+	if IsNull(s.Values) {
+		return Fixed{refs.PreFetched(quad.Int(0))}, true
+	}
+	// if the store knows an exact size, there is no need to iterate
+	if sz, ok := s.Values.(Sizer); ok {
+		if n, exact := sz.Size(ctx, quadStoreOf(r)); exact {
+			return Fixed{refs.PreFetched(quad.Int(n))}, true
+		}
+	}
+	if r != nil {
+		ns, nopt := r.OptimizeShape(ctx, s)
+		return ns, opt || nopt
+	}
+	return s, opt
+}
+
+// PathCount returns a number of paths that lead to objects in source as a single value.
+// Unlike Count, each object is counted as many times as there are paths to reach it.
+// It always returns exactly one value.
+type PathCount struct {
+	Values Shape
+}
+
+func (s PathCount) BuildIterator(qs graph.QuadStore) iterator.Shape {
+	var it iterator.Shape
+	if IsNull(s.Values) {
+		it = iterator.NewNull()
+	} else {
+		it = s.Values.BuildIterator(qs)
+	}
+	return iterator.NewPathCount(it, qs)
+}
+func (s PathCount) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
 	if IsNull(s.Values) {
 		return Fixed{refs.PreFetched(quad.Int(0))}, true
 	}
@@ -432,7 +705,6 @@ func (s Count) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
 		ns, nopt := r.OptimizeShape(ctx, s)
 		return ns, opt || nopt
 	}
-	// TODO: ask QS to estimate size - if it exact, then we can use it
 	return s, opt
 }
 
@@ -488,7 +760,30 @@ func (s Quads) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
 			s = nq
 		}
 	}
-	// TODO: multiple constraints on the same dir -> merge as Intersect on Values of this dir
+	// multiple constraints on the same dir are merged as Intersect on Values of this dir
+	for i := 0; i < len(s); i++ {
+		if s[i].Values == nil {
+			return nil, true
+		}
+		for j := 0; j < i; j++ {
+			if s[i].Dir != s[j].Dir {
+				continue
+			}
+			realloc()
+			// the same constraint may appear multiple times after merging quad filters,
+			// for example a label filter from a LabelContext is added to every traversal
+			if !equalShapes(s[i].Values, s[j].Values) {
+				if in, ok := s[j].Values.(Intersect); ok {
+					s[j].Values = append(in[:len(in):len(in)], s[i].Values)
+				} else {
+					s[j].Values = Intersect{s[j].Values, s[i].Values}
+				}
+			}
+			s = append(s[:i], s[i+1:]...)
+			i--
+			break
+		}
+	}
 	for i := 0; i < len(s); i++ {
 		f := s[i]
 		if f.Values == nil {
@@ -516,6 +811,27 @@ func (s Quads) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
 	return s, opt
 }
 
+// hasSave checks if any of the filters tags the quad directions.
+func (s Quads) hasSave() bool {
+	for _, f := range s {
+		if _, ok := f.Values.(Save); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Quads) Size(ctx context.Context, qs graph.QuadStore) (int64, bool) {
+	if len(s) != 0 || qs == nil {
+		return 0, false
+	}
+	st, err := qs.Stats(ctx, false)
+	if err != nil {
+		return 0, false
+	}
+	return st.Quads.Value, st.Quads.Exact
+}
+
 // NodesFrom extracts nodes on a given direction from source quads. Similar to HasA iterator.
 type NodesFrom struct {
 	Dir   quad.Direction
@@ -532,6 +848,13 @@ func (s NodesFrom) BuildIterator(qs graph.QuadStore) iterator.Shape {
 	}
 	return graph.NewHasA(qs, sub, s.Dir)
 }
+func (s NodesFrom) Size(ctx context.Context, qs graph.QuadStore) (int64, bool) {
+	// each quad produces exactly one node
+	if sz, ok := s.Quads.(Sizer); ok {
+		return sz.Size(ctx, qs)
+	}
+	return 0, false
+}
 func (s NodesFrom) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
 	if IsNull(s.Quads) {
 		return nil, true
@@ -620,6 +943,46 @@ func (s NodesFrom) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
 
 var _ Composite = QuadsAction{}
 
+// Degree selects nodes on a given direction of source quads, that are linked to at least Min and at most Max quads.
+// Zero Max means there is no upper limit. See iterator.Degree for details.
+type Degree struct {
+	Quads Shape
+	Dir   quad.Direction
+	Min   int64
+	Max   int64
+}
+
+func (s Degree) BuildIterator(qs graph.QuadStore) iterator.Shape {
+	if IsNull(s.Quads) {
+		return iterator.NewNull()
+	}
+	if s.Dir == quad.Any {
+		panic("direction is not set")
+	}
+	return iterator.NewDegree(qs, s.Quads.BuildIterator(qs), s.Dir, s.Min, s.Max)
+}
+func (s Degree) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
+	if IsNull(s.Quads) {
+		return nil, true
+	} else if s.Max > 0 && s.Max < s.Min {
+		return nil, true
+	}
+	var opt bool
+	s.Quads, opt = s.Quads.Optimize(ctx, r)
+	if IsNull(s.Quads) {
+		return nil, true
+	}
+	if s.Min <= 1 && s.Max == 0 {
+		// any node that has a quad passes the filter
+		return Unique{From: NodesFrom{Quads: s.Quads, Dir: s.Dir}}.Optimize(ctx, r)
+	}
+	if r != nil {
+		ns, nopt := r.OptimizeShape(ctx, s)
+		return ns, opt || nopt
+	}
+	return s, opt
+}
+
 // QuadsAction represents a set of actions that can be done to a set of quads in a single scan pass.
 // It filters quads according to Filter constraints (equivalent of LinksTo), tags directions using tags in Save field
 // and returns a specified quad direction as result of the iterator (equivalent of HasA).
@@ -669,6 +1032,24 @@ func (s QuadsAction) simplify() NodesFrom {
 	}
 	return NodesFrom{Dir: s.Result, Quads: q}
 }
+
+// simplifyWith converts QuadsAction to an equivalent NodesFrom tree with additional quad filters.
+// Unlike simplify, filters are sorted by direction.
+func (s QuadsAction) simplifyWith(filters ...QuadFilter) NodesFrom {
+	q := make(Quads, 0, len(filters)+len(s.Save)+len(s.Filter))
+	q = append(q, filters...)
+	for _, dir := range quad.Directions {
+		if val, ok := s.Filter[dir]; ok {
+			q = append(q, QuadFilter{Dir: dir, Values: Fixed{val}})
+		}
+	}
+	for _, dir := range quad.Directions {
+		if tags := s.Save[dir]; len(tags) != 0 {
+			q = append(q, QuadFilter{Dir: dir, Values: Save{From: AllNodes{}, Tags: tags}})
+		}
+	}
+	return NodesFrom{Dir: s.Result, Quads: q}
+}
 func (s QuadsAction) SimplifyFrom(quads Shape) Shape {
 	q := make(Quads, 0, len(s.Save))
 	for dir, tags := range s.Save {
@@ -766,6 +1147,9 @@ func (s Fixed) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
 	}
 	return s, false
 }
+func (s Fixed) Size(ctx context.Context, qs graph.QuadStore) (int64, bool) {
+	return int64(len(s)), true
+}
 
 // FixedTags adds a set of fixed tag values to query results. It does not affect query execution in any other way.
 //
@@ -824,16 +1208,28 @@ type valueResolver interface {
 	ValueOf(v quad.Value) (refs.Ref, error)
 }
 
-func (s Lookup) resolve(qs valueResolver) (Shape, error) {
-	// TODO: check if QS supports batch lookup
+func (s Lookup) resolve(ctx context.Context, qs valueResolver) (Shape, error) {
 	vals := make([]refs.Ref, 0, len(s))
-	for _, v := range s {
-		gv, err := qs.ValueOf(v)
+	if bq, ok := qs.(refs.BatchNamer); ok {
+		// resolve all values in a single request; missing values are nil
+		rs, err := bq.RefsOf(ctx, s)
 		if err != nil {
 			return nil, err
 		}
-		if gv != nil {
-			vals = append(vals, gv)
+		for _, gv := range rs {
+			if gv != nil {
+				vals = append(vals, gv)
+			}
+		}
+	} else {
+		for _, v := range s {
+			gv, err := qs.ValueOf(v)
+			if err != nil {
+				return nil, err
+			}
+			if gv != nil {
+				vals = append(vals, gv)
+			}
 		}
 	}
 	if len(vals) == 0 {
@@ -842,7 +1238,7 @@ func (s Lookup) resolve(qs valueResolver) (Shape, error) {
 	return Fixed(vals), nil
 }
 func (s Lookup) BuildIterator(qs graph.QuadStore) iterator.Shape {
-	f, err := s.resolve(qs)
+	f, err := s.resolve(context.TODO(), qs)
 	if err != nil {
 		return iterator.NewError(err)
 	}
@@ -851,6 +1247,10 @@ func (s Lookup) BuildIterator(qs graph.QuadStore) iterator.Shape {
 	}
 	return f.BuildIterator(qs)
 }
+func (s Lookup) Size(ctx context.Context, qs graph.QuadStore) (int64, bool) {
+	// some values may not exist in the store
+	return int64(len(s)), false
+}
 func (s Lookup) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
 	if r == nil {
 		return s, false
@@ -860,7 +1260,7 @@ func (s Lookup) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
 		return ns, true
 	}
 	if qs, ok := r.(valueResolver); ok {
-		res, err := s.resolve(qs)
+		res, err := s.resolve(ctx, qs)
 		if err == nil {
 			ns, opt = res, true
 		}
@@ -868,6 +1268,81 @@ func (s Lookup) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
 	return ns, opt
 }
 
+// equalShapes checks if two shapes are structurally equal.
+func equalShapes(a, b Shape) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+// SharedNode holds a sub-shape that is referenced by one or more Shared shapes.
+type SharedNode struct {
+	Values Shape
+	it     iterator.Shape // built on the first use
+}
+
+// Shared is a sub-shape that is used in multiple branches of the query. All Shared shapes
+// that point to the same node are built into a single materialized iterator,
+// thus the sub-query is executed only once.
+type Shared struct {
+	Node *SharedNode
+}
+
+func (s Shared) BuildIterator(qs graph.QuadStore) iterator.Shape {
+	n := s.Node
+	if n.it == nil {
+		if IsNull(n.Values) {
+			n.it = iterator.NewNull()
+		} else {
+			n.it = iterator.NewMaterializeShared(n.Values.BuildIterator(qs))
+		}
+	}
+	return n.it
+}
+func (s Shared) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
+	n := s.Node
+	if IsNull(n.Values) {
+		return nil, true
+	}
+	// node is shared, thus all references will see the optimized shape
+	var opt bool
+	n.Values, opt = n.Values.Optimize(ctx, r)
+	if IsNull(n.Values) {
+		return nil, true
+	}
+	return s, opt
+}
+
+// shareEqual replaces structurally equal members of the list with Shared shapes pointing to the same node,
+// so the sub-query will be built and executed only once. Trivial shapes are not shared.
+func shareEqual(arr []Shape) ([]Shape, bool) {
+	var out []Shape
+	for i := 1; i < len(arr); i++ {
+		switch arr[i].(type) {
+		case nil, Null, AllNodes, Fixed, Lookup, Shared:
+			continue
+		}
+		for j := 0; j < i; j++ {
+			if !equalShapes(arr[i], arr[j]) {
+				continue
+			}
+			if out == nil {
+				out = make([]Shape, len(arr))
+				copy(out, arr)
+			}
+			sh, ok := out[j].(Shared)
+			if !ok {
+				sh = Shared{Node: &SharedNode{Values: arr[j]}}
+				out[j] = sh
+			}
+			out[i] = sh
+			break
+		}
+	}
+	if out == nil {
+		return arr, false
+	}
+	return out, true
+}
+
 var MaterializeThreshold = 100 // TODO: tune
 
 // Materialize loads results of sub-query into memory during execution to speedup iteration.
@@ -915,6 +1390,31 @@ func clearFixedTags(arr []Shape) ([]Shape, map[string]refs.Ref) {
 	return arr, tags
 }
 
+// clearUnionFixedTags is like clearFixedTags, but only pops tags if all shapes have the same fixed tags.
+// Otherwise, values of the tags depend on the branch of the union, and the shapes are returned unchanged.
+func clearUnionFixedTags(arr []Shape) ([]Shape, map[string]refs.Ref) {
+	var first map[string]refs.Ref
+	for i, sub := range arr {
+		ft, ok := sub.(FixedTags)
+		if !ok {
+			return arr, nil
+		}
+		if i == 0 {
+			first = ft.Tags
+			continue
+		}
+		if len(ft.Tags) != len(first) {
+			return arr, nil
+		}
+		for k, v := range ft.Tags {
+			if v2, ok := first[k]; !ok || refs.ToKey(v) != refs.ToKey(v2) {
+				return arr, nil
+			}
+		}
+	}
+	return clearFixedTags(arr)
+}
+
 // Intersect computes an intersection of nodes between multiple queries. Similar to And iterator.
 type Intersect []Shape
 
@@ -922,14 +1422,31 @@ func (s Intersect) BuildIterator(qs graph.QuadStore) iterator.Shape {
 	if len(s) == 0 {
 		return iterator.NewNull()
 	}
-	sub := make([]iterator.Shape, 0, len(s))
+	var (
+		sub     = make([]iterator.Shape, 0, len(s))
+		exclude []iterator.Shape
+	)
 	for _, c := range s {
+		if m, ok := c.(Minus); ok {
+			exclude = append(exclude, m.Exclude.BuildIterator(qs))
+			continue
+		}
 		sub = append(sub, c.BuildIterator(qs))
 	}
-	if len(sub) == 1 {
-		return sub[0]
+	var it iterator.Shape
+	switch len(sub) {
+	case 0:
+		it = qs.NodesAllIterator()
+	case 1:
+		it = sub[0]
+	default:
+		it = iterator.NewAnd(sub...)
+	}
+	// negative members are applied to the intersection of all other members
+	for _, ex := range exclude {
+		it = iterator.NewNot(ex, it)
 	}
-	return iterator.NewAnd(sub...)
+	return it
 }
 func (s Intersect) Optimize(ctx context.Context, r Optimizer) (sout Shape, opt bool) {
 	if len(s) == 0 {
@@ -961,6 +1478,9 @@ func (s Intersect) Optimize(ctx context.Context, r Optimizer) (sout Shape, opt b
 		s[i] = v
 	}
 	if r != nil {
+		if ns, ok := s.sortBySize(ctx, quadStoreOf(r)); ok {
+			s, opt = ns, true
+		}
 		ns, nopt := r.OptimizeShape(ctx, s)
 		return ns, opt || nopt
 	}
@@ -975,6 +1495,8 @@ func (s Intersect) Optimize(ctx context.Context, r Optimizer) (sout Shape, opt b
 		tags     []string // if we find a Save inside, we will push it outside of Intersect
 		quads    Quads    // also, collect all quad filters into a single set
 		optional []Shape
+		minus    []Shape // negative members will be applied to the rest of the intersection
+		order    *Sort   // sorting has no effect inside And, thus it will be applied to the whole intersection
 	)
 	remove := func(i *int, optimized bool) {
 		realloc()
@@ -994,6 +1516,10 @@ func (s Intersect) Optimize(ctx context.Context, r Optimizer) (sout Shape, opt b
 			remove(&i, true)
 			hasAll = true
 			continue // prevent resetting of onlyAll
+		case Minus: // collect all negative members
+			remove(&i, false)
+			minus = append(minus, c.Exclude)
+			continue // only negative members means all nodes except them
 		case Quads: // merge all quad filters
 			remove(&i, false)
 			if quads == nil {
@@ -1018,12 +1544,49 @@ func (s Intersect) Optimize(ctx context.Context, r Optimizer) (sout Shape, opt b
 			tags = append(tags, c.Tags...)
 			s[i] = c.From
 			i--
+		case Sort: // push Sort outside of Intersect
+			if len(c.RowTags) != 0 {
+				break // row numbers depend on the sorted subset
+			}
+			realloc()
+			opt = true
+			if order == nil {
+				order = &Sort{Desc: c.Desc}
+			}
+			s[i] = c.From
+			i--
 		}
 		onlyAll = false
 	}
+	if arr, ok := shareEqual(s); ok {
+		s, opt = Intersect(arr), true
+	}
+	var exclude Shape
+	if len(minus) == 1 {
+		exclude = minus[0]
+	} else if len(minus) > 1 {
+		opt = true
+		exclude = Union(minus)
+	}
 	if onlyAll {
+		if exclude != nil {
+			return Minus{Exclude: exclude}, true
+		}
 		return AllNodes{}, true
 	}
+	if order != nil {
+		// sort the result of the intersection; should run after Save is moved outside
+		defer func() {
+			if IsNull(sout) {
+				return
+			}
+			so := *order
+			so.From = sout
+			var topt bool
+			sout, topt = so.Optimize(ctx, r)
+			opt = opt || topt
+		}()
+	}
 	if len(tags) != 0 {
 		// don't forget to move Save outside of Intersect at the end
 		defer func() {
@@ -1053,6 +1616,23 @@ func (s Intersect) Optimize(ctx context.Context, r Optimizer) (sout Shape, opt b
 			opt = opt || topt
 		}()
 	}
+	if exclude != nil {
+		// add negative members back, after all other optimizations are done
+		defer func() {
+			if IsNull(sout) {
+				return
+			}
+			m := Minus{Exclude: exclude}
+			switch so := sout.(type) {
+			case AllNodes:
+				sout = m
+			case Intersect:
+				sout = append(so[:len(so):len(so)], m)
+			default:
+				sout = Intersect{sout, m}
+			}
+		}()
+	}
 	if quads != nil {
 		nq, qopt := quads.Optimize(ctx, r)
 		if IsNull(nq) {
@@ -1061,13 +1641,23 @@ func (s Intersect) Optimize(ctx context.Context, r Optimizer) (sout Shape, opt b
 		opt = opt || qopt
 		s = append(s, nq)
 	}
-	// TODO: intersect fixed
+	if len(fixed) > 1 {
+		fixed = []Fixed{intersectFixed(fixed)}
+		if len(fixed[0]) == 0 {
+			return nil, true
+		}
+	}
 	if len(fixed) == 1 {
 		fix := fixed[0]
 		if len(s) == 1 {
 			// try to push fixed down the tree
 			switch sf := s[0].(type) {
 			case QuadsAction:
+				if len(sf.Save) != 0 {
+					// tagged quads of a fixed node are alternative paths to it; pushing fixed down
+					// would return each of them as a separate result
+					break
+				}
 				// TODO: accept an array of Fixed values
 				if len(fix) == 1 {
 					// we have a single value in Fixed that is intersected with HasA tree
@@ -1086,9 +1676,13 @@ func (s Intersect) Optimize(ctx context.Context, r Optimizer) (sout Shape, opt b
 					sf.Size = 0                 // re-calculate size
 					ns, _ := sf.Optimize(ctx, r)
 					return ns, true
+				} else if _, ok := sf.Filter[sf.Result]; !ok {
+					// multiple values in Fixed can't be baked into QuadsAction, but we still can
+					// add them as a constraint on the result direction: LinksTo(HasA.Dir, fixed)
+					return sf.simplifyWith(QuadFilter{Dir: sf.Result, Values: fix}), true
 				}
 			case NodesFrom:
-				if sq, ok := sf.Quads.(Quads); ok {
+				if sq, ok := sf.Quads.(Quads); ok && !sq.hasSave() {
 					// an optimization above is valid for NodesFrom+Quads as well
 					// we can add the same constraint to Quads and remove Fixed
 					qi := -1
@@ -1118,13 +1712,6 @@ func (s Intersect) Optimize(ctx context.Context, r Optimizer) (sout Shape, opt b
 		s = append(s, nil)
 		copy(s[1:], s)
 		s[0] = fix
-	} else if len(fixed) > 1 {
-		ns := make(Intersect, len(s)+len(fixed))
-		for i, f := range fixed {
-			ns[i] = f
-		}
-		copy(ns[len(fixed):], s)
-		s = ns
 	}
 	if len(s) == 0 {
 		if hasAll {
@@ -1134,7 +1721,87 @@ func (s Intersect) Optimize(ctx context.Context, r Optimizer) (sout Shape, opt b
 	} else if len(s) == 1 {
 		return s[0], true
 	}
-	// TODO: optimize order
+	return s, opt
+}
+
+// sortBySize orders members of the intersection by their estimated size, so the smallest one drives the iteration.
+// Members are reordered only if all of them can report their size.
+func (s Intersect) sortBySize(ctx context.Context, qs graph.QuadStore) (Intersect, bool) {
+	if len(s) < 2 || qs == nil {
+		return s, false
+	}
+	sizes := make([]int64, len(s))
+	for i, c := range s {
+		sz, ok := c.(Sizer)
+		if !ok {
+			return s, false
+		}
+		n, exact := sz.Size(ctx, qs)
+		if n == 0 && !exact {
+			return s, false // size is unknown
+		}
+		sizes[i] = n
+	}
+	if sort.SliceIsSorted(sizes, func(i, j int) bool { return sizes[i] < sizes[j] }) {
+		return s, false
+	}
+	idx := make([]int, len(s))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool { return sizes[idx[i]] < sizes[idx[j]] })
+	out := make(Intersect, len(s))
+	for i, j := range idx {
+		out[i] = s[j]
+	}
+	return out, true
+}
+
+// intersectFixed returns values of the first Fixed set that are present in all other sets.
+// The order of values is preserved.
+func intersectFixed(sets []Fixed) Fixed {
+	out := sets[0]
+	for _, f := range sets[1:] {
+		seen := make(map[interface{}]struct{}, len(f))
+		for _, v := range f {
+			seen[refs.ToKey(v)] = struct{}{}
+		}
+		var next Fixed
+		for _, v := range out {
+			if _, ok := seen[refs.ToKey(v)]; ok {
+				next = append(next, v)
+			}
+		}
+		out = next
+	}
+	return out
+}
+
+// Minus is a negative member of Intersect that excludes nodes of a given shape from the intersection,
+// which is equivalent to Except, but allows the optimizer to treat it as any other member of Intersect.
+// Outside of Intersect it returns all nodes except the ones from Exclude.
+type Minus struct {
+	Exclude Shape // nodes to exclude
+}
+
+func (s Minus) BuildIterator(qs graph.QuadStore) iterator.Shape {
+	return Except{Exclude: s.Exclude}.BuildIterator(qs)
+}
+func (s Minus) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
+	if IsNull(s.Exclude) {
+		return AllNodes{}, true
+	}
+	var opt bool
+	s.Exclude, opt = s.Exclude.Optimize(ctx, r)
+	if r != nil {
+		ns, nopt := r.OptimizeShape(ctx, s)
+		return ns, opt || nopt
+	}
+	if IsNull(s.Exclude) {
+		return AllNodes{}, true
+	} else if _, ok := s.Exclude.(AllNodes); ok {
+		return nil, true
+	}
 	return s, opt
 }
 
@@ -1284,11 +1951,26 @@ func (s Union) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
 		opt = true
 		s[i] = v
 	}
+	// flatten nested unions
+	var flat Union
+	for i, c := range s {
+		if u, ok := c.(Union); ok {
+			if flat == nil {
+				flat = append(Union{}, s[:i]...)
+			}
+			flat = append(flat, u...)
+		} else if flat != nil {
+			flat = append(flat, c)
+		}
+	}
+	if flat != nil {
+		s, opt = flat, true
+	}
 	if r != nil {
 		ns, nopt := r.OptimizeShape(ctx, s)
 		return ns, opt || nopt
 	}
-	if arr, ft := clearFixedTags([]Shape(s)); ft != nil {
+	if arr, ft := clearUnionFixedTags([]Shape(s)); ft != nil {
 		ns, _ := FixedTags{On: Union(arr), Tags: ft}.Optimize(ctx, r)
 		return ns, true
 	}
@@ -1306,6 +1988,9 @@ func (s Union) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
 	} else if len(s) == 1 {
 		return s[0], true
 	}
+	if arr, ok := shareEqual(s); ok {
+		s, opt = Union(arr), true
+	}
 	// TODO: join Fixed
 	return s, opt
 }
@@ -1330,6 +2015,26 @@ func (s Page) BuildIterator(qs graph.QuadStore) iterator.Shape {
 	}
 	return it
 }
+func (s Page) Size(ctx context.Context, qs graph.QuadStore) (int64, bool) {
+	sz, ok := s.From.(Sizer)
+	if !ok {
+		return 0, false
+	}
+	n, exact := sz.Size(ctx, qs)
+	if !exact {
+		return 0, false
+	}
+	if s.Skip > 0 {
+		n -= s.Skip
+		if n < 0 {
+			n = 0
+		}
+	}
+	if s.Limit > 0 && n > s.Limit {
+		n = s.Limit
+	}
+	return n, true
+}
 func (s Page) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
 	if IsNull(s.From) {
 		return nil, true
@@ -1346,11 +2051,15 @@ func (s Page) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
 		}
 		s, opt = *p2, true
 	}
+	if sz, ok := s.From.(Sizer); ok && s.Skip > 0 {
+		if n, exact := sz.Size(ctx, quadStoreOf(r)); exact && s.Skip >= n {
+			return nil, true
+		}
+	}
 	if r != nil {
 		ns, nopt := r.OptimizeShape(ctx, s)
 		return ns, opt || nopt
 	}
-	// TODO: check size
 	return s, opt
 }
 func (s Page) ApplyPage(p Page) *Page {
@@ -1372,6 +2081,9 @@ func (s Page) ApplyPage(p Page) *Page {
 // Unique makes query results unique.
 type Unique struct {
 	From Shape
+	// Sorted indicates that equal values are adjacent in From, thus only the last value has to be remembered.
+	// It is set by the optimizer when From is sorted.
+	Sorted bool
 }
 
 func (s Unique) BuildIterator(qs graph.QuadStore) iterator.Shape {
@@ -1379,6 +2091,9 @@ func (s Unique) BuildIterator(qs graph.QuadStore) iterator.Shape {
 		return iterator.NewNull()
 	}
 	it := s.From.BuildIterator(qs)
+	if s.Sorted {
+		return iterator.NewUniqueSorted(it)
+	}
 	return iterator.NewUnique(it)
 }
 func (s Unique) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
@@ -1390,6 +2105,144 @@ func (s Unique) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
 	if IsNull(s.From) {
 		return nil, true
 	}
+	if _, ok := s.From.(Sort); ok && !s.Sorted {
+		// equal values are adjacent in the sorted output, no need to remember all of them
+		s.Sorted, opt = true, true
+	}
+	if r != nil {
+		ns, nopt := r.OptimizeShape(ctx, s)
+		return ns, opt || nopt
+	}
+	return s, opt
+}
+
+// UniqueBy makes query results unique by a combination of values of provided tags.
+//
+// Only tags that were saved in the From shape are considered.
+type UniqueBy struct {
+	From Shape
+	Tags []string
+}
+
+func (s UniqueBy) BuildIterator(qs graph.QuadStore) iterator.Shape {
+	if IsNull(s.From) {
+		return iterator.NewNull()
+	}
+	it := s.From.BuildIterator(qs)
+	return iterator.NewUniqueBy(it, s.Tags...)
+}
+func (s UniqueBy) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
+	if IsNull(s.From) {
+		return nil, true
+	}
+	var opt bool
+	s.From, opt = s.From.Optimize(ctx, r)
+	if IsNull(s.From) {
+		return nil, true
+	}
+	if r != nil {
+		ns, nopt := r.OptimizeShape(ctx, s)
+		return ns, opt || nopt
+	}
+	return s, opt
+}
+
+// ExceptBy removes query results with a value of a given tag that is found in the Exclude set.
+// Results without the tag are not removed. See iterator.ExceptBy for details.
+type ExceptBy struct {
+	From    Shape
+	Tag     string
+	Exclude Shape
+}
+
+func (s ExceptBy) BuildIterator(qs graph.QuadStore) iterator.Shape {
+	if IsNull(s.From) {
+		return iterator.NewNull()
+	}
+	it := s.From.BuildIterator(qs)
+	if IsNull(s.Exclude) {
+		return it
+	}
+	return iterator.NewExceptBy(it, s.Tag, s.Exclude.BuildIterator(qs))
+}
+func (s ExceptBy) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
+	if IsNull(s.From) {
+		return nil, true
+	}
+	var opt, opte bool
+	s.From, opt = s.From.Optimize(ctx, r)
+	if IsNull(s.From) {
+		return nil, true
+	}
+	if s.Exclude != nil {
+		s.Exclude, opte = s.Exclude.Optimize(ctx, r)
+	}
+	if IsNull(s.Exclude) {
+		return s.From, true
+	}
+	opt = opt || opte
+	if r != nil {
+		ns, nopt := r.OptimizeShape(ctx, s)
+		return ns, opt || nopt
+	}
+	return s, opt
+}
+
+// ValueType replaces each value of the query with an IRI of its type. See iterator.TypeOf for details.
+type ValueType struct {
+	From Shape
+}
+
+func (s ValueType) BuildIterator(qs graph.QuadStore) iterator.Shape {
+	if IsNull(s.From) {
+		return iterator.NewNull()
+	}
+	it := s.From.BuildIterator(qs)
+	return iterator.NewValueType(qs, it)
+}
+func (s ValueType) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
+	if IsNull(s.From) {
+		return nil, true
+	}
+	var opt bool
+	s.From, opt = s.From.Optimize(ctx, r)
+	if IsNull(s.From) {
+		return nil, true
+	}
+	if r != nil {
+		ns, nopt := r.OptimizeShape(ctx, s)
+		return ns, opt || nopt
+	}
+	return s, opt
+}
+
+// componentsSrcTag is used internally by Components to save sources of edges.
+const componentsSrcTag = "src"
+
+// Components returns all nodes connected by edges and saves ids of connected components they belong to.
+// Edge directions are ignored. See iterator.Components for details.
+type Components struct {
+	// Edges returns targets of edges, while sources of edges are saved into the componentsSrcTag.
+	Edges Shape
+	Tags  []string
+}
+
+func (s Components) BuildIterator(qs graph.QuadStore) iterator.Shape {
+	if IsNull(s.Edges) {
+		return iterator.NewNull()
+	}
+	it := s.Edges.BuildIterator(qs)
+	return iterator.NewComponents(it, componentsSrcTag, s.Tags...)
+}
+func (s Components) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
+	if IsNull(s.Edges) {
+		return nil, true
+	}
+	var opt bool
+	s.Edges, opt = s.Edges.Optimize(ctx, r)
+	if IsNull(s.Edges) {
+		return nil, true
+	}
 	if r != nil {
 		ns, nopt := r.OptimizeShape(ctx, s)
 		return ns, opt || nopt
@@ -1431,6 +2284,37 @@ func (s Save) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
 	return s, opt
 }
 
+// QuadCompare filters quads by comparing values of two directions of the same quad.
+type QuadCompare struct {
+	Quads Shape // quads to filter
+	A     quad.Direction
+	Op    iterator.Operator
+	B     quad.Direction
+}
+
+func (s QuadCompare) BuildIterator(qs graph.QuadStore) iterator.Shape {
+	if IsNull(s.Quads) {
+		return iterator.NewNull()
+	}
+	it := s.Quads.BuildIterator(qs)
+	return iterator.NewQuadComparison(qs, it, s.A, s.Op, s.B)
+}
+func (s QuadCompare) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
+	if IsNull(s.Quads) {
+		return nil, true
+	}
+	var opt bool
+	s.Quads, opt = s.Quads.Optimize(ctx, r)
+	if IsNull(s.Quads) {
+		return nil, true
+	}
+	if r != nil {
+		ns, nopt := r.OptimizeShape(ctx, s)
+		return ns, opt || nopt
+	}
+	return s, opt
+}
+
 func FilterQuads(subject, predicate, object, label []quad.Value) Shape {
 	var q Quads
 	if len(subject) != 0 {
@@ -1450,14 +2334,22 @@ func FilterQuads(subject, predicate, object, label []quad.Value) Shape {
 
 type Sort struct {
 	From Shape
+	// RowTags are used to tag each result with a 1-based position in the ordered output.
+	RowTags []string
+	Desc    bool // sort in descending order
+	Stable  bool // keep the original order of equal values
 }
 
 func (s Sort) BuildIterator(qs graph.QuadStore) iterator.Shape {
 	if IsNull(s.From) {
 		return iterator.NewNull()
 	}
-	it := s.From.BuildIterator(qs)
-	return iterator.NewSort(qs, it)
+	it := iterator.NewSortBy(qs, s.From.BuildIterator(qs), iterator.SortOptions{Stable: s.Stable})
+	it.SetDescending(s.Desc)
+	if len(s.RowTags) != 0 {
+		it.AddRowNumberTags(s.RowTags...)
+	}
+	return it
 }
 func (s Sort) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
 	if IsNull(s.From) {
@@ -1468,6 +2360,16 @@ func (s Sort) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
 	if IsNull(s.From) {
 		return nil, true
 	}
+	if in, ok := s.From.(Sort); ok && len(in.RowTags) == 0 {
+		// only the outer order matters
+		s.From, opt = in.From, true
+	}
+	if sz, ok := s.From.(Sizer); ok && len(s.RowTags) == 0 {
+		// nothing to sort in a single value
+		if n, exact := sz.Size(ctx, quadStoreOf(r)); exact && n <= 1 {
+			return s.From, true
+		}
+	}
 	if r != nil {
 		ns, nopt := r.OptimizeShape(ctx, s)
 		return ns, opt || nopt
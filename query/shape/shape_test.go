@@ -71,7 +71,7 @@ func (ValLookup) NameOf(_ refs.Ref) (quad.Value, error) {
 	panic("not implemented")
 }
 func (ValLookup) Stats(ctx context.Context, exact bool) (graph.Stats, error) {
-	panic("not implemented")
+	return graph.Stats{}, nil // unknown size
 }
 func (ValLookup) Close() error {
 	panic("not implemented")
@@ -107,6 +107,36 @@ var optimizeCases = []struct {
 		opt:    false,
 		expect: AllNodes{},
 	},
+	{
+		name:   "except all nodes",
+		from:   Except{Exclude: AllNodes{}, From: Fixed{intVal(1)}},
+		opt:    true,
+		expect: Null{},
+	},
+	{
+		name:   "except from empty set",
+		from:   Except{Exclude: Fixed{intVal(1)}, From: Intersect{Fixed{intVal(2)}, Null{}}},
+		opt:    true,
+		expect: Null{},
+	},
+	{
+		name:   "except empty set",
+		from:   Except{Exclude: Null{}, From: Fixed{intVal(1)}},
+		opt:    true,
+		expect: Fixed{intVal(1)},
+	},
+	{
+		name:   "except empty set from all nodes",
+		from:   Except{Exclude: Null{}},
+		opt:    true,
+		expect: AllNodes{},
+	},
+	{
+		name:   "except",
+		from:   Except{Exclude: Fixed{intVal(1)}, From: Fixed{intVal(1), intVal(2)}},
+		opt:    false,
+		expect: Except{Exclude: Fixed{intVal(1)}, From: Fixed{intVal(1), intVal(2)}},
+	},
 	{
 		name: "page min limit",
 		from: Page{
@@ -137,6 +167,143 @@ var optimizeCases = []struct {
 			From: AllNodes{},
 		},
 	},
+	{
+		name: "page skip then limit",
+		from: Page{
+			Limit: 3,
+			From: Page{
+				Skip: 2,
+				From: AllNodes{},
+			},
+		},
+		opt: true,
+		expect: Page{
+			Skip: 2, Limit: 3,
+			From: AllNodes{},
+		},
+	},
+	{
+		name: "page limit then skip",
+		from: Page{
+			Skip: 2,
+			From: Page{
+				Limit: 3,
+				From:  AllNodes{},
+			},
+		},
+		opt: true,
+		expect: Page{
+			Skip: 2, Limit: 1,
+			From: AllNodes{},
+		},
+	},
+	{
+		name: "page skip past limit",
+		from: Page{
+			Skip: 5,
+			From: Page{
+				Limit: 3,
+				From:  AllNodes{},
+			},
+		},
+		opt:    true,
+		expect: Null{},
+	},
+	{
+		name:   "collapse nested sort",
+		from:   Sort{From: Sort{From: AllNodes{}}, Desc: true},
+		opt:    true,
+		expect: Sort{From: AllNodes{}, Desc: true},
+	},
+	{
+		name:   "keep nested sort with row numbers",
+		from:   Sort{From: Sort{From: AllNodes{}, RowTags: []string{"row"}}, Desc: true},
+		opt:    false,
+		expect: Sort{From: Sort{From: AllNodes{}, RowTags: []string{"row"}}, Desc: true},
+	},
+	{
+		name:   "sort single value",
+		from:   Sort{From: Fixed{intVal(1)}},
+		opt:    true,
+		expect: Fixed{intVal(1)},
+	},
+	{
+		name: "move sort out of intersect",
+		from: Intersect{
+			Sort{From: NodesFrom{Dir: quad.Subject, Quads: Quads{}}},
+			NodesFrom{Dir: quad.Object, Quads: Quads{}},
+		},
+		opt: true,
+		expect: Sort{From: Intersect{
+			QuadsAction{Result: quad.Subject},
+			QuadsAction{Result: quad.Object},
+		}},
+	},
+	{
+		name:   "unique over sorted values",
+		from:   Unique{From: Sort{From: AllNodes{}}},
+		opt:    true,
+		expect: Unique{From: Sort{From: AllNodes{}}, Sorted: true},
+	},
+	{
+		name:   "unique over unsorted values",
+		from:   Unique{From: AllNodes{}},
+		opt:    false,
+		expect: Unique{From: AllNodes{}},
+	},
+	{
+		name:   "unique over removed sort",
+		from:   Unique{From: Sort{From: Fixed{intVal(1)}}},
+		opt:    true,
+		expect: Unique{From: Fixed{intVal(1)}},
+	},
+	{
+		name: "page skip past fixed",
+		from: Page{
+			Skip: 2,
+			From: Fixed{intVal(1), intVal(2)},
+		},
+		opt:    true,
+		expect: Null{},
+	},
+	{
+		name: "page skip within fixed",
+		from: Page{
+			Skip: 1,
+			From: Fixed{intVal(1), intVal(2)},
+		},
+		opt: false,
+		expect: Page{
+			Skip: 1,
+			From: Fixed{intVal(1), intVal(2)},
+		},
+	},
+	{
+		name: "page skip past lookup",
+		from: Page{
+			Skip: 1,
+			From: Lookup{quad.IRI("a")},
+		},
+		qs:     ValLookup{quad.IRI("a"): intVal(1)},
+		opt:    true,
+		expect: Null{},
+	},
+	{
+		name: "flatten nested unions",
+		from: Union{
+			Union{
+				Fixed{intVal(1)},
+				Fixed{intVal(2)},
+			},
+			Fixed{intVal(3)},
+		},
+		opt: true,
+		expect: Union{
+			Fixed{intVal(1)},
+			Fixed{intVal(2)},
+			Fixed{intVal(3)},
+		},
+	},
 	{
 		name:   "intersect tagged all",
 		from:   Intersect{Save{Tags: []string{"id"}, From: AllNodes{}}},
@@ -170,14 +337,14 @@ var optimizeCases = []struct {
 			NodesFrom{Dir: quad.Subject, Quads: Quads{}},
 			Intersect{
 				Lookup{quad.IRI("alice")},
-				Unique{NodesFrom{Dir: quad.Object, Quads: Quads{}}},
+				Unique{From: NodesFrom{Dir: quad.Object, Quads: Quads{}}},
 			},
 		},
 		opt: true,
 		expect: Intersect{
 			Fixed{intVal(1)},
 			QuadsAction{Result: quad.Subject},
-			Unique{QuadsAction{Result: quad.Object}},
+			Unique{From: QuadsAction{Result: quad.Object}},
 		},
 		qs: ValLookup{
 			quad.IRI("alice"): intVal(1),
@@ -190,14 +357,14 @@ var optimizeCases = []struct {
 				Tags: []string{"id"},
 				From: NodesFrom{Dir: quad.Subject, Quads: Quads{}},
 			},
-			Unique{NodesFrom{Dir: quad.Object, Quads: Quads{}}},
+			Unique{From: NodesFrom{Dir: quad.Object, Quads: Quads{}}},
 		},
 		opt: true,
 		expect: Save{
 			Tags: []string{"id"},
 			From: Intersect{
 				QuadsAction{Result: quad.Subject},
-				Unique{QuadsAction{Result: quad.Object}},
+				Unique{From: QuadsAction{Result: quad.Object}},
 			},
 		},
 	},
@@ -205,7 +372,7 @@ var optimizeCases = []struct {
 		name: "collapse empty set",
 		from: Intersect{Quads{
 			{Dir: quad.Subject, Values: Union{
-				Unique{emptySet()},
+				Unique{From: emptySet()},
 			}},
 		}},
 		opt:    true,
@@ -221,13 +388,51 @@ var optimizeCases = []struct {
 		},
 		opt: true,
 		expect: Save{
-			From: Intersect{
-				Fixed{intVal(1), intVal(2)},
-				Fixed{intVal(2)},
-			},
+			From: Fixed{intVal(2)},
 			Tags: []string{"all"},
 		},
 	},
+	{
+		name:   "intersect fixed",
+		from:   Intersect{Fixed{intVal(1), intVal(2)}, Fixed{intVal(2)}},
+		opt:    true,
+		expect: Fixed{intVal(2)},
+	},
+	{
+		name:   "intersect disjoint fixed",
+		from:   Intersect{Fixed{intVal(1)}, Fixed{intVal(2)}},
+		opt:    true,
+		expect: Null{},
+	},
+	{
+		name: "degree of at least one",
+		from: Degree{Dir: quad.Subject, Min: 1, Quads: Quads{
+			{Dir: quad.Predicate, Values: Fixed{intVal(1)}},
+		}},
+		opt: true,
+		expect: Unique{From: QuadsAction{
+			Result: quad.Subject,
+			Filter: map[quad.Direction]refs.Ref{quad.Predicate: intVal(1)},
+		}},
+	},
+	{
+		name: "degree with inverted range",
+		from: Degree{Dir: quad.Subject, Min: 3, Max: 2, Quads: Quads{
+			{Dir: quad.Predicate, Values: Fixed{intVal(1)}},
+		}},
+		opt:    true,
+		expect: Null{},
+	},
+	{
+		name: "degree range",
+		from: Degree{Dir: quad.Subject, Min: 2, Max: 3, Quads: Quads{
+			{Dir: quad.Predicate, Values: Fixed{intVal(1)}},
+		}},
+		opt: true,
+		expect: Degree{Dir: quad.Subject, Min: 2, Max: 3, Quads: Quads{
+			{Dir: quad.Predicate, Values: Fixed{intVal(1)}},
+		}},
+	},
 	{
 		name: "remove HasA-LinksTo pairs",
 		from: NodesFrom{
@@ -240,6 +445,30 @@ var optimizeCases = []struct {
 		opt:    true,
 		expect: Fixed{intVal(1)},
 	},
+	{
+		name: "pop same fixed tags from union",
+		from: Union{
+			FixedTags{Tags: map[string]refs.Ref{"foo": intVal(1)}, On: Fixed{intVal(2)}},
+			FixedTags{Tags: map[string]refs.Ref{"foo": intVal(1)}, On: Fixed{intVal(3)}},
+		},
+		opt: true,
+		expect: FixedTags{
+			Tags: map[string]refs.Ref{"foo": intVal(1)},
+			On:   Union{Fixed{intVal(2)}, Fixed{intVal(3)}},
+		},
+	},
+	{
+		name: "keep different fixed tags in union",
+		from: Union{
+			FixedTags{Tags: map[string]refs.Ref{"foo": intVal(1)}, On: Fixed{intVal(2)}},
+			FixedTags{Tags: map[string]refs.Ref{"foo": intVal(4)}, On: Fixed{intVal(3)}},
+		},
+		opt: false,
+		expect: Union{
+			FixedTags{Tags: map[string]refs.Ref{"foo": intVal(1)}, On: Fixed{intVal(2)}},
+			FixedTags{Tags: map[string]refs.Ref{"foo": intVal(4)}, On: Fixed{intVal(3)}},
+		},
+	},
 	{ // pop fixed tags to the top of the tree
 		name: "pop fixed tags",
 		from: NodesFrom{Dir: quad.Subject, Quads: Quads{
@@ -325,6 +554,240 @@ var optimizeCases = []struct {
 			},
 		},
 	},
+	{ // has("<status>", "cool_person")
+		name: "has single value",
+		from: Has(AllNodes{}, Lookup{quad.IRI("status")}, Lookup{quad.String("cool_person")}, false),
+		opt:  true,
+		expect: QuadsAction{
+			Result: quad.Subject,
+			Filter: map[quad.Direction]refs.Ref{
+				quad.Predicate: intVal(1),
+				quad.Object:    intVal(2),
+			},
+		},
+		qs: ValLookup{
+			quad.IRI("status"):         intVal(1),
+			quad.String("cool_person"): intVal(2),
+		},
+	},
+	{ // V("<bob>", "<dani>").has("<status>", "cool_person")
+		name: "has single value on fixed nodes",
+		from: Has(
+			Lookup{quad.IRI("bob"), quad.IRI("dani")},
+			Lookup{quad.IRI("status")}, Lookup{quad.String("cool_person")}, false,
+		),
+		opt: true,
+		expect: NodesFrom{
+			Dir: quad.Subject,
+			Quads: Quads{
+				{Dir: quad.Subject, Values: Fixed{intVal(3), intVal(4)}},
+				{Dir: quad.Predicate, Values: Fixed{intVal(1)}},
+				{Dir: quad.Object, Values: Fixed{intVal(2)}},
+			},
+		},
+		qs: ValLookup{
+			quad.IRI("status"):         intVal(1),
+			quad.String("cool_person"): intVal(2),
+			quad.IRI("bob"):            intVal(3),
+			quad.IRI("dani"):           intVal(4),
+		},
+	},
+	{ // V("<dani>").save("<follows>", "target")
+		name: "keep fixed node out of tagged quads",
+		from: SaveViaLabels(Lookup{quad.IRI("dani")}, Lookup{quad.IRI("follows")}, nil, "target", false, false),
+		opt:  true,
+		// tagged quads are alternative paths to a single result, instead of separate results
+		expect: Intersect{
+			Fixed{intVal(2)},
+			QuadsAction{
+				Result: quad.Subject,
+				Save: map[quad.Direction][]string{
+					quad.Object: {"target"},
+				},
+				Filter: map[quad.Direction]refs.Ref{
+					quad.Predicate: intVal(1),
+				},
+			},
+		},
+		qs: ValLookup{
+			quad.IRI("follows"): intVal(1),
+			quad.IRI("dani"):    intVal(2),
+		},
+	},
+	{
+		name: "merge duplicate label filters",
+		from: Intersect{
+			Quads{
+				{Dir: quad.Subject, Values: Lookup{quad.IRI("bob")}},
+				{Dir: quad.Label, Values: Lookup{quad.IRI("ctx")}},
+			},
+			Quads{
+				{Dir: quad.Predicate, Values: Lookup{quad.IRI("follows")}},
+				{Dir: quad.Label, Values: Lookup{quad.IRI("ctx")}},
+			},
+		},
+		opt: true,
+		expect: Quads{
+			{Dir: quad.Subject, Values: Fixed{intVal(1)}},
+			{Dir: quad.Label, Values: Fixed{intVal(3)}},
+			{Dir: quad.Predicate, Values: Fixed{intVal(2)}},
+		},
+		qs: ValLookup{
+			quad.IRI("bob"):     intVal(1),
+			quad.IRI("follows"): intVal(2),
+			quad.IRI("ctx"):     intVal(3),
+		},
+	},
+	{
+		name: "merge filters on the same direction",
+		from: Quads{
+			{Dir: quad.Subject, Values: Fixed{intVal(1), intVal(2)}},
+			{Dir: quad.Predicate, Values: Fixed{intVal(3)}},
+			{Dir: quad.Subject, Values: Fixed{intVal(2)}},
+		},
+		opt: true,
+		expect: Quads{
+			{Dir: quad.Predicate, Values: Fixed{intVal(3)}},
+			{Dir: quad.Subject, Values: Fixed{intVal(2)}},
+		},
+	},
+	{
+		name: "merge filters on the same direction into empty set",
+		from: NodesFrom{
+			Dir: quad.Object,
+			Quads: Quads{
+				{Dir: quad.Subject, Values: Fixed{intVal(1)}},
+				{Dir: quad.Subject, Values: emptySet()},
+			},
+		},
+		opt:    true,
+		expect: Null{},
+	},
+	{
+		name: "two hops under one label context",
+		from: NodesFrom{
+			Dir: quad.Object,
+			Quads: Intersect{
+				Quads{
+					{Dir: quad.Subject, Values: Out(
+						Lookup{quad.IRI("alice")}, Lookup{quad.IRI("follows")}, Lookup{quad.IRI("ctx")},
+					)},
+					{Dir: quad.Label, Values: Lookup{quad.IRI("ctx")}},
+				},
+				Quads{
+					{Dir: quad.Predicate, Values: Lookup{quad.IRI("follows")}},
+					{Dir: quad.Label, Values: Lookup{quad.IRI("ctx")}},
+				},
+			},
+		},
+		opt: true,
+		expect: NodesFrom{
+			Dir: quad.Object,
+			Quads: Quads{
+				{Dir: quad.Label, Values: Fixed{intVal(3)}},
+				{Dir: quad.Predicate, Values: Fixed{intVal(2)}},
+				{Dir: quad.Subject, Values: QuadsAction{
+					Result: quad.Object,
+					Filter: map[quad.Direction]graph.Ref{
+						quad.Subject:   intVal(1),
+						quad.Predicate: intVal(2),
+						quad.Label:     intVal(3),
+					},
+				}},
+			},
+		},
+		qs: ValLookup{
+			quad.IRI("alice"):   intVal(1),
+			quad.IRI("follows"): intVal(2),
+			quad.IRI("ctx"):     intVal(3),
+		},
+	},
+	{
+		name: "out with a source filter",
+		from: OutFiltered(
+			Lookup{quad.IRI("alice"), quad.IRI("bob")},
+			Lookup{quad.IRI("alice")},
+			Lookup{quad.IRI("follows")},
+			nil,
+		),
+		opt: true,
+		expect: QuadsAction{
+			Result: quad.Object,
+			Filter: map[quad.Direction]graph.Ref{
+				quad.Subject:   intVal(1),
+				quad.Predicate: intVal(3),
+			},
+		},
+		qs: ValLookup{
+			quad.IRI("alice"):   intVal(1),
+			quad.IRI("bob"):     intVal(2),
+			quad.IRI("follows"): intVal(3),
+		},
+	},
+	{
+		name: "share equal members of union",
+		from: Union{
+			Out(Lookup{quad.IRI("alice")}, Lookup{quad.IRI("follows")}, nil),
+			Out(Lookup{quad.IRI("alice")}, Lookup{quad.IRI("follows")}, nil),
+		},
+		opt: true,
+		expect: func() Shape {
+			sh := Shared{Node: &SharedNode{Values: QuadsAction{
+				Result: quad.Object,
+				Filter: map[quad.Direction]graph.Ref{
+					quad.Subject:   intVal(1),
+					quad.Predicate: intVal(2),
+				},
+			}}}
+			return Union{sh, sh}
+		}(),
+		qs: ValLookup{
+			quad.IRI("alice"):   intVal(1),
+			quad.IRI("follows"): intVal(2),
+		},
+	},
+	{
+		name: "intersect with a negative member",
+		from: Intersect{
+			Minus{Exclude: Lookup{quad.IRI("bob")}},
+			Has(AllNodes{}, Lookup{quad.IRI("status")}, Lookup{quad.String("cool_person")}, false),
+		},
+		opt: true,
+		expect: Intersect{
+			QuadsAction{
+				Result: quad.Subject,
+				Filter: map[quad.Direction]graph.Ref{
+					quad.Predicate: intVal(1),
+					quad.Object:    intVal(2),
+				},
+			},
+			Minus{Exclude: Fixed{intVal(3)}},
+		},
+		qs: ValLookup{
+			quad.IRI("status"):         intVal(1),
+			quad.String("cool_person"): intVal(2),
+			quad.IRI("bob"):            intVal(3),
+		},
+	},
+	{
+		name: "merge negative members",
+		from: Intersect{
+			Minus{Exclude: Fixed{intVal(1)}},
+			AllNodes{},
+			Minus{Exclude: Fixed{intVal(2)}},
+		},
+		opt:    true,
+		expect: Minus{Exclude: Union{Fixed{intVal(1)}, Fixed{intVal(2)}}},
+	},
+	{
+		name: "negative member excludes everything",
+		from: Intersect{
+			Fixed{intVal(1)},
+			Minus{Exclude: AllNodes{}},
+		},
+		opt:    true,
+		expect: Null{},
+	},
 	{
 		name: "all optional",
 		from: Intersect{IntersectOpt{
@@ -365,6 +828,169 @@ func TestOptimize(t *testing.T) {
 	}
 }
 
+// sizedLookup is a quad store with known graph size.
+type sizedLookup struct {
+	ValLookup
+	nodes, quads refs.Size
+}
+
+func (qs sizedLookup) Stats(ctx context.Context, exact bool) (graph.Stats, error) {
+	return graph.Stats{Nodes: qs.nodes, Quads: qs.quads}, nil
+}
+
+func TestPageSkipSize(t *testing.T) {
+	ctx := context.TODO()
+	qs := sizedLookup{
+		nodes: refs.Size{Value: 3, Exact: true},
+		quads: refs.Size{Value: 5, Exact: true},
+	}
+	all := NodesFrom{Dir: quad.Subject, Quads: Quads{}}
+	for _, c := range []struct {
+		name   string
+		from   Shape
+		expect Shape
+	}{
+		{name: "skip past all nodes", from: Page{Skip: 3, From: AllNodes{}}, expect: Null{}},
+		{name: "skip within all nodes", from: Page{Skip: 2, From: AllNodes{}}, expect: Page{Skip: 2, From: AllNodes{}}},
+		{name: "skip past all quads", from: Page{Skip: 5, From: all}, expect: Null{}},
+		{name: "skip within all quads", from: Page{Skip: 4, From: all}, expect: Page{Skip: 4, From: QuadsAction{Result: quad.Subject}}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			got, _ := Optimize(ctx, c.from, qs)
+			require.Equal(t, c.expect, got)
+		})
+	}
+
+	// inexact sizes should not be used
+	qs.nodes.Exact = false
+	got, _ := Optimize(ctx, Page{Skip: 3, From: AllNodes{}}, qs)
+	require.Equal(t, Page{Skip: 3, From: AllNodes{}}, got)
+}
+
+func TestCountExactSize(t *testing.T) {
+	ctx := context.TODO()
+	qs := sizedLookup{
+		nodes: refs.Size{Value: 3, Exact: true},
+		quads: refs.Size{Value: 5, Exact: true},
+	}
+	got, opt := Optimize(ctx, Count{Values: AllNodes{}}, qs)
+	require.True(t, opt)
+	require.Equal(t, Fixed{refs.PreFetched(quad.Int(3))}, got)
+
+	got, opt = Optimize(ctx, Count{Values: NodesFrom{Dir: quad.Subject, Quads: Quads{}}}, qs)
+	require.True(t, opt)
+	require.Equal(t, Fixed{refs.PreFetched(quad.Int(5))}, got)
+
+	// limit and skip are applied to the exact size
+	got, opt = Optimize(ctx, Count{Values: Page{From: AllNodes{}, Limit: 2}}, qs)
+	require.True(t, opt)
+	require.Equal(t, Fixed{refs.PreFetched(quad.Int(2))}, got)
+
+	got, opt = Optimize(ctx, Count{Values: Page{From: AllNodes{}, Skip: 1, Limit: 10}}, qs)
+	require.True(t, opt)
+	require.Equal(t, Fixed{refs.PreFetched(quad.Int(2))}, got)
+
+	// fall back to counting if the size is not exact
+	qs.nodes.Exact = false
+	got, _ = Optimize(ctx, Count{Values: AllNodes{}}, qs)
+	require.Equal(t, Count{Values: AllNodes{}}, got)
+}
+
+func TestIntersectSortBySize(t *testing.T) {
+	ctx := context.TODO()
+	qs := sizedLookup{
+		nodes: refs.Size{Value: 10, Exact: true},
+		quads: refs.Size{Value: 100, Exact: false},
+	}
+	quads := NodesFrom{Dir: quad.Subject, Quads: Quads{}}
+	fixed := Fixed{intVal(1), intVal(2)}
+
+	got, opt := Intersect{quads, AllNodes{}, fixed}.Optimize(ctx, qs)
+	require.True(t, opt)
+	require.Equal(t, Intersect{fixed, AllNodes{}, quads}, got)
+
+	// already sorted
+	got, opt = Intersect{fixed, AllNodes{}}.Optimize(ctx, qs)
+	require.False(t, opt)
+	require.Equal(t, Intersect{fixed, AllNodes{}}, got)
+
+	// one of the members has no size estimate
+	other := Save{From: AllNodes{}, Tags: []string{"x"}}
+	got, opt = Intersect{AllNodes{}, other, fixed}.Optimize(ctx, qs)
+	require.False(t, opt)
+	require.Equal(t, Intersect{AllNodes{}, other, fixed}, got)
+}
+
+func TestIntersectMinusBuild(t *testing.T) {
+	ctx := context.TODO()
+	qs := ValLookup(nil)
+	s := Intersect{
+		Fixed{intVal(1), intVal(2), intVal(3)},
+		Minus{Exclude: Fixed{intVal(3)}},
+		Fixed{intVal(2), intVal(3)},
+	}
+	it := s.BuildIterator(qs)
+	// negative member must be applied to the intersection of positive ones
+	require.IsType(t, &iterator.Not{}, it)
+	require.IsType(t, &iterator.And{}, it.SubIterators()[1])
+
+	got, err := iterator.Iterate(ctx, it).All()
+	require.NoError(t, err)
+	require.Equal(t, []refs.Ref{intVal(2)}, got)
+}
+
+func TestStableOrderBuild(t *testing.T) {
+	ctx := context.TODO()
+	qs := &graphmock.Store{}
+	a, b, c := refs.PreFetched(quad.String("a")), refs.PreFetched(quad.String("b")), refs.PreFetched(quad.String("c"))
+	s := Page{From: Fixed{c, a, b}, Limit: 2}
+
+	got, err := iterator.Iterate(ctx, BuildIterator(ctx, qs, s)).All()
+	require.NoError(t, err)
+	require.Equal(t, []refs.Ref{c, a}, got)
+
+	// the page must be taken from sorted results
+	ctx = WithStableOrder(ctx, true)
+	got, err = iterator.Iterate(ctx, BuildIterator(ctx, qs, s)).All()
+	require.NoError(t, err)
+	require.Equal(t, []refs.Ref{a, b}, got)
+}
+
+func TestUniqueSortedBuild(t *testing.T) {
+	qs := ValLookup(nil)
+	it := Unique{From: Sort{From: Fixed{intVal(2), intVal(1)}}, Sorted: true}.BuildIterator(qs)
+	require.IsType(t, &iterator.UniqueSorted{}, it)
+
+	it = Unique{From: Fixed{intVal(2), intVal(1)}}.BuildIterator(qs)
+	require.IsType(t, &iterator.Unique{}, it)
+}
+
+// buildCounter is a shape that counts how many times it was built.
+type buildCounter struct {
+	n *int
+}
+
+func (s buildCounter) BuildIterator(qs graph.QuadStore) iterator.Shape {
+	*s.n++
+	return iterator.NewFixed(intVal(1))
+}
+func (s buildCounter) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
+	return s, false
+}
+
+func TestSharedBuild(t *testing.T) {
+	ctx := context.TODO()
+	var n int
+	sub := Save{From: buildCounter{n: &n}, Tags: []string{"id"}}
+	s, _ := Optimize(ctx, Union{sub, sub}, nil)
+	it := s.BuildIterator(nil)
+	require.Equal(t, 1, n, "shared sub-shape must be built once")
+
+	vals, err := iterator.Iterate(ctx, it).All()
+	require.NoError(t, err)
+	require.Equal(t, []refs.Ref{intVal(1), intVal(1)}, vals)
+}
+
 func TestWalk(t *testing.T) {
 	var s Shape = NodesFrom{
 		Dir: quad.Subject,
@@ -395,3 +1021,119 @@ func TestWalk(t *testing.T) {
 		"shape.QuadsAction",
 	}, types)
 }
+
+func TestDescribe(t *testing.T) {
+	var s Shape = NodesFrom{
+		Dir: quad.Subject,
+		Quads: Quads{
+			{Dir: quad.Predicate, Values: Lookup{quad.IRI("follows")}},
+			{Dir: quad.Object, Values: Fixed{intVal(2)}},
+		},
+	}
+	require.Equal(t, `NodesFrom Dir=subject
+  Quads: Quads
+    QuadFilter Dir=predicate
+      Values: Lookup
+        <follows>
+    QuadFilter Dir=object
+      Values: Fixed
+        2
+`, Describe(s))
+}
+
+func TestBoth(t *testing.T) {
+	from, via := Fixed{intVal(1)}, Fixed{intVal(2)}
+	pred := Save{From: via, Tags: []string{"pred"}}
+	require.Equal(t, Union{
+		NodesFrom{
+			Dir: quad.Subject,
+			Quads: Quads{
+				{Dir: quad.Object, Values: from},
+				{Dir: quad.Predicate, Values: pred},
+			},
+		},
+		NodesFrom{
+			Dir: quad.Object,
+			Quads: Quads{
+				{Dir: quad.Subject, Values: from},
+				{Dir: quad.Predicate, Values: pred},
+			},
+		},
+	}, Both(from, via, nil, "pred"))
+}
+
+func TestHas(t *testing.T) {
+	node, via := Fixed{intVal(1)}, Fixed{intVal(2)}
+	require.Equal(t, NodesFrom{
+		Dir: quad.Subject,
+		Quads: Quads{
+			{Dir: quad.Object, Values: node},
+			{Dir: quad.Predicate, Values: via},
+		},
+	}, Has(AllNodes{}, via, node, false))
+	require.Equal(t, NodesFrom{
+		Dir: quad.Object,
+		Quads: Quads{
+			{Dir: quad.Subject, Values: node},
+			{Dir: quad.Predicate, Values: via},
+		},
+	}, Has(AllNodes{}, via, node, true))
+}
+
+func TestSaveQuads(t *testing.T) {
+	from, via := Fixed{intVal(1)}, Fixed{intVal(2)}
+	require.Equal(t, Save{
+		Tags: []string{"pred"},
+		From: NodesFrom{
+			Dir: quad.Object,
+			Quads: Save{
+				Tags: []string{"link"},
+				From: Quads{
+					{Dir: quad.Subject, Values: from},
+					{Dir: quad.Predicate, Values: via},
+				},
+			},
+		},
+	}, SaveQuads(Save{Tags: []string{"pred"}, From: Out(from, via, nil)}, "link"))
+	require.IsType(t, Error{}, SaveQuads(from, "link"))
+	require.Equal(t, Null{}, SaveQuads(Null{}, "link"))
+}
+
+// batchLookup is a quad store that only supports batch lookups.
+type batchLookup struct {
+	ValLookup
+	batches *int
+}
+
+func (qs batchLookup) ValueOf(v quad.Value) (refs.Ref, error) {
+	panic("batch lookup must be used")
+}
+func (qs batchLookup) RefsOf(ctx context.Context, nodes []quad.Value) ([]refs.Ref, error) {
+	*qs.batches++
+	out := make([]refs.Ref, len(nodes))
+	for i, v := range nodes {
+		out[i] = qs.ValLookup[v]
+	}
+	return out, nil
+}
+func (qs batchLookup) ValuesOf(ctx context.Context, vals []refs.Ref) ([]quad.Value, error) {
+	panic("not implemented")
+}
+
+func TestLookupBatch(t *testing.T) {
+	ctx := context.TODO()
+	vals := ValLookup{
+		quad.IRI("a"): intVal(1),
+		quad.IRI("b"): intVal(2),
+	}
+	s := Lookup{quad.IRI("a"), quad.IRI("b"), quad.IRI("missing")}
+
+	var n int
+	got, opt := Optimize(ctx, s, batchLookup{ValLookup: vals, batches: &n})
+	require.True(t, opt)
+	require.Equal(t, 1, n, "values must be resolved in a single batch")
+
+	expect, _ := Optimize(ctx, s, vals)
+	require.Equal(t, Fixed{intVal(1), intVal(2)}, expect)
+	require.Equal(t, expect, got)
+}
@@ -0,0 +1,150 @@
+package shape
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/graph/iterator"
+	"github.com/cayleygraph/quad"
+)
+
+var transforms = make(map[string]iterator.TransformFunc)
+
+// RegisterTransform registers a function that can be referenced by name in Transform shape.
+func RegisterTransform(name string, fnc iterator.TransformFunc) {
+	if fnc == nil {
+		panic("transform function must not be nil")
+	}
+	if _, found := transforms[name]; found {
+		panic(fmt.Sprintf("Already registered transform %q.", name))
+	}
+	transforms[name] = fnc
+}
+
+// TransformFunction returns a transform function registered with a given name, or nil if it doesn't exist.
+func TransformFunction(name string) iterator.TransformFunc {
+	return transforms[name]
+}
+
+func init() {
+	RegisterTransform("concat", transformConcat)
+	RegisterTransform("add", transformAdd)
+	RegisterTransform("lower", transformLower)
+}
+
+// valueText returns a text of the value without any type information.
+func valueText(v quad.Value) string {
+	switch v := v.(type) {
+	case quad.String:
+		return string(v)
+	case quad.IRI:
+		return string(v)
+	case quad.BNode:
+		return string(v)
+	case quad.LangString:
+		return string(v.Value)
+	case quad.TypedString:
+		return string(v.Value)
+	}
+	return quad.StringOf(v)
+}
+
+// transformConcat joins text of all values. Missing values are skipped.
+func transformConcat(args []quad.Value) (quad.Value, error) {
+	var buf strings.Builder
+	for _, v := range args {
+		if v == nil {
+			continue
+		}
+		buf.WriteString(valueText(v))
+	}
+	return quad.String(buf.String()), nil
+}
+
+// transformAdd sums numeric values. The result is an integer if all values are integers.
+func transformAdd(args []quad.Value) (quad.Value, error) {
+	var (
+		isum  quad.Int
+		fsum  quad.Float
+		float bool
+	)
+	for _, v := range args {
+		switch v := v.(type) {
+		case nil:
+			return nil, nil
+		case quad.Int:
+			isum += v
+		case quad.Float:
+			fsum += v
+			float = true
+		default:
+			return nil, fmt.Errorf("add: unsupported value type: %T", v)
+		}
+	}
+	if float {
+		return fsum + quad.Float(isum), nil
+	}
+	return isum, nil
+}
+
+// transformLower converts a single string value to lower case.
+func transformLower(args []quad.Value) (quad.Value, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("lower: expected one argument, got %d", len(args))
+	}
+	switch v := args[0].(type) {
+	case nil:
+		return nil, nil
+	case quad.String:
+		return quad.String(strings.ToLower(string(v))), nil
+	case quad.LangString:
+		v.Value = quad.String(strings.ToLower(string(v.Value)))
+		return v, nil
+	case quad.TypedString:
+		v.Value = quad.String(strings.ToLower(string(v.Value)))
+		return v, nil
+	case quad.IRI:
+		return quad.IRI(strings.ToLower(string(v))), nil
+	default:
+		return nil, fmt.Errorf("lower: unsupported value type: %T", v)
+	}
+}
+
+// Transform applies a registered function to values of tags and saves the result into a new tag.
+//
+// See RegisterTransform.
+type Transform struct {
+	From Shape
+	Func string   // name of registered transform function
+	Args []string // tags to pass to the function
+	Tag  string   // tag to save the result to
+}
+
+func (s Transform) BuildIterator(qs graph.QuadStore) iterator.Shape {
+	if IsNull(s.From) {
+		return iterator.NewNull()
+	}
+	fnc := TransformFunction(s.Func)
+	if fnc == nil {
+		return iterator.NewError(fmt.Errorf("unknown transform function: %q", s.Func))
+	}
+	it := s.From.BuildIterator(qs)
+	return iterator.NewTransform(qs, it, fnc, s.Tag, s.Args...)
+}
+func (s Transform) Optimize(ctx context.Context, r Optimizer) (Shape, bool) {
+	if IsNull(s.From) {
+		return nil, true
+	}
+	var opt bool
+	s.From, opt = s.From.Optimize(ctx, r)
+	if IsNull(s.From) {
+		return nil, true
+	}
+	if r != nil {
+		ns, nopt := r.OptimizeShape(ctx, s)
+		return ns, opt || nopt
+	}
+	return s, opt
+}